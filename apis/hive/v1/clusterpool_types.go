@@ -225,6 +225,16 @@ const (
 	ClusterPoolDeletionPossibleCondition ClusterPoolConditionType = "DeletionPossible"
 )
 
+// PositivePolarityClusterPoolConditions is a slice containing all cluster pool condition types with positive polarity
+// For controllers that handle these conditions, the desired state is True
+// All cluster pool condition types that are not in this slice are assumed to have negative polarity
+var PositivePolarityClusterPoolConditions = []ClusterPoolConditionType{
+	ClusterPoolCapacityAvailableCondition,
+	ClusterPoolAllClustersCurrentCondition,
+	ClusterPoolInventoryValidCondition,
+	ClusterPoolDeletionPossibleCondition,
+}
+
 const (
 	// InventoryReasonValid is used when all ClusterDeploymentCustomization are
 	// available and when used the ClusterDeployments are successfully installed.
@@ -1,5 +1,7 @@
 package metricsconfig
 
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 type MetricsConfig struct {
 	// Optional metrics and their configurations
 	// +optional
@@ -17,4 +19,135 @@ type MetricsConfig struct {
 	// pkg/controller/metrics/metrics_with_dynamic_labels.go
 	// +optional
 	AdditionalClusterDeploymentLabels *map[string]string `json:"additionalClusterDeploymentLabels,omitempty"`
+	// Tenant configures how the tenant owning a ClusterDeployment is determined for metrics reported per tenant,
+	// such as hive_cluster_deployments_per_tenant. Those metrics are only reported when this is set.
+	// +optional
+	Tenant *TenantConfig `json:"tenant,omitempty"`
+	// ProvisioningSLOs are named limits on how long a ClusterDeployment may take to provision. ClusterDeployments
+	// still provisioning once a limit has passed are reported by hive_cluster_deployment_slo_breached.
+	// +optional
+	ProvisioningSLOs []ProvisioningSLO `json:"provisioningSLOs,omitempty"`
+	// ProvisioningUnderwayOwnedBy adds an owned_by label to hive_cluster_deployment_provision_underway_seconds,
+	// naming the ClusterDeployment's controller owner, or else its first owner, as kind/name. ClusterDeployments
+	// without owners are reported as "none".
+	// +optional
+	ProvisioningUnderwayOwnedBy bool `json:"provisioningUnderwayOwnedBy,omitempty"`
+	// ProvisioningUnderwayVersion adds a version label to hive_cluster_deployment_provision_underway_seconds, naming
+	// the OpenShift version each ClusterDeployment is being installed with, or empty until it is known. Every version
+	// adds its own series for each provisioning cluster, so the label is off by default.
+	// +optional
+	ProvisioningUnderwayVersion bool `json:"provisioningUnderwayVersion,omitempty"`
+	// ProvisioningUnderwayProvisionKind adds a provision_kind label to
+	// hive_cluster_deployment_provision_underway_seconds, telling fresh installs ("initial") apart from installs
+	// restarted after failing ("reprovision") and ClusterDeployments whose metadata was supplied by their creator
+	// ("adopted").
+	// +optional
+	ProvisioningUnderwayProvisionKind bool `json:"provisioningUnderwayProvisionKind,omitempty"`
+	// ProvisioningUnderwayQuotaDetail adds a quota_detail label to hive_cluster_deployment_provision_underway_seconds,
+	// naming the quota exhausted by ClusterDeployments failing on a quota, as parsed from their condition message.
+	// Each quota and region adds its own series, so the label is off by default.
+	// +optional
+	ProvisioningUnderwayQuotaDetail bool `json:"provisioningUnderwayQuotaDetail,omitempty"`
+	// ProvisioningUnderwayPostInstallDegraded enables hive_cluster_deployment_post_install_degraded_seconds, reporting
+	// installed ClusterDeployments that have had a post-install condition, such as ClusterImageSetNotFound or
+	// Unreachable, in an undesired state, and for how long.
+	// +optional
+	ProvisioningUnderwayPostInstallDegraded bool `json:"provisioningUnderwayPostInstallDegraded,omitempty"`
+	// InstallRestartsHistogram enables hive_cluster_deployment_install_restarts, the distribution of install restarts
+	// across all provisioning ClusterDeployments, reported alongside
+	// hive_cluster_deployment_provision_underway_install_restarts.
+	// +optional
+	InstallRestartsHistogram bool `json:"installRestartsHistogram,omitempty"`
+	// IncludeClusterTypes, when not empty, limits hive_cluster_deployment_provision_underway_seconds,
+	// hive_cluster_deployment_provision_underway_install_restarts and hive_cluster_deployment_deprovision_underway_seconds
+	// to ClusterDeployments whose cluster_type label value is in the list. ClusterDeployments without the
+	// hive.openshift.io/cluster-type label have the cluster_type "unspecified".
+	// +optional
+	IncludeClusterTypes []string `json:"includeClusterTypes,omitempty"`
+	// ClusterTypeLabel is the ClusterDeployment label whose value the custom collectors report as cluster_type, and
+	// which IncludeClusterTypes and the cluster types of ProvisioningSLOs are matched against. ClusterDeployments
+	// without the label have the cluster_type "unspecified". Defaults to hive.openshift.io/cluster-type.
+	// +optional
+	ClusterTypeLabel string `json:"clusterTypeLabel,omitempty"`
+	// IncludePausedClusters reports ClusterDeployments annotated with hive.openshift.io/reconcile-pause=true in
+	// hive_cluster_deployment_provision_underway_seconds, hive_cluster_deployment_post_install_degraded_seconds and
+	// hive_cluster_deployment_deprovision_underway_seconds, with a paused label telling them apart from the others.
+	// By default paused ClusterDeployments, such as those under maintenance, are left out of these metrics.
+	// +optional
+	IncludePausedClusters bool `json:"includePausedClusters,omitempty"`
+	// ExcessiveProvisionsMax is how many ClusterProvisions a ClusterDeployment may have before it is reported by
+	// hive_cluster_deployment_excessive_provisions. Defaults to 10.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ExcessiveProvisionsMax *int32 `json:"excessiveProvisionsMax,omitempty"`
+	// CollectTimeout bounds how long each metrics collector may spend reading from the API server while a scrape is
+	// served. Collectors that run out of time report what they gathered so far and increment
+	// hive_metrics_collector_timeouts_total. Defaults to 10s.
+	// This is a Duration value; see https://pkg.go.dev/time#ParseDuration for accepted formats.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$"
+	// +optional
+	CollectTimeout *metav1.Duration `json:"collectTimeout,omitempty"`
+	// CollectFromAPIServer makes the metrics collectors list objects from the API server, a page of CollectPageSize
+	// at a time, rather than from the controller's informer cache. Every scrape then lists each kind of object the
+	// collectors report on from the API server, so this is off by default.
+	// +optional
+	CollectFromAPIServer bool `json:"collectFromAPIServer,omitempty"`
+	// CollectPageSize is the number of objects each metrics collector reads from the API server per List request
+	// when CollectFromAPIServer is set, following continue tokens until all objects have been read. 0 reads all
+	// objects in a single request. Defaults to 500.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	CollectPageSize *int64 `json:"collectPageSize,omitempty"`
+	// PerClusterReadMetrics enables the metrics that read a Secret, ConfigMap or ClusterProvision for each
+	// ClusterDeployment on every scrape. These are hive_cluster_deployment_additional_manifest_count,
+	// hive_cluster_deployment_pull_secret_expiring, hive_cluster_deployment_known_install_error,
+	// hive_cluster_deployment_installconfig_mutated, hive_cluster_deployment_certificate_valid_seconds,
+	// hive_cluster_deployment_mirror_install, hive_cluster_deployments_by_fips,
+	// hive_cluster_deployment_instance_family, hive_cluster_deployment_credentials_request_pending,
+	// hive_cluster_deployments_by_cloud_org, hive_cluster_deployment_install_resource_timeout,
+	// hive_cluster_deployments_by_network_type and hive_cluster_deployments_with_proxy. They are off by default, as on
+	// large fleets they make scrapes far more expensive.
+	// +optional
+	PerClusterReadMetrics bool `json:"perClusterReadMetrics,omitempty"`
+	// EnabledMetrics names metrics reported by hive's custom collectors to enable, such as
+	// hive_cluster_deployments_by_fips. Apart from hive_cluster_deployment_provision_underway_seconds,
+	// hive_cluster_deployment_provision_underway_install_restarts and hive_cluster_deployment_deprovision_underway_seconds,
+	// these metrics are off by default. Naming one of the metrics of a collector reporting several enables all of them.
+	// Unknown names are logged and ignored.
+	// +optional
+	EnabledMetrics []string `json:"enabledMetrics,omitempty"`
+	// DisabledMetrics names metrics reported by hive's custom collectors to disable, including those on by default
+	// and those enabled by PerClusterReadMetrics. It takes precedence over EnabledMetrics. Unknown names are logged and
+	// ignored.
+	// +optional
+	DisabledMetrics []string `json:"disabledMetrics,omitempty"`
+}
+
+// TenantConfig identifies the ClusterDeployment label or annotation whose value names the tenant owning the
+// ClusterDeployment. ClusterDeployments carrying neither are reported under the "unknown" tenant.
+type TenantConfig struct {
+	// LabelKey is a ClusterDeployment label key (from metadata.labels) whose value is the tenant.
+	// +optional
+	LabelKey string `json:"labelKey,omitempty"`
+	// AnnotationKey is a ClusterDeployment annotation key (from metadata.annotations) whose value is the tenant.
+	// It is only consulted when the ClusterDeployment does not carry the LabelKey label.
+	// +optional
+	AnnotationKey string `json:"annotationKey,omitempty"`
+}
+
+// ProvisioningSLO is a named limit on how long ClusterDeployments of a given cluster type may take to provision.
+type ProvisioningSLO struct {
+	// Name of the SLO. It is reported as the slo label of hive_cluster_deployment_slo_breached.
+	Name string `json:"name"`
+	// ClusterType restricts the SLO to ClusterDeployments whose hive.openshift.io/cluster-type label matches. When
+	// unset, the SLO applies to all ClusterDeployments.
+	// +optional
+	ClusterType string `json:"clusterType,omitempty"`
+	// Duration is how long a ClusterDeployment may be provisioning, measured from its creation, before the SLO is
+	// breached.
+	// This is a Duration value; see https://pkg.go.dev/time#ParseDuration for accepted formats.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$"
+	Duration metav1.Duration `json:"duration"`
 }
@@ -30,6 +30,46 @@ func (in *MetricsConfig) DeepCopyInto(out *MetricsConfig) {
 			}
 		}
 	}
+	if in.Tenant != nil {
+		in, out := &in.Tenant, &out.Tenant
+		*out = new(TenantConfig)
+		**out = **in
+	}
+	if in.ProvisioningSLOs != nil {
+		in, out := &in.ProvisioningSLOs, &out.ProvisioningSLOs
+		*out = make([]ProvisioningSLO, len(*in))
+		copy(*out, *in)
+	}
+	if in.IncludeClusterTypes != nil {
+		in, out := &in.IncludeClusterTypes, &out.IncludeClusterTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcessiveProvisionsMax != nil {
+		in, out := &in.ExcessiveProvisionsMax, &out.ExcessiveProvisionsMax
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CollectTimeout != nil {
+		in, out := &in.CollectTimeout, &out.CollectTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.CollectPageSize != nil {
+		in, out := &in.CollectPageSize, &out.CollectPageSize
+		*out = new(int64)
+		**out = **in
+	}
+	if in.EnabledMetrics != nil {
+		in, out := &in.EnabledMetrics, &out.EnabledMetrics
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DisabledMetrics != nil {
+		in, out := &in.DisabledMetrics, &out.DisabledMetrics
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -63,3 +103,36 @@ func (in *MetricsWithDuration) DeepCopy() *MetricsWithDuration {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProvisioningSLO) DeepCopyInto(out *ProvisioningSLO) {
+	*out = *in
+	out.Duration = in.Duration
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvisioningSLO.
+func (in *ProvisioningSLO) DeepCopy() *ProvisioningSLO {
+	if in == nil {
+		return nil
+	}
+	out := new(ProvisioningSLO)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenantConfig) DeepCopyInto(out *TenantConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantConfig.
+func (in *TenantConfig) DeepCopy() *TenantConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TenantConfig)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -4,6 +4,7 @@ import (
 	admissionCmd "github.com/openshift/generic-admission-server/pkg/cmd"
 	log "github.com/sirupsen/logrus"
 
+	"k8s.io/component-base/metrics/legacyregistry"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	"github.com/openshift/hive/pkg/util/scheme"
@@ -20,16 +21,19 @@ func main() {
 
 	decoder := createDecoder()
 
+	// The admission server serves the metrics of the legacy registry.
+	legacyregistry.RawMustRegister(hivevalidatingwebhooks.MetricAdmissionRejectionsTotal)
+
 	admissionCmd.RunAdmissionServer(
-		hivevalidatingwebhooks.NewDNSZoneValidatingAdmissionHook(decoder),
-		hivevalidatingwebhooks.NewClusterDeploymentValidatingAdmissionHook(decoder),
-		hivevalidatingwebhooks.NewClusterPoolValidatingAdmissionHook(decoder),
-		hivevalidatingwebhooks.NewClusterImageSetValidatingAdmissionHook(decoder),
-		hivevalidatingwebhooks.NewClusterProvisionValidatingAdmissionHook(decoder),
-		hivevalidatingwebhooks.NewMachinePoolValidatingAdmissionHook(decoder),
-		hivevalidatingwebhooks.NewSyncSetValidatingAdmissionHook(decoder),
-		hivevalidatingwebhooks.NewSelectorSyncSetValidatingAdmissionHook(decoder),
-		hivevalidatingwebhooks.NewClusterDeploymentCustomizationValidatingAdmissionHook(decoder),
+		hivevalidatingwebhooks.WithRejectionMetrics(hivevalidatingwebhooks.NewDNSZoneValidatingAdmissionHook(decoder)),
+		hivevalidatingwebhooks.WithRejectionMetrics(hivevalidatingwebhooks.NewClusterDeploymentValidatingAdmissionHook(decoder)),
+		hivevalidatingwebhooks.WithRejectionMetrics(hivevalidatingwebhooks.NewClusterPoolValidatingAdmissionHook(decoder)),
+		hivevalidatingwebhooks.WithRejectionMetrics(hivevalidatingwebhooks.NewClusterImageSetValidatingAdmissionHook(decoder)),
+		hivevalidatingwebhooks.WithRejectionMetrics(hivevalidatingwebhooks.NewClusterProvisionValidatingAdmissionHook(decoder)),
+		hivevalidatingwebhooks.WithRejectionMetrics(hivevalidatingwebhooks.NewMachinePoolValidatingAdmissionHook(decoder)),
+		hivevalidatingwebhooks.WithRejectionMetrics(hivevalidatingwebhooks.NewSyncSetValidatingAdmissionHook(decoder)),
+		hivevalidatingwebhooks.WithRejectionMetrics(hivevalidatingwebhooks.NewSelectorSyncSetValidatingAdmissionHook(decoder)),
+		hivevalidatingwebhooks.WithRejectionMetrics(hivevalidatingwebhooks.NewClusterDeploymentCustomizationValidatingAdmissionHook(decoder)),
 	)
 }
 
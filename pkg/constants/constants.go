@@ -390,6 +390,10 @@ const (
 	// Reconcile functions.
 	ReconcileIDLen = 8
 
+	// InstallConfigChecksumAnnotation is set on ClusterProvisions to record a checksum of the install-config secret
+	// data the provision was started with, so that changes made to the secret mid-provision can be detected.
+	InstallConfigChecksumAnnotation = "hive.openshift.io/install-config-checksum"
+
 	// SyncSetMetricsGroupAnnotation can be applied to non-selector SyncSets to make them part of a
 	// group for which first applied metrics can be reported
 	SyncSetMetricsGroupAnnotation = "hive.openshift.io/syncset-metrics-group"
@@ -532,6 +536,15 @@ const (
 	// OverrideInstallerImageNameAnnotation specifies the name of the image within release metadata containing the
 	// `openshift-install` command. By default we look for the image named `installer`.
 	OverrideInstallerImageNameAnnotation = "hive.openshift.io/installer-image-name-override"
+
+	// CreatorAnnotation may be set on a ClusterDeployment by whoever creates it to name the principal or service
+	// account responsible for the cluster. It is used for auditing only.
+	CreatorAnnotation = "hive.openshift.io/creator"
+
+	// APIMarkerAnnotation may be set on a ClusterDeployment by whoever creates or migrates it to record the version
+	// of the ClusterDeployment API it was written for, so that clusters still carrying old markers can be found
+	// during CRD migrations. Hive does not act on it.
+	APIMarkerAnnotation = "hive.openshift.io/api-marker"
 )
 
 // GetMergedPullSecretName returns name for merged pull secret name per cluster deployment
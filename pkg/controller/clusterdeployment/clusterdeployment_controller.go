@@ -1,6 +1,7 @@
 package clusterdeployment
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
@@ -299,6 +300,7 @@ func (r *ReconcileClusterDeployment) Reconcile(ctx context.Context, request reco
 			// For additional cleanup logic use finalizers.
 			cdLog.Info("cluster deployment Not Found")
 			r.expectations.DeleteExpectations(request.NamespacedName.String())
+			clearClusterDeploymentMetrics(request.NamespacedName)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -468,6 +470,9 @@ func (r *ReconcileClusterDeployment) addAdditionalKubeconfigCAs(cd *hivev1.Clust
 		cdLog.WithError(err).Log(controllerutils.LogLevel(err), "error updating admin kubeconfig secret")
 		return err
 	}
+	if !bytes.Equal(originalSecret.Data[constants.KubeconfigSecretKey], adminKubeconfigSecret.Data[constants.KubeconfigSecretKey]) {
+		metricKubeconfigRotations.WithLabelValues(cd.Namespace, cd.Name).Inc()
+	}
 
 	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"testing"
@@ -35,6 +36,8 @@ import (
 	"github.com/openshift/library-go/pkg/verify"
 	"github.com/openshift/library-go/pkg/verify/store"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -200,7 +203,11 @@ func TestClusterDeploymentReconcile(t *testing.T) {
 			expectPendingCreation: true,
 			validate: func(c client.Client, t *testing.T) {
 				provisions := getProvisions(c)
-				assert.Len(t, provisions, 1, "expected provision to exist")
+				if assert.Len(t, provisions, 1, "expected provision to exist") {
+					expectedChecksum, err := controllerutils.GetInstallConfigChecksum(testInstallConfigSecretAWS())
+					require.NoError(t, err)
+					assert.Equal(t, expectedChecksum, provisions[0].Annotations[constants.InstallConfigChecksumAnnotation], "unexpected install config checksum on provision")
+				}
 				testassert.AssertConditions(t, getCD(c), []hivev1.ClusterDeploymentCondition{{
 					Type:    hivev1.ProvisionedCondition,
 					Status:  corev1.ConditionFalse,
@@ -3644,6 +3651,147 @@ func TestDeleteOldFailedProvisions(t *testing.T) {
 	}
 }
 
+func TestAddAdditionalKubeconfigCAsRotationMetric(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "additional-ca.crt")
+	require.NoError(t, os.WriteFile(caFile, []byte("additional-ca-data"), 0600))
+	t.Setenv("ADDITIONAL_CA", caFile)
+	require.NoError(t, controllerutils.SetupAdditionalCA())
+	defer func() {
+		// Clear the additional CA data so other tests are unaffected
+		require.NoError(t, os.WriteFile(caFile, nil, 0600))
+		require.NoError(t, controllerutils.SetupAdditionalCA())
+	}()
+
+	logger := log.WithField("controller", "clusterDeployment")
+	cd := testInstalledClusterDeployment(time.Now())
+	fakeClient := testfake.NewFakeClientBuilder().WithRuntimeObjects(
+		cd,
+		testSecret(corev1.SecretTypeOpaque, adminKubeconfigSecret, constants.KubeconfigSecretKey, adminKubeconfig),
+	).Build()
+	rcd := &ReconcileClusterDeployment{
+		Client: fakeClient,
+		scheme: scheme.GetScheme(),
+		logger: logger,
+	}
+	rotations := metricKubeconfigRotations.WithLabelValues(cd.Namespace, cd.Name)
+	before := testutil.ToFloat64(rotations)
+
+	require.NoError(t, rcd.addAdditionalKubeconfigCAs(cd, logger))
+	assert.Equal(t, before+1, testutil.ToFloat64(rotations), "expected rotation to be counted")
+
+	// The kubeconfig already contains the additional CAs, so nothing is rotated the second time around.
+	require.NoError(t, rcd.addAdditionalKubeconfigCAs(cd, logger))
+	assert.Equal(t, before+1, testutil.ToFloat64(rotations), "unexpected rotation counted")
+}
+
+func TestDeletedClusterDeploymentMetricsCleared(t *testing.T) {
+	logger := log.WithField("controller", "clusterDeployment")
+	rcd := &ReconcileClusterDeployment{
+		Client:       testfake.NewFakeClientBuilder().Build(),
+		scheme:       scheme.GetScheme(),
+		logger:       logger,
+		expectations: controllerutils.NewExpectations(logger),
+	}
+	metricKubeconfigRotations.WithLabelValues(testNamespace, testName).Inc()
+	before := testutil.CollectAndCount(metricKubeconfigRotations)
+
+	_, err := rcd.Reconcile(context.TODO(), reconcile.Request{
+		NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: testName},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, before-1, testutil.CollectAndCount(metricKubeconfigRotations), "expected the deleted cluster's rotations to be cleared")
+}
+
+func TestStartNewProvisionRetryDelayMetric(t *testing.T) {
+	// Retry regardless of the failure reason
+	t.Setenv(constants.FailedProvisionConfigFileEnvVar, "")
+	logger := log.WithField("controller", "clusterDeployment")
+	cd := testClusterDeploymentWithDefaultConditions(testClusterDeploymentWithInitializedConditions(testClusterDeployment()))
+	cd.Status.InstallRestarts = 1
+	retryDelay := 10 * time.Minute
+	fakeClient := testfake.NewFakeClientBuilder().WithRuntimeObjects(
+		cd,
+		testInstallConfigSecretAWS(),
+		testSecret(corev1.SecretTypeDockerConfigJson, pullSecretSecret, corev1.DockerConfigJsonKey, "{}"),
+		testProvision(tcp.WithFailureTime(time.Now().Add(-retryDelay)), tcp.Attempt(0)),
+	).Build()
+	rcd := &ReconcileClusterDeployment{
+		Client:       fakeClient,
+		scheme:       scheme.GetScheme(),
+		logger:       logger,
+		expectations: controllerutils.NewExpectations(logger),
+	}
+	before := &dto.Metric{}
+	require.NoError(t, metricRetryDelaySeconds.Write(before))
+
+	cd = &hivev1.ClusterDeployment{}
+	require.NoError(t, fakeClient.Get(context.TODO(), types.NamespacedName{Namespace: testNamespace, Name: testName}, cd))
+	_, err := rcd.startNewProvision(cd, "example.com/openshift-release:latest", logger)
+	require.NoError(t, err)
+
+	after := &dto.Metric{}
+	require.NoError(t, metricRetryDelaySeconds.Write(after))
+	assert.Equal(t, before.GetHistogram().GetSampleCount()+1, after.GetHistogram().GetSampleCount(), "expected one retry delay to be observed")
+	assert.InDelta(t, retryDelay.Seconds(), after.GetHistogram().GetSampleSum()-before.GetHistogram().GetSampleSum(), 5, "unexpected retry delay observed")
+}
+
+func TestObserveProvisionDuration(t *testing.T) {
+	installedAt := time.Now()
+	timestamp := func(d time.Duration) *metav1.Time {
+		ts := metav1.NewTime(installedAt.Add(-d))
+		return &ts
+	}
+
+	cases := []struct {
+		name string
+
+		created        time.Duration
+		installStarted *metav1.Time
+		installed      *metav1.Time
+
+		expectObserved bool
+		expected       time.Duration
+	}{{
+		name:           "provisioned",
+		created:        time.Hour,
+		installStarted: timestamp(45 * time.Minute),
+		installed:      timestamp(0),
+		expectObserved: true,
+		expected:       45 * time.Minute,
+	}, {
+		name:           "install start not recorded",
+		created:        time.Hour,
+		installed:      timestamp(0),
+		expectObserved: true,
+		expected:       time.Hour,
+	}, {
+		name:           "not installed",
+		created:        time.Hour,
+		installStarted: timestamp(45 * time.Minute),
+	}}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cd := testClusterDeployment()
+			cd.CreationTimestamp = *timestamp(tc.created)
+			cd.Status.InstallStartedTimestamp = tc.installStarted
+			cd.Status.InstalledTimestamp = tc.installed
+
+			before := &dto.Metric{}
+			require.NoError(t, metricProvisionDuration.Write(before))
+			observeProvisionDuration(cd, log.WithField("test", tc.name))
+			after := &dto.Metric{}
+			require.NoError(t, metricProvisionDuration.Write(after))
+
+			if !tc.expectObserved {
+				assert.Equal(t, before.GetHistogram().GetSampleCount(), after.GetHistogram().GetSampleCount(), "unexpected provision duration observed")
+				return
+			}
+			assert.Equal(t, before.GetHistogram().GetSampleCount()+1, after.GetHistogram().GetSampleCount(), "expected one provision duration to be observed")
+			assert.InDelta(t, tc.expected.Seconds(), after.GetHistogram().GetSampleSum()-before.GetHistogram().GetSampleSum(), 1, "unexpected provision duration observed")
+		})
+	}
+}
+
 func testEmptyClusterDeployment() *hivev1.ClusterDeployment {
 	cd := &hivev1.ClusterDeployment{
 		TypeMeta: metav1.TypeMeta{
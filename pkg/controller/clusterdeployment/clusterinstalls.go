@@ -210,6 +210,7 @@ func (r *ReconcileClusterDeployment) reconcileExistingInstallingClusterInstall(c
 			installDuration := cd.Status.InstalledTimestamp.Sub(installStartTime.Time)
 			logger.WithField("duration", installDuration.Seconds()).Debug("install job completed")
 			metricInstallJobDuration.Observe(float64(installDuration.Seconds()))
+			observeProvisionDuration(cd, logger)
 
 			metricCompletedInstallJobRestarts.Observe(cd, nil, float64(cd.Status.InstallRestarts))
 
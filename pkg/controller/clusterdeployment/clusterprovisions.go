@@ -185,6 +185,7 @@ func (r *ReconcileClusterDeployment) startNewProvision(
 		},
 	}
 	controllerutils.CopyLogAnnotation(cd, provision)
+	r.setInstallConfigChecksum(cd, provision, logger)
 
 	// Copy over the name, cluster ID and infra ID from previous provision so that a failed install can be removed.
 	if lastFailedProvision != nil {
@@ -247,6 +248,13 @@ func (r *ReconcileClusterDeployment) startNewProvision(
 		kickstartDuration := time.Since(cd.CreationTimestamp.Time)
 		logger.WithField("elapsed", kickstartDuration.Seconds()).Info("calculated time to first provision seconds")
 		metricInstallDelaySeconds.Observe(float64(kickstartDuration.Seconds()))
+	} else if lastFailedProvision != nil {
+		failedCond := controllerutils.FindCondition(lastFailedProvision.Status.Conditions, hivev1.ClusterProvisionFailedCondition)
+		if failedCond != nil && failedCond.Status == corev1.ConditionTrue {
+			retryDelay := time.Since(failedCond.LastTransitionTime.Time)
+			logger.WithField("elapsed", retryDelay.Seconds()).Info("calculated time from provision failure to retry seconds")
+			metricRetryDelaySeconds.Observe(retryDelay.Seconds())
+		}
 	}
 
 	return reconcile.Result{}, nil
@@ -634,6 +642,7 @@ func (r *ReconcileClusterDeployment) reconcileCompletedProvision(cd *hivev1.Clus
 	jobDuration := time.Since(startTime.Time)
 	cdLog.WithField("duration", jobDuration.Seconds()).Debug("install job completed")
 	metricInstallJobDuration.Observe(float64(jobDuration.Seconds()))
+	observeProvisionDuration(cd, cdLog)
 
 	// Report a metric for the total number of install restarts:
 	metricCompletedInstallJobRestarts.Observe(cd, nil, float64(cd.Status.InstallRestarts))
@@ -839,3 +848,26 @@ func (r *ReconcileClusterDeployment) trackClusterProvisionAdd(obj interface{}) {
 		r.expectations.CreationObserved(cdKey)
 	}
 }
+
+// setInstallConfigChecksum records a checksum of the ClusterDeployment's install-config secret on the provision.
+// Failure to do so is logged but does not block provisioning; it only means mid-provision changes to the secret
+// cannot be detected.
+func (r *ReconcileClusterDeployment) setInstallConfigChecksum(cd *hivev1.ClusterDeployment, provision *hivev1.ClusterProvision, logger log.FieldLogger) {
+	if cd.Spec.Provisioning == nil || cd.Spec.Provisioning.InstallConfigSecretRef == nil {
+		return
+	}
+	icSecret := &corev1.Secret{}
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: cd.Namespace, Name: cd.Spec.Provisioning.InstallConfigSecretRef.Name}, icSecret); err != nil {
+		logger.WithError(err).Warn("could not load install config secret to record its checksum")
+		return
+	}
+	checksum, err := controllerutils.GetInstallConfigChecksum(icSecret)
+	if err != nil {
+		logger.WithError(err).Warn("could not compute install config checksum")
+		return
+	}
+	if provision.Annotations == nil {
+		provision.Annotations = map[string]string{}
+	}
+	provision.Annotations[constants.InstallConfigChecksumAnnotation] = checksum
+}
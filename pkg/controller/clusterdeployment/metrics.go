@@ -4,6 +4,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
@@ -20,6 +21,13 @@ var (
 			Buckets: []float64{1800, 2400, 3000, 3600, 4500, 5400, 7200},
 		},
 	)
+	metricProvisionDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "hive_cluster_deployment_provision_duration_seconds",
+			Help:    "Distribution of the time between the start of a cluster's install and the cluster being installed.",
+			Buckets: []float64{1200, 1800, 2400, 3000, 3600, 4500, 5400, 7200},
+		},
+	)
 	metricInstallDelaySeconds = prometheus.NewHistogram(
 		prometheus.HistogramOpts{
 			Name:    "hive_cluster_deployment_install_job_delay_seconds",
@@ -41,6 +49,22 @@ var (
 			Buckets: []float64{10, 30, 60, 300, 600, 1200, 1800},
 		},
 	)
+	metricRetryDelaySeconds = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "hive_cluster_deployment_retry_delay_seconds",
+			Help:    "Time between the failure of a cluster provision and the start of the next provision attempt.",
+			Buckets: []float64{60, 120, 300, 600, 1200, 1800, 3600, 7200},
+		},
+	)
+	// metricKubeconfigRotations tracks the number of times Hive has rewritten the admin kubeconfig of a cluster,
+	// e.g. to inject additional certificate authorities.
+	metricKubeconfigRotations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hive_cluster_deployment_kubeconfig_rotations_total",
+			Help: "Counter incremented every time Hive rotates the admin kubeconfig of a cluster.",
+		},
+		[]string{"namespace", "cluster_deployment"},
+	)
 
 	// Declare the metrics which allow optional labels to be added.
 	// They are defined later once the hive config has been read.
@@ -69,6 +93,27 @@ func incProvisionFailedTerminal(cd *hivev1.ClusterDeployment) {
 	metricProvisionFailedTerminal.Observe(cd, fixedLabels, 1)
 }
 
+// clearClusterDeploymentMetrics deletes the series reported for the ClusterDeployment named by nsName once it is gone,
+// so that per-cluster metrics don't grow with every cluster ever created.
+func clearClusterDeploymentMetrics(nsName types.NamespacedName) {
+	metricKubeconfigRotations.DeleteLabelValues(nsName.Namespace, nsName.Name)
+}
+
+// observeProvisionDuration records how long cd took to provision, from the start of its install, or its creation if
+// the install start was not recorded, until it was installed.
+func observeProvisionDuration(cd *hivev1.ClusterDeployment, logger log.FieldLogger) {
+	if cd.Status.InstalledTimestamp == nil {
+		return
+	}
+	startTime := cd.CreationTimestamp
+	if cd.Status.InstallStartedTimestamp != nil {
+		startTime = *cd.Status.InstallStartedTimestamp
+	}
+	duration := cd.Status.InstalledTimestamp.Sub(startTime.Time)
+	logger.WithField("duration", duration.Seconds()).Debug("cluster provisioned")
+	metricProvisionDuration.Observe(duration.Seconds())
+}
+
 func registerMetrics(mConfig *metricsconfig.MetricsConfig, log log.FieldLogger) {
 	mapClusterTypeLabelToValue := hivemetrics.GetOptionalClusterTypeLabels(mConfig)
 
@@ -115,9 +160,12 @@ func registerMetrics(mConfig *metricsconfig.MetricsConfig, log log.FieldLogger)
 	)
 
 	metrics.Registry.MustRegister(metricInstallJobDuration)
+	metrics.Registry.MustRegister(metricProvisionDuration)
 	metrics.Registry.MustRegister(metricInstallDelaySeconds)
 	metrics.Registry.MustRegister(metricImageSetDelaySeconds)
 	metrics.Registry.MustRegister(metricDNSDelaySeconds)
+	metrics.Registry.MustRegister(metricRetryDelaySeconds)
+	metrics.Registry.MustRegister(metricKubeconfigRotations)
 
 	metricProvisionFailedTerminal.Register()
 	metricCompletedInstallJobRestarts.Register()
@@ -50,6 +50,13 @@ var (
 			Buckets: []float64{60, 300, 600, 1200, 1800, 2400, 3000, 3600},
 		},
 	)
+	metricDeprovisionsCompleted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hive_cluster_deprovisions_completed_total",
+			Help: "Counter incremented every time a cluster deprovision completes successfully.",
+		},
+		[]string{"platform"},
+	)
 
 	// actuators is a list of available actuators for this controller
 	// It is populated via the registerActuator function
@@ -65,6 +72,7 @@ func registerActuator(a Actuator) {
 
 func init() {
 	metrics.Registry.MustRegister(metricUninstallJobDuration)
+	metrics.Registry.MustRegister(metricDeprovisionsCompleted)
 }
 
 // Add creates a new ClusterDeprovision Controller and adds it to the Manager with default RBAC. The Manager will set fields on the Controller
@@ -358,6 +366,7 @@ func (r *ReconcileClusterDeprovision) Reconcile(ctx context.Context, request rec
 			return reconcile.Result{}, err
 		}
 		metricUninstallJobDuration.Observe(float64(jobDuration.Seconds()))
+		metricDeprovisionsCompleted.WithLabelValues(controllerutils.GetClusterDeprovisionPlatform(instance)).Inc()
 		return reconcile.Result{}, nil
 	}
 
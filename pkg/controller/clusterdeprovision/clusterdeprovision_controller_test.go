@@ -7,6 +7,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -57,6 +58,7 @@ func TestClusterDeprovisionReconcile(t *testing.T) {
 		validate                       func(t *testing.T, c client.Client)
 		expectErr                      bool
 		deprovisionsDisabled           bool
+		expectCompletedMetric          bool
 	}{
 		{
 			name: "no-op deleting",
@@ -151,6 +153,7 @@ func TestClusterDeprovisionReconcile(t *testing.T) {
 			validate: func(t *testing.T, c client.Client) {
 				validateCompleted(t, c)
 			},
+			expectCompletedMetric: true,
 		},
 		{
 			name:        "error on failed delete",
@@ -304,6 +307,9 @@ func TestClusterDeprovisionReconcile(t *testing.T) {
 				return mocks.mockAWSClient, nil
 			}}}
 
+			completed := metricDeprovisionsCompleted.WithLabelValues(constants.PlatformAWS)
+			completedBefore := testutil.ToFloat64(completed)
+
 			_, err := r.Reconcile(context.TODO(), reconcile.Request{
 				NamespacedName: types.NamespacedName{
 					Name:      testName,
@@ -324,6 +330,12 @@ func TestClusterDeprovisionReconcile(t *testing.T) {
 				assert.Nil(t, err, "Unexpected error: %v", err)
 			}
 
+			expectedCompleted := completedBefore
+			if test.expectCompletedMetric {
+				expectedCompleted++
+			}
+			assert.Equal(t, expectedCompleted, testutil.ToFloat64(completed), "unexpected number of completed deprovisions")
+
 		})
 	}
 }
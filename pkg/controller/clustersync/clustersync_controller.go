@@ -100,6 +100,13 @@ var (
 			Buckets: []float64{60, 300, 600, 1200, 1800, 2400, 3000, 3600},
 		},
 	)
+
+	metricNoOpReconciles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hive_clustersync_noop_reconciles_total",
+		Help: "Counter incremented each time a ClusterSync is reconciled without any syncsets needing to be applied or its status changing.",
+	},
+		[]string{"namespaced_name"},
+	)
 )
 
 func init() {
@@ -108,6 +115,7 @@ func init() {
 	metrics.Registry.MustRegister(metricResourcesApplied)
 	metrics.Registry.MustRegister(metricTimeToApplySyncSetResource)
 	metrics.Registry.MustRegister(metricTimeToApplySyncSets)
+	metrics.Registry.MustRegister(metricNoOpReconciles)
 }
 
 // Add creates a new clustersync Controller and adds it to the Manager with default RBAC. The Manager will set fields on the Controller
@@ -375,6 +383,8 @@ func (r *ReconcileClusterSync) Reconcile(ctx context.Context, request reconcile.
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			logger.Info("ClusterDeployment not found")
+			// The ClusterSync is deleted along with its ClusterDeployment, so stop reporting it.
+			metricNoOpReconciles.DeleteLabelValues(request.NamespacedName.String())
 			return reconcile.Result{}, nil
 		}
 		logger.WithError(err).Error("failed to get ClusterDeployment")
@@ -528,6 +538,9 @@ func (r *ReconcileClusterSync) Reconcile(ctx context.Context, request reconcile.
 			logger.WithError(err).Log(controllerutils.LogLevel(err), "could not update ClusterSync")
 			return reconcile.Result{}, err
 		}
+	} else if !needToDoFullReapply {
+		// Every syncset was up-to-date, so this reconcile did no work.
+		metricNoOpReconciles.WithLabelValues(request.NamespacedName.String()).Inc()
 	}
 
 	if needToDoFullReapply {
@@ -9,6 +9,7 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -795,6 +796,99 @@ func TestReconcileClusterSync_Reapply(t *testing.T) {
 	}
 }
 
+func TestReconcileClusterSync_NoOpReconcileMetric(t *testing.T) {
+	cases := []struct {
+		name            string
+		renewTime       time.Time
+		generation      int64
+		expectReapply   bool
+		expectedNoOpInc float64
+	}{
+		{
+			name:            "up-to-date",
+			renewTime:       time.Now().Add(-time.Hour),
+			generation:      1,
+			expectedNoOpInc: 1,
+		},
+		{
+			name:       "generation changed",
+			renewTime:  time.Now().Add(-time.Hour),
+			generation: 2,
+		},
+		{
+			name:          "time for reapply",
+			renewTime:     time.Now().Add(-3 * time.Hour),
+			generation:    1,
+			expectReapply: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			scheme := scheme.GetScheme()
+			resourceToApply := testConfigMap("dest-namespace", "dest-name")
+			existing := []runtime.Object{
+				cdBuilder(scheme).Build(),
+				clusterSyncBuilder(scheme).Build(
+					testcs.WithSyncSetStatus(buildSyncStatus("test-syncset",
+						withTransitionInThePast(),
+						withFirstSuccessTimeInThePast(),
+					)),
+					testcs.WithCondition(hiveintv1alpha1.ClusterSyncCondition{
+						Type:               hiveintv1alpha1.ClusterSyncFailed,
+						Status:             corev1.ConditionFalse,
+						Reason:             "Success",
+						Message:            "All SyncSets and SelectorSyncSets have been applied to the cluster",
+						LastTransitionTime: timeInThePast,
+					}),
+					testcs.WithFirstSuccessTime(timeInThePast.Time),
+				),
+				teststatefulset.FullBuilder("hive", stsName, scheme).Build(
+					teststatefulset.WithCurrentReplicas(3),
+					teststatefulset.WithReplicas(3),
+				),
+				testsyncset.FullBuilder(testNamespace, "test-syncset", scheme).Build(
+					testsyncset.ForClusterDeployments(testCDName),
+					testsyncset.WithGeneration(tc.generation),
+					testsyncset.WithResources(resourceToApply),
+				),
+				buildSyncLease(tc.renewTime),
+			}
+			rt := newReconcileTest(t, mockCtrl, scheme, existing...)
+			expectedSyncStatusOpts := []syncStatusOption{
+				withObservedGeneration(tc.generation),
+				withFirstSuccessTimeInThePast(),
+			}
+			if tc.generation == 1 {
+				expectedSyncStatusOpts = append(expectedSyncStatusOpts, withTransitionInThePast())
+			}
+			rt.expectedSyncSetStatuses = []hiveintv1alpha1.SyncStatus{
+				buildSyncStatus("test-syncset", expectedSyncStatusOpts...),
+			}
+			if tc.expectReapply || tc.generation != 1 {
+				rt.mockResourceHelper.EXPECT().Apply(newApplyMatcher(resourceToApply)).Return(resource.CreatedApplyResult, nil)
+			}
+			rt.expectUnchangedLeaseRenewTime = !tc.expectReapply
+			noOps := metricNoOpReconciles.WithLabelValues(types.NamespacedName{Namespace: testNamespace, Name: testCDName}.String())
+			before := testutil.ToFloat64(noOps)
+			rt.run(t)
+			assert.Equal(t, before+tc.expectedNoOpInc, testutil.ToFloat64(noOps), "unexpected number of no-op reconciles")
+		})
+	}
+}
+
+func TestReconcileClusterSync_DeletedClusterDeploymentMetricsCleared(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	rt := newReconcileTest(t, mockCtrl, scheme.GetScheme())
+	nsName := types.NamespacedName{Namespace: testNamespace, Name: testCDName}
+	metricNoOpReconciles.WithLabelValues(nsName.String()).Inc()
+	before := testutil.CollectAndCount(metricNoOpReconciles)
+
+	_, err := rt.r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: nsName})
+	require.NoError(t, err, "unexpected error from Reconcile")
+	assert.Equal(t, before-1, testutil.CollectAndCount(metricNoOpReconciles), "expected the deleted ClusterSync's no-op reconciles to be cleared")
+}
+
 func TestReconcileClusterSync_NewSyncSetApplied(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	scheme := scheme.GetScheme()
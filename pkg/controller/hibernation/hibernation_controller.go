@@ -15,6 +15,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/client-go/util/workqueue"
@@ -163,6 +165,7 @@ func (r *hibernationReconciler) Reconcile(ctx context.Context, request reconcile
 			// Object not found, return.  Created objects are automatically garbage collected.
 			// For additional cleanup logic use finalizers.
 			cdLog.Info("cluster deployment Not Found")
+			clearClusterDeploymentMetrics(request.NamespacedName)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -367,6 +370,7 @@ func (r *hibernationReconciler) Reconcile(ctx context.Context, request reconcile
 	}
 	// If we get here, we're not supposed to be hibernating
 	if isFakeCluster {
+		resuming := hibernatingCondition.Status == corev1.ConditionTrue
 		changed := r.setCDCondition(cd, hivev1.ClusterHibernatingCondition, hivev1.HibernatingReasonResumingOrRunning,
 			clusterResumingOrRunningMsg, corev1.ConditionFalse, cdLog)
 		rChanged := r.setCDCondition(cd, hivev1.ClusterReadyCondition, hivev1.ReadyReasonRunning, clusterRunningMsg,
@@ -376,6 +380,9 @@ func (r *hibernationReconciler) Reconcile(ctx context.Context, request reconcile
 			if err := r.updateClusterDeploymentStatus(cd, cdLog); err != nil {
 				return reconcile.Result{}, err
 			}
+			if resuming {
+				incrementClusterResumedMetric(cd)
+			}
 		}
 		return reconcile.Result{}, nil
 	}
@@ -393,6 +400,11 @@ func (r *hibernationReconciler) startMachines(cd *hivev1.ClusterDeployment, logg
 		return reconcile.Result{}, nil
 	}
 	logger.Info("Resuming cluster")
+	// Clusters are also started once after installing; only count those coming out of hibernation.
+	resuming := false
+	if hibernatingCondition := controllerutils.FindCondition(cd.Status.Conditions, hivev1.ClusterHibernatingCondition); hibernatingCondition != nil {
+		resuming = hibernatingCondition.Status == corev1.ConditionTrue
+	}
 	changed := r.setCDCondition(cd, hivev1.ClusterHibernatingCondition, hivev1.HibernatingReasonResumingOrRunning,
 		clusterResumingOrRunningMsg, corev1.ConditionFalse, logger)
 	rChanged := r.setCDCondition(cd, hivev1.ClusterReadyCondition, hivev1.ReadyReasonStartingMachines,
@@ -411,6 +423,9 @@ func (r *hibernationReconciler) startMachines(cd *hivev1.ClusterDeployment, logg
 		if updateErr := r.updateClusterDeploymentStatus(cd, logger); updateErr != nil {
 			return reconcile.Result{}, updateErr
 		}
+		if resuming {
+			incrementClusterResumedMetric(cd)
+		}
 	}
 	// Return the error (if occurred) starting machines, so we get requeue + backoff
 	return reconcile.Result{}, err
@@ -430,6 +445,10 @@ func (r *hibernationReconciler) stopMachines(cd *hivev1.ClusterDeployment, logge
 	if changed {
 		cd.Status.PowerState = hivev1.ClusterPowerStateStopping
 	}
+	// We won't be checking the ClusterOperators or MachineConfigPools of a stopped cluster, so don't keep reporting
+	// what we saw last.
+	clearClusterOperatorDegradedMetrics(client.ObjectKeyFromObject(cd))
+	clearMachineConfigRolloutPendingMetrics(client.ObjectKeyFromObject(cd))
 	err := actuator.StopMachines(cd, r.Client, logger)
 	if err != nil {
 		msg := fmt.Sprintf("Failed to stop machines: %v (more detail may be available in the logs)", err)
@@ -603,7 +622,13 @@ func (r *hibernationReconciler) checkClusterRunning(cd *hivev1.ClusterDeployment
 			}
 		}
 
-		operatorsReady, err := r.operatorsReady(remoteClient, logger)
+		// Pending machine config rollouts don't keep the cluster from being ready, we only report them while the
+		// cluster is resuming.
+		if readyCondition.Reason != hivev1.ReadyReasonRunning {
+			r.checkMachineConfigRollouts(cd, remoteClient, logger)
+		}
+
+		operatorsReady, err := r.operatorsReady(cd, remoteClient, logger)
 		if err != nil {
 			logger.WithError(err).Log(controllerutils.LogLevel(err), "Failed to check whether ClusterOperators are ready")
 			return reconcile.Result{}, err
@@ -622,6 +647,7 @@ func (r *hibernationReconciler) checkClusterRunning(cd *hivev1.ClusterDeployment
 		}
 	} else {
 		logger.Warn("Skipping ClusterOperator health checks!")
+		clearClusterOperatorDegradedMetrics(client.ObjectKeyFromObject(cd))
 	}
 
 	logger.Info("Cluster has started and is in Running state")
@@ -754,7 +780,7 @@ func (r *hibernationReconciler) nodesReady(cd *hivev1.ClusterDeployment, syncSet
 	return true, nil
 }
 
-func (r *hibernationReconciler) operatorsReady(remoteClient client.Client, logger log.FieldLogger) (bool, error) {
+func (r *hibernationReconciler) operatorsReady(cd *hivev1.ClusterDeployment, remoteClient client.Client, logger log.FieldLogger) (bool, error) {
 	logger.Debug("Checking if ClusterOperators are ready")
 	coList := &configv1.ClusterOperatorList{}
 	err := remoteClient.List(context.TODO(), coList)
@@ -766,10 +792,14 @@ func (r *hibernationReconciler) operatorsReady(remoteClient client.Client, logge
 	success := true
 
 	for _, co := range coList.Items {
+		degraded := false
 		for _, cosc := range co.Status.Conditions {
 			if cosc.Type == "Disabled" && cosc.Status == "True" {
 				continue
 			}
+			if cosc.Type == "Degraded" && cosc.Status == configv1.ConditionTrue {
+				degraded = true
+			}
 
 			// Check that ClusterOperators are in a good state before we consider a cluster ready:
 			if (cosc.Type == "Available" && cosc.Status == configv1.ConditionFalse) ||
@@ -783,10 +813,54 @@ func (r *hibernationReconciler) operatorsReady(remoteClient client.Client, logge
 				success = false
 			}
 		}
+		setClusterOperatorDegradedMetric(cd, co.Name, degraded)
 	}
 	return success, nil
 }
 
+// machineConfigPoolListGVK identifies MachineConfigPools on the spoke cluster. We don't vendor the machineconfiguration
+// API, so they are read as unstructured objects.
+var machineConfigPoolListGVK = schema.GroupVersionKind{
+	Group:   "machineconfiguration.openshift.io",
+	Version: "v1",
+	Kind:    "MachineConfigPoolList",
+}
+
+// checkMachineConfigRollouts reports the MachineConfigPools of the spoke cluster that are still rolling out their
+// machine config. Failures are logged and otherwise ignored.
+func (r *hibernationReconciler) checkMachineConfigRollouts(cd *hivev1.ClusterDeployment, remoteClient client.Client, logger log.FieldLogger) {
+	logger.Debug("Checking for pending MachineConfigPool rollouts")
+	mcpList := &unstructured.UnstructuredList{}
+	mcpList.SetGroupVersionKind(machineConfigPoolListGVK)
+	if err := remoteClient.List(context.TODO(), mcpList); err != nil {
+		logger.WithError(err).Debug("Failed to fetch MachineConfigPools")
+		return
+	}
+	var pendingPools []string
+	for i := range mcpList.Items {
+		if machineConfigRolloutPending(&mcpList.Items[i]) {
+			logger.WithField("machineConfigPool", mcpList.Items[i].GetName()).Info("MachineConfigPool rollout is pending")
+			pendingPools = append(pendingPools, mcpList.Items[i].GetName())
+		}
+	}
+	setMachineConfigRolloutPendingMetrics(cd, pendingPools)
+}
+
+// machineConfigRolloutPending returns true if the MachineConfigPool is updating or has machines that have not yet been
+// updated to its current machine config.
+func machineConfigRolloutPending(mcp *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(mcp.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if ok && cond["type"] == "Updating" && cond["status"] == string(corev1.ConditionTrue) {
+			return true
+		}
+	}
+	machineCount, _, _ := unstructured.NestedInt64(mcp.Object, "status", "machineCount")
+	updatedMachineCount, _, _ := unstructured.NestedInt64(mcp.Object, "status", "updatedMachineCount")
+	return updatedMachineCount < machineCount
+}
+
 func (r *hibernationReconciler) checkCSRs(cd *hivev1.ClusterDeployment, remoteClient client.Client, logger log.FieldLogger) (reconcile.Result, error) {
 	kubeClient, err := r.remoteClientBuilder(cd).BuildKubeClient()
 	if err != nil {
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -14,6 +15,7 @@ import (
 	certsv1 "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	fakekubeclient "k8s.io/client-go/kubernetes/fake"
@@ -1355,6 +1357,392 @@ func unreadyNode() []runtime.Object {
 	return append(readyNodes(), node)
 }
 
+func TestClusterOperatorDegradedMetrics(t *testing.T) {
+	clusterOperator := func(name string, degraded configv1.ConditionStatus) runtime.Object {
+		return &configv1.ClusterOperator{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: configv1.ClusterOperatorStatus{
+				Conditions: []configv1.ClusterOperatorStatusCondition{
+					{Type: "Available", Status: configv1.ConditionTrue},
+					{Type: "Progressing", Status: configv1.ConditionFalse},
+					{Type: "Degraded", Status: degraded},
+				},
+			},
+		}
+	}
+	tests := []struct {
+		name                     string
+		clusterOperators         []runtime.Object
+		expectReady              bool
+		expectDegraded           bool
+		expectMonitoringDegraded bool
+	}{
+		{
+			name: "healthy ingress",
+			clusterOperators: []runtime.Object{
+				clusterOperator("ingress", configv1.ConditionFalse),
+				clusterOperator("dns", configv1.ConditionFalse),
+				clusterOperator("monitoring", configv1.ConditionFalse),
+			},
+			expectReady: true,
+		},
+		{
+			name: "degraded ingress",
+			clusterOperators: []runtime.Object{
+				clusterOperator("ingress", configv1.ConditionTrue),
+				clusterOperator("dns", configv1.ConditionFalse),
+				clusterOperator("monitoring", configv1.ConditionFalse),
+			},
+			expectDegraded: true,
+		},
+		{
+			name: "degraded monitoring",
+			clusterOperators: []runtime.Object{
+				clusterOperator("ingress", configv1.ConditionFalse),
+				clusterOperator("monitoring", configv1.ConditionTrue),
+			},
+			expectMonitoringDegraded: true,
+		},
+		{
+			name: "other operator degraded",
+			clusterOperators: []runtime.Object{
+				clusterOperator("ingress", configv1.ConditionFalse),
+				clusterOperator("dns", configv1.ConditionTrue),
+				clusterOperator("monitoring", configv1.ConditionFalse),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cd := testcd.FullBuilder(namespace, cdName, scheme.GetScheme()).Build()
+			// Start from a degraded state to ensure a healthy operator clears the metric.
+			metricIngressDegraded.WithLabelValues(namespace, cdName).Set(1)
+			metricSpokeMonitoringDegraded.WithLabelValues(namespace, cdName).Set(1)
+			remoteClient := testfake.NewFakeClientBuilder().WithRuntimeObjects(test.clusterOperators...).Build()
+			r := &hibernationReconciler{}
+			ready, err := r.operatorsReady(cd, remoteClient, log.WithField("controller", "hibernation"))
+			require.NoError(t, err)
+			assert.Equal(t, test.expectReady, ready, "unexpected ready result")
+			if test.expectDegraded {
+				assert.Equal(t, 1, testutil.CollectAndCount(metricIngressDegraded), "expected ingress degraded metric")
+				assert.Equal(t, float64(1), testutil.ToFloat64(metricIngressDegraded.WithLabelValues(namespace, cdName)))
+			} else {
+				assert.Equal(t, 0, testutil.CollectAndCount(metricIngressDegraded), "unexpected ingress degraded metric")
+			}
+			if test.expectMonitoringDegraded {
+				assert.Equal(t, float64(1), testutil.ToFloat64(metricSpokeMonitoringDegraded.WithLabelValues(namespace, cdName)))
+			} else {
+				assert.Equal(t, 0, testutil.CollectAndCount(metricSpokeMonitoringDegraded), "unexpected monitoring degraded metric")
+			}
+		})
+	}
+}
+
+func TestClusterOperatorDegradedMetricsCleared(t *testing.T) {
+	o := clusterDeploymentOptions{}
+	cdBuilder := testcd.FullBuilder(namespace, cdName, scheme.GetScheme()).Options(testcd.Installed())
+	tests := []struct {
+		name          string
+		cd            *hivev1.ClusterDeployment
+		setupActuator func(actuator *mock.MockHibernationActuator)
+		setupRemote   func(builder *remoteclientmock.MockBuilder)
+	}{
+		{
+			name: "cluster deployment deleted",
+		},
+		{
+			name: "start hibernating",
+			cd:   cdBuilder.Build(o.shouldHibernate),
+			setupActuator: func(actuator *mock.MockHibernationActuator) {
+				actuator.EXPECT().StopMachines(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil)
+				actuator.EXPECT().MachinesStopped(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(false, []string{"machine-1"}, nil)
+			},
+		},
+		{
+			name: "resume skips cluster operators",
+			cd: cdBuilder.Build(
+				testcd.WithLabel(constants.ResumeSkipsClusterOperatorsLabel, "true"),
+				testcd.WithCondition(hivev1.ClusterDeploymentCondition{
+					Type:   hivev1.ClusterHibernatingCondition,
+					Status: corev1.ConditionFalse,
+					Reason: hivev1.HibernatingReasonResumingOrRunning,
+				}),
+				testcd.WithCondition(hivev1.ClusterDeploymentCondition{
+					Type:   hivev1.ClusterReadyCondition,
+					Status: corev1.ConditionFalse,
+					Reason: hivev1.ReadyReasonWaitingForNodes,
+				}),
+			),
+			setupActuator: func(actuator *mock.MockHibernationActuator) {
+				actuator.EXPECT().MachinesRunning(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(true, nil, nil)
+			},
+			setupRemote: func(builder *remoteclientmock.MockBuilder) {
+				c := testfake.NewFakeClientBuilder().WithRuntimeObjects(append(readyNodes(), degradedClusterOperators()...)...).Build()
+				builder.EXPECT().Build().Times(1).Return(c, nil)
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			metricIngressDegraded.WithLabelValues(namespace, cdName).Set(1)
+			metricSpokeMonitoringDegraded.WithLabelValues(namespace, cdName).Set(1)
+			ctrl := gomock.NewController(t)
+			mockActuator := mock.NewMockHibernationActuator(ctrl)
+			mockActuator.EXPECT().CanHandle(gomock.Any()).AnyTimes().Return(true)
+			if test.setupActuator != nil {
+				test.setupActuator(mockActuator)
+			}
+			mockBuilder := remoteclientmock.NewMockBuilder(ctrl)
+			if test.setupRemote != nil {
+				test.setupRemote(mockBuilder)
+			}
+			actuators = []HibernationActuator{mockActuator}
+			objs := []runtime.Object{
+				testcs.FullBuilder(namespace, cdName, scheme.GetScheme()).Build(testcs.WithFirstSuccessTime(time.Now().Add(-10 * time.Hour))),
+			}
+			if test.cd != nil {
+				objs = append(objs, test.cd)
+			}
+			reconciler := hibernationReconciler{
+				Client: testfake.NewFakeClientBuilder().WithRuntimeObjects(objs...).Build(),
+				logger: log.WithField("controller", "hibernation"),
+				remoteClientBuilder: func(cd *hivev1.ClusterDeployment) remoteclient.Builder {
+					return mockBuilder
+				},
+			}
+			_, err := reconciler.Reconcile(context.TODO(), reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: namespace, Name: cdName},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, 0, testutil.CollectAndCount(metricIngressDegraded), "unexpected ingress degraded metric")
+			assert.Equal(t, 0, testutil.CollectAndCount(metricSpokeMonitoringDegraded), "unexpected monitoring degraded metric")
+		})
+	}
+}
+
+func TestMachineConfigRolloutPendingMetrics(t *testing.T) {
+	machineConfigPool := func(name string, updating string, machineCount, updatedMachineCount int64) runtime.Object {
+		mcp := &unstructured.Unstructured{}
+		mcp.SetAPIVersion("machineconfiguration.openshift.io/v1")
+		mcp.SetKind("MachineConfigPool")
+		mcp.SetName(name)
+		mcp.Object["status"] = map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Updated", "status": "True"},
+				map[string]interface{}{"type": "Updating", "status": updating},
+			},
+			"machineCount":        machineCount,
+			"updatedMachineCount": updatedMachineCount,
+		}
+		return mcp
+	}
+	tests := []struct {
+		name               string
+		machineConfigPools []runtime.Object
+		expectPendingPools []string
+	}{
+		{
+			name: "rollouts complete",
+			machineConfigPools: []runtime.Object{
+				machineConfigPool("master", "False", 3, 3),
+				machineConfigPool("worker", "False", 3, 3),
+			},
+		},
+		{
+			name: "pool updating",
+			machineConfigPools: []runtime.Object{
+				machineConfigPool("master", "False", 3, 3),
+				machineConfigPool("worker", "True", 3, 3),
+			},
+			expectPendingPools: []string{"worker"},
+		},
+		{
+			name: "machines not updated",
+			machineConfigPools: []runtime.Object{
+				machineConfigPool("master", "False", 3, 2),
+				machineConfigPool("worker", "False", 3, 3),
+			},
+			expectPendingPools: []string{"master"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cd := testcd.FullBuilder(namespace, cdName, scheme.GetScheme()).Build()
+			// Start with a stale pending pool to ensure completed rollouts clear the metric.
+			metricMachineConfigRolloutPending.WithLabelValues(namespace, cdName, "stale").Set(1)
+			remoteClient := testfake.NewFakeClientBuilder().WithRuntimeObjects(test.machineConfigPools...).Build()
+			r := &hibernationReconciler{}
+			r.checkMachineConfigRollouts(cd, remoteClient, log.WithField("controller", "hibernation"))
+			assert.Equal(t, len(test.expectPendingPools), testutil.CollectAndCount(metricMachineConfigRolloutPending), "unexpected number of pending pools")
+			for _, pool := range test.expectPendingPools {
+				assert.Equal(t, float64(1), testutil.ToFloat64(metricMachineConfigRolloutPending.WithLabelValues(namespace, cdName, pool)))
+			}
+		})
+	}
+}
+
+func TestMachineConfigRolloutPendingMetricsCleared(t *testing.T) {
+	o := clusterDeploymentOptions{}
+	cdBuilder := testcd.FullBuilder(namespace, cdName, scheme.GetScheme()).Options(testcd.Installed())
+	tests := []struct {
+		name          string
+		cd            *hivev1.ClusterDeployment
+		setupActuator func(actuator *mock.MockHibernationActuator)
+		setupRemote   func(builder *remoteclientmock.MockBuilder)
+		expectPending int
+	}{
+		{
+			name: "cluster deployment deleted",
+		},
+		{
+			name: "start hibernating",
+			cd:   cdBuilder.Build(o.shouldHibernate),
+			setupActuator: func(actuator *mock.MockHibernationActuator) {
+				actuator.EXPECT().StopMachines(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil)
+			},
+		},
+		{
+			name: "running cluster not checked",
+			cd: cdBuilder.Build(
+				testcd.WithCondition(hivev1.ClusterDeploymentCondition{
+					Type:   hivev1.ClusterHibernatingCondition,
+					Status: corev1.ConditionFalse,
+					Reason: hivev1.HibernatingReasonResumingOrRunning,
+				}),
+				testcd.WithCondition(hivev1.ClusterDeploymentCondition{
+					Type:   hivev1.ClusterReadyCondition,
+					Status: corev1.ConditionTrue,
+					Reason: hivev1.ReadyReasonRunning,
+				}),
+			),
+			setupActuator: func(actuator *mock.MockHibernationActuator) {
+				actuator.EXPECT().MachinesRunning(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(true, nil, nil)
+			},
+			setupRemote: func(builder *remoteclientmock.MockBuilder) {
+				// The rollout has completed since we last checked, but we don't look again once the cluster is running.
+				mcp := &unstructured.Unstructured{}
+				mcp.SetAPIVersion("machineconfiguration.openshift.io/v1")
+				mcp.SetKind("MachineConfigPool")
+				mcp.SetName("worker")
+				objs := append(readyNodes(), readyClusterOperators()...)
+				c := testfake.NewFakeClientBuilder().WithRuntimeObjects(append(objs, mcp)...).Build()
+				builder.EXPECT().Build().Times(1).Return(c, nil)
+			},
+			expectPending: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			metricMachineConfigRolloutPending.Reset()
+			metricMachineConfigRolloutPending.WithLabelValues(namespace, cdName, "worker").Set(1)
+			ctrl := gomock.NewController(t)
+			mockActuator := mock.NewMockHibernationActuator(ctrl)
+			mockActuator.EXPECT().CanHandle(gomock.Any()).AnyTimes().Return(true)
+			if test.setupActuator != nil {
+				test.setupActuator(mockActuator)
+			}
+			mockBuilder := remoteclientmock.NewMockBuilder(ctrl)
+			if test.setupRemote != nil {
+				test.setupRemote(mockBuilder)
+			}
+			actuators = []HibernationActuator{mockActuator}
+			objs := []runtime.Object{
+				testcs.FullBuilder(namespace, cdName, scheme.GetScheme()).Build(testcs.WithFirstSuccessTime(time.Now().Add(-10 * time.Hour))),
+			}
+			if test.cd != nil {
+				objs = append(objs, test.cd)
+			}
+			reconciler := hibernationReconciler{
+				Client: testfake.NewFakeClientBuilder().WithRuntimeObjects(objs...).Build(),
+				logger: log.WithField("controller", "hibernation"),
+				remoteClientBuilder: func(cd *hivev1.ClusterDeployment) remoteclient.Builder {
+					return mockBuilder
+				},
+			}
+			_, err := reconciler.Reconcile(context.TODO(), reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: namespace, Name: cdName},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, test.expectPending, testutil.CollectAndCount(metricMachineConfigRolloutPending), "unexpected number of pending pools")
+		})
+	}
+}
+
+func TestClusterResumedMetric(t *testing.T) {
+	o := clusterDeploymentOptions{}
+	tests := []struct {
+		name         string
+		options      []testcd.Option
+		expectResume bool
+	}{
+		{
+			name:         "resume hibernating cluster",
+			options:      []testcd.Option{o.hibernating, o.shouldRun},
+			expectResume: true,
+		},
+		{
+			name: "start newly installed cluster",
+			options: []testcd.Option{o.shouldRun, testcd.WithCondition(hivev1.ClusterDeploymentCondition{
+				Type:   hivev1.ClusterHibernatingCondition,
+				Status: corev1.ConditionFalse,
+				Reason: hivev1.HibernatingReasonSyncSetsApplied,
+			})},
+		},
+		{
+			name: "resume hibernating fake cluster",
+			options: []testcd.Option{o.hibernating, o.shouldRun,
+				testcd.WithAnnotation(constants.HiveFakeClusterAnnotation, "true")},
+			expectResume: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			metricClusterResumedTotal.Reset()
+			ctrl := gomock.NewController(t)
+			mockActuator := mock.NewMockHibernationActuator(ctrl)
+			mockActuator.EXPECT().CanHandle(gomock.Any()).AnyTimes().Return(true)
+			mockActuator.EXPECT().StartMachines(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+			mockActuator.EXPECT().MachinesRunning(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(false, []string{"machine-1"}, nil)
+			actuators = []HibernationActuator{mockActuator}
+			cd := testcd.FullBuilder(namespace, cdName, scheme.GetScheme()).Build(
+				append([]testcd.Option{testcd.Installed()}, test.options...)...)
+			cs := testcs.FullBuilder(namespace, cdName, scheme.GetScheme()).Build(
+				testcs.WithFirstSuccessTime(time.Now().Add(-10 * time.Hour)))
+			reconciler := hibernationReconciler{
+				Client: testfake.NewFakeClientBuilder().WithRuntimeObjects(cd, cs).Build(),
+				logger: log.WithField("controller", "hibernation"),
+			}
+			// Reconciling the resuming cluster again doesn't count another resume.
+			for i := 0; i < 2; i++ {
+				_, err := reconciler.Reconcile(context.TODO(), reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: namespace, Name: cdName},
+				})
+				require.NoError(t, err)
+			}
+			if test.expectResume {
+				assert.Equal(t, float64(1), testutil.ToFloat64(metricClusterResumedTotal.WithLabelValues(namespace, cdName)))
+			} else {
+				assert.Equal(t, 0, testutil.CollectAndCount(metricClusterResumedTotal), "unexpected resumed metric")
+			}
+		})
+	}
+}
+
+func TestClusterResumedMetricCleared(t *testing.T) {
+	metricClusterResumedTotal.Reset()
+	metricClusterResumedTotal.WithLabelValues(namespace, cdName).Inc()
+	metricClusterResumedTotal.WithLabelValues(namespace, "other-cluster-deployment").Inc()
+	reconciler := hibernationReconciler{
+		Client: testfake.NewFakeClientBuilder().Build(),
+		logger: log.WithField("controller", "hibernation"),
+	}
+	_, err := reconciler.Reconcile(context.TODO(), reconcile.Request{
+		NamespacedName: types.NamespacedName{Namespace: namespace, Name: cdName},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, testutil.CollectAndCount(metricClusterResumedTotal), "expected only the deleted cluster's series to be cleared")
+}
+
 func readyClusterOperators() []runtime.Object {
 	cos := make([]runtime.Object, 5)
 	for i := 0; i < len(cos); i++ {
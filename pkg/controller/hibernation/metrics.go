@@ -0,0 +1,108 @@
+package hibernation
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+)
+
+var (
+	// metricIngressDegraded tracks ClusterDeployments whose ingress ClusterOperator was observed as degraded the
+	// last time we checked the ClusterOperators of the spoke cluster.
+	metricIngressDegraded = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hive_cluster_deployment_ingress_degraded",
+		Help: "Whether the ingress ClusterOperator of the cluster was degraded when last checked.",
+	}, []string{"namespace", "cluster_deployment"})
+
+	// metricSpokeMonitoringDegraded tracks ClusterDeployments whose monitoring ClusterOperator was observed as degraded
+	// the last time we checked the ClusterOperators of the spoke cluster.
+	metricSpokeMonitoringDegraded = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hive_cluster_deployment_spoke_monitoring_degraded",
+		Help: "Whether the monitoring ClusterOperator of the cluster was degraded when last checked.",
+	}, []string{"namespace", "cluster_deployment"})
+
+	// metricMachineConfigRolloutPending tracks the spoke MachineConfigPools that had not finished rolling out their
+	// machine config the last time we checked them.
+	metricMachineConfigRolloutPending = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hive_cluster_deployment_machineconfig_rollout_pending",
+		Help: "Whether the MachineConfigPool of the cluster had a machine config rollout pending when last checked.",
+	}, []string{"namespace", "cluster_deployment", "pool"})
+
+	// metricClusterResumedTotal counts the ClusterDeployments resumed from hibernation.
+	metricClusterResumedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hive_cluster_deployment_resumed_total",
+		Help: "Number of times the cluster was resumed from hibernation.",
+	}, []string{"namespace", "cluster_deployment"})
+
+	// clusterOperatorDegradedMetrics maps the names of spoke ClusterOperators to the metric reporting whether they
+	// are degraded.
+	clusterOperatorDegradedMetrics = map[string]*prometheus.GaugeVec{
+		"ingress":    metricIngressDegraded,
+		"monitoring": metricSpokeMonitoringDegraded,
+	}
+)
+
+func init() {
+	metrics.Registry.MustRegister(metricIngressDegraded)
+	metrics.Registry.MustRegister(metricSpokeMonitoringDegraded)
+	metrics.Registry.MustRegister(metricMachineConfigRolloutPending)
+	metrics.Registry.MustRegister(metricClusterResumedTotal)
+}
+
+// setClusterOperatorDegradedMetric reports whether the named ClusterOperator is degraded on the cluster, if we've got
+// a metric for that ClusterOperator.
+func setClusterOperatorDegradedMetric(cd *hivev1.ClusterDeployment, clusterOperator string, degraded bool) {
+	metric, ok := clusterOperatorDegradedMetrics[clusterOperator]
+	if !ok {
+		return
+	}
+	if degraded {
+		metric.WithLabelValues(cd.Namespace, cd.Name).Set(1)
+	} else {
+		metric.DeleteLabelValues(cd.Namespace, cd.Name)
+	}
+}
+
+// clearClusterOperatorDegradedMetrics deletes the ClusterOperator degraded series of the ClusterDeployment named by
+// nsName, for when we are no longer checking the ClusterOperators of its spoke cluster.
+func clearClusterOperatorDegradedMetrics(nsName types.NamespacedName) {
+	for _, metric := range clusterOperatorDegradedMetrics {
+		metric.DeleteLabelValues(nsName.Namespace, nsName.Name)
+	}
+}
+
+// setMachineConfigRolloutPendingMetrics reports the MachineConfigPools of the cluster with a pending rollout, clearing
+// any pools reported by a previous check.
+func setMachineConfigRolloutPendingMetrics(cd *hivev1.ClusterDeployment, pendingPools []string) {
+	clearMachineConfigRolloutPendingMetrics(client.ObjectKeyFromObject(cd))
+	for _, pool := range pendingPools {
+		metricMachineConfigRolloutPending.WithLabelValues(cd.Namespace, cd.Name, pool).Set(1)
+	}
+}
+
+// clearMachineConfigRolloutPendingMetrics deletes the pending MachineConfigPool series of the ClusterDeployment named
+// by nsName.
+func clearMachineConfigRolloutPendingMetrics(nsName types.NamespacedName) {
+	metricMachineConfigRolloutPending.DeletePartialMatch(prometheus.Labels{
+		"namespace":          nsName.Namespace,
+		"cluster_deployment": nsName.Name,
+	})
+}
+
+// incrementClusterResumedMetric counts a resume of the cluster.
+func incrementClusterResumedMetric(cd *hivev1.ClusterDeployment) {
+	metricClusterResumedTotal.WithLabelValues(cd.Namespace, cd.Name).Inc()
+}
+
+// clearClusterDeploymentMetrics deletes the series reported for the ClusterDeployment named by nsName once it is gone,
+// so that per-cluster metrics don't grow with every cluster ever created.
+func clearClusterDeploymentMetrics(nsName types.NamespacedName) {
+	clearClusterOperatorDegradedMetrics(nsName)
+	clearMachineConfigRolloutPendingMetrics(nsName)
+	metricClusterResumedTotal.DeleteLabelValues(nsName.Namespace, nsName.Name)
+}
@@ -2,20 +2,28 @@ package metrics
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 
-	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/clock"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
-	hiveintv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
-	controllerutils "github.com/openshift/hive/pkg/controller/utils"
+	"github.com/openshift/hive/pkg/constants"
 )
 
 var (
@@ -29,380 +37,432 @@ var (
 		hivev1.AuthenticationFailureClusterDeploymentCondition,
 		hivev1.InstallImagesNotResolvedCondition,
 	}
+
+	// ClusterDeployment conditions that could indicate problems with a cluster after it has installed.
+	// First condition appearing True will be used in the metric labels.
+	postInstallDegradedCondition = [...]hivev1.ClusterDeploymentConditionType{
+		hivev1.ClusterImageSetNotFoundCondition,
+		hivev1.UnreachableCondition,
+		hivev1.ControlPlaneCertificateNotFoundCondition,
+		hivev1.IngressCertificateNotFoundCondition,
+		hivev1.SyncSetFailedCondition,
+		hivev1.AWSPrivateLinkFailedClusterDeploymentCondition,
+	}
 )
 
-// provisioning underway metrics collected through a custom prometheus collector
-type provisioningUnderwayCollector struct {
-	client client.Client
+// constMetricDesc is a prometheus.Desc along with the label names it was defined with, kept in canonical
+// (alphabetical) order. Custom collectors report through mustNewConstMetric so label values are matched by name
+// rather than by position, and every collector declares its labels in the same order.
+type constMetricDesc struct {
+	*prometheus.Desc
+	fqName     string
+	help       string
+	labelNames []string
+}
 
-	// minDuration, when non-zero, is the minimum duration after which clusters provisioning
-	// will start becoming part of this metric. When set to zero, all clusters provisioning
-	// will be included in the metric.
-	minDuration time.Duration
+// newConstMetricDesc returns a constMetricDesc with the given variable label names sorted into canonical order.
+func newConstMetricDesc(fqName, help string, labelNames ...string) constMetricDesc {
+	sorted := sortedLabelNames(labelNames)
+	return constMetricDesc{
+		Desc:       prometheus.NewDesc(fqName, help, sorted, nil),
+		fqName:     fqName,
+		help:       help,
+		labelNames: sorted,
+	}
+}
 
-	// metricClusterDeploymentProvisionUnderwaySeconds is a prometheus metric for the number of seconds
-	// between when a still provisioning cluster was created and now.
-	metricClusterDeploymentProvisionUnderwaySeconds *prometheus.Desc
+// withLabels returns a constMetricDesc for the same metric as d, with labelNames added to its variable label names.
+func (d constMetricDesc) withLabels(labelNames ...string) constMetricDesc {
+	return newConstMetricDesc(d.fqName, d.help, append(labelNames, d.labelNames...)...)
 }
 
-// Collect collects the metrics for provisioningUnderwayCollector
-func (cc provisioningUnderwayCollector) Collect(ch chan<- prometheus.Metric) {
-	ccLog := log.WithField("controller", "metrics")
-	ccLog.Info("calculating provisioning underway metrics across all ClusterDeployments")
+// sortedLabelNames returns a copy of labelNames in canonical order.
+func sortedLabelNames(labelNames []string) []string {
+	sorted := append([]string(nil), labelNames...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// mustNewConstMetric builds a constant metric for d, ordering the label values to match d's label names.
+// It panics if labels does not contain exactly the label names of d.
+func (d constMetricDesc) mustNewConstMetric(valueType prometheus.ValueType, value float64, labels prometheus.Labels) prometheus.Metric {
+	labelValues := d.orderLabelValues(make([]string, len(d.labelNames)), labels)
+	return prometheus.MustNewConstMetric(d.Desc, valueType, value, labelValues...)
+}
 
-	// Load all ClusterDeployments so we can accumulate facts about them.
-	clusterDeployments := &hivev1.ClusterDeploymentList{}
-	err := cc.client.List(context.Background(), clusterDeployments)
-	if err != nil {
-		log.WithError(err).Error("error listing cluster deployments")
-		return
+// orderLabelValues fills labelValues, which must be as long as d's label names, with the values of labels in the
+// order of d's label names, and returns it. It panics if labels does not contain exactly the label names of d.
+func (d constMetricDesc) orderLabelValues(labelValues []string, labels prometheus.Labels) []string {
+	if len(labels) != len(d.labelNames) {
+		panic(fmt.Sprintf("expected %d labels %v for %s, got %d", len(d.labelNames), d.labelNames, d.Desc, len(labels)))
 	}
-	for _, cd := range clusterDeployments.Items {
-		if cd.DeletionTimestamp != nil {
-			continue
-		}
-		if cd.Spec.Installed {
-			continue
+	for i, name := range d.labelNames {
+		labelValue, ok := labels[name]
+		if !ok {
+			panic(fmt.Sprintf("missing value for label %q of %s", name, d.Desc))
 		}
+		labelValues[i] = labelValue
+	}
+	return labelValues
+}
 
-		platform := cd.Labels[hivev1.HiveClusterPlatformLabel]
-		imageSet := "none"
-		if cd.Spec.Provisioning != nil && cd.Spec.Provisioning.ImageSetRef != nil {
-			imageSet = cd.Spec.Provisioning.ImageSetRef.Name
-		}
+// constMetricBuffer builds constant metrics for a constMetricDesc from a label map and label value slice that are
+// reused for every metric, so that collectors reporting an object at a time don't allocate them for each object.
+// Constant metrics keep copies of their label values, so the buffer can be refilled as soon as a metric is built.
+// A constMetricBuffer is not safe for concurrent use; collectors get a new one for each Collect.
+type constMetricBuffer struct {
+	desc constMetricDesc
 
-		elapsedDuration := time.Since(cd.CreationTimestamp.Time)
-		if cc.minDuration.Seconds() > 0 && elapsedDuration < cc.minDuration {
-			continue // skip reporting the metric for clusterdeployment until the elapsed time is at least minDuration
-		}
+	// labels holds the label values of the next metric by label name. Every label name of desc must be set before
+	// each metric is built.
+	labels prometheus.Labels
 
-		// Add install failure details for stuck provision
-		condition, reason, skip := getConditionAndReason(cd.Status.Conditions)
-		if skip {
-			continue
-		}
-		// For installing clusters we report the seconds since the cluster was created.
-		ch <- prometheus.MustNewConstMetric(
-			cc.metricClusterDeploymentProvisionUnderwaySeconds,
-			prometheus.GaugeValue,
-			elapsedDuration.Seconds(),
-			cd.Name,
-			cd.Namespace,
-			GetLabelValue(&cd, hivev1.HiveClusterTypeLabel),
-			condition,
-			reason,
-			platform,
-			imageSet,
-		)
+	labelValues []string
+}
 
+// newBuffer returns a constMetricBuffer for d.
+func (d constMetricDesc) newBuffer() *constMetricBuffer {
+	return &constMetricBuffer{
+		desc:        d,
+		labels:      make(prometheus.Labels, len(d.labelNames)),
+		labelValues: make([]string, len(d.labelNames)),
 	}
-
 }
 
-func (cc provisioningUnderwayCollector) Describe(ch chan<- *prometheus.Desc) {
-	prometheus.DescribeByCollect(cc, ch)
+// mustNewConstMetric builds a constant metric for the desc of b from the labels held by b. It panics if b's labels
+// do not contain exactly the label names of the desc.
+func (b *constMetricBuffer) mustNewConstMetric(valueType prometheus.ValueType, value float64) prometheus.Metric {
+	labelValues := b.desc.orderLabelValues(b.labelValues, b.labels)
+	return prometheus.MustNewConstMetric(b.desc.Desc, valueType, value, labelValues...)
 }
 
-var (
-	metricClusterDeploymentProvisionUnderwaySecondsDesc = prometheus.NewDesc(
-		"hive_cluster_deployment_provision_underway_seconds",
-		"Length of time a cluster has been provisioning.",
-		[]string{"cluster_deployment", "namespace", "cluster_type", "condition", "reason", "platform", "image_set"},
-		nil,
-	)
-)
+// namespaceFilter holds path.Match patterns for namespaces whose objects collectors should skip.
+type namespaceFilter []string
 
-func newProvisioningUnderwaySecondsCollector(client client.Client, minimum time.Duration) prometheus.Collector {
-	return provisioningUnderwayCollector{
-		client: client,
-		metricClusterDeploymentProvisionUnderwaySeconds: metricClusterDeploymentProvisionUnderwaySecondsDesc,
-		minDuration: minimum,
+// newNamespaceFilter returns a namespaceFilter for the given patterns. It panics if any pattern is malformed.
+func newNamespaceFilter(patterns []string) namespaceFilter {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			panic(fmt.Sprintf("invalid excluded namespace pattern %q: %v", pattern, err))
+		}
 	}
+	return namespaceFilter(patterns)
 }
 
-// provisioning underway install restarts metrics collected through a custom prometheus collector
-type provisioningUnderwayInstallRestartsCollector struct {
-	client client.Client
+// excludes returns true if namespace matches any of the filter's patterns.
+func (f namespaceFilter) excludes(namespace string) bool {
+	for _, pattern := range f {
+		// Patterns were validated when the filter was created.
+		if match, _ := path.Match(pattern, namespace); match {
+			return true
+		}
+	}
+	return false
+}
 
-	// minRestarts, when non-zero, is the minimum restarts after which clusters provisioning
-	// will start becoming part of the metric. When set to zero, all clusters provisioning
-	// will be included in the metric.
-	minRestarts int
+// clusterTypeLabel is the ClusterDeployment label whose value collectors report as cluster_type.
+type clusterTypeLabel string
 
-	// metricClusterDeploymentProvisionUnderwayInstallRestarts is a prometheus metric for the number of install
-	// restarts for a still provisioning cluster.
-	metricClusterDeploymentProvisionUnderwayInstallRestarts *prometheus.Desc
+// newClusterTypeLabel returns the clusterTypeLabel for key, or for hive.openshift.io/cluster-type if key is empty. It
+// panics if key is not a valid label key.
+func newClusterTypeLabel(key string) clusterTypeLabel {
+	if key == "" {
+		return clusterTypeLabel(hivev1.HiveClusterTypeLabel)
+	}
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		panic(fmt.Sprintf("invalid cluster type label %q: %s", key, strings.Join(errs, "; ")))
+	}
+	return clusterTypeLabel(key)
 }
 
-// Collect collects the metrics for provisioningUnderwayInstallRestartsCollector
-func (cc provisioningUnderwayInstallRestartsCollector) Collect(ch chan<- prometheus.Metric) {
-	ccLog := log.WithField("controller", "metrics")
-	ccLog.Info("calculating provisioning underway install restarts metrics across all ClusterDeployments")
+// value returns the cluster_type of obj, which is "unspecified" for objects without the label.
+func (l clusterTypeLabel) value(obj metav1.Object) string {
+	return GetLabelValue(obj, string(l))
+}
 
-	// Load all ClusterDeployments so we can accumulate facts about them.
-	clusterDeployments := &hivev1.ClusterDeploymentList{}
-	err := cc.client.List(context.Background(), clusterDeployments)
-	if err != nil {
-		log.WithError(err).Error("error listing cluster deployments")
-		return
-	}
-	for _, cd := range clusterDeployments.Items {
-		if cd.DeletionTimestamp != nil {
-			continue
-		}
-		if cd.Spec.Installed {
-			continue
-		}
+// clusterTypeFilter holds the cluster types whose ClusterDeployments collectors should report. An empty filter
+// includes every ClusterDeployment.
+type clusterTypeFilter []string
 
-		platform := cd.Labels[hivev1.HiveClusterPlatformLabel]
-		imageSet := "none"
-		if cd.Spec.Provisioning != nil && cd.Spec.Provisioning.ImageSetRef != nil {
-			imageSet = cd.Spec.Provisioning.ImageSetRef.Name
+// includes returns true if the filter is empty or holds clusterType.
+func (f clusterTypeFilter) includes(clusterType string) bool {
+	if len(f) == 0 {
+		return true
+	}
+	for _, included := range f {
+		if included == clusterType {
+			return true
 		}
+	}
+	return false
+}
 
-		restarts := cd.Status.InstallRestarts
-		if restarts == 0 {
-			continue // skip reporting the metric for clusterdeployment that hasn't restarted at all
-		}
-		if cc.minRestarts > 0 && restarts < cc.minRestarts {
-			continue // skip reporting the metric for clusterdeployment until the InstallRestarts is at least minRestarts
-		}
+// reasonOther is reported in place of condition reasons that are not known to a reasonFilter.
+const reasonOther = "Other"
+
+// knownConditionReasons are the ClusterDeployment condition reasons defined by the hivev1 API, along with the Unknown
+// reason reported when a condition doesn't have one.
+var knownConditionReasons = []string{
+	"Unknown",
+	hivev1.InitializedConditionReason,
+	hivev1.HibernatingReasonResumingOrRunning,
+	hivev1.HibernatingReasonStopping,
+	hivev1.HibernatingReasonWaitingForMachinesToStop,
+	hivev1.HibernatingReasonHibernating,
+	hivev1.HibernatingReasonUnsupported,
+	hivev1.HibernatingReasonFailedToStop,
+	hivev1.HibernatingReasonSyncSetsNotApplied,
+	hivev1.HibernatingReasonSyncSetsApplied,
+	hivev1.HibernatingReasonPowerStatePaused,
+	hivev1.HibernatingReasonClusterDeploymentDeleted,
+	hivev1.ReadyReasonStoppingOrHibernating,
+	hivev1.ReadyReasonStartingMachines,
+	hivev1.ReadyReasonFailedToStartMachines,
+	hivev1.ReadyReasonWaitingForMachines,
+	hivev1.ReadyReasonWaitingForNodes,
+	hivev1.ReadyReasonPausingForClusterOperatorsToSettle,
+	hivev1.ReadyReasonWaitingForClusterOperators,
+	hivev1.ReadyReasonRunning,
+	hivev1.ReadyReasonPowerStatePaused,
+	hivev1.ReadyReasonClusterDeploymentDeleted,
+	hivev1.ProvisionedReasonProvisioning,
+	hivev1.ProvisionedReasonProvisionStopped,
+	hivev1.ProvisionedReasonProvisioned,
+	hivev1.ProvisionedReasonDeprovisioning,
+	hivev1.ProvisionedReasonDeprovisionFailed,
+	hivev1.ProvisionedReasonDeprovisioned,
+}
 
-		// Add install failure details for stuck provision
-		condition, reason, skip := getConditionAndReason(cd.Status.Conditions)
-		if skip {
-			continue
-		}
+// reasonFilter holds the condition reasons that may be reported as reason label values. Controllers may set
+// free-form reasons, so anything else is collapsed to reasonOther to bound the cardinality of the label.
+type reasonFilter sets.Set[string]
 
-		// For installing clusters we report the seconds since the cluster was created.
-		ch <- prometheus.MustNewConstMetric(
-			cc.metricClusterDeploymentProvisionUnderwayInstallRestarts,
-			prometheus.GaugeValue,
-			float64(restarts),
-			cd.Name,
-			cd.Namespace,
-			GetLabelValue(&cd, hivev1.HiveClusterTypeLabel),
-			condition,
-			reason,
-			platform,
-			imageSet,
-		)
+// newReasonFilter returns a reasonFilter allowing knownConditionReasons and additionalReasons.
+func newReasonFilter(additionalReasons []string) reasonFilter {
+	return reasonFilter(sets.New[string](knownConditionReasons...).Insert(additionalReasons...))
+}
 
+// labelValue returns reason if it is allowed by the filter, and reasonOther otherwise.
+func (f reasonFilter) labelValue(reason string) string {
+	if sets.Set[string](f).Has(reason) {
+		return reason
 	}
+	return reasonOther
+}
 
+// defaultCollectTimeout is how long a custom collector may spend reading from the API server during a single
+// Collect when MetricsConfig.CollectTimeout is not set.
+const defaultCollectTimeout = 10 * time.Second
+
+// collectTimeout bounds how long a custom collector may spend reading from the API server during a single Collect.
+// It is set from MetricsConfig.CollectTimeout when the metrics controller is added.
+var collectTimeout = defaultCollectTimeout
+
+// collectScrape is a single Collect of a custom collector. It bounds the reads the collector makes from the API
+// server by collectTimeout, and records their failures under the collector's name:
+//
+//	scrape := newCollectScrape(cc, "provisioning underway metrics across all ClusterDeployments")
+//	defer scrape.done()
+//	pages := scrape.list(cc.client, clusterDeployments)
+//	for pages.next() {
+//		for _, cd := range clusterDeployments.Items { ... }
+//	}
+//	if scrape.listFailed(pages) {
+//		return
+//	}
+type collectScrape struct {
+	// ctx is the context the collector reads through. It expires once collectTimeout has passed.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	collector prometheus.Collector
+
+	log log.FieldLogger
 }
 
-func (cc provisioningUnderwayInstallRestartsCollector) Describe(ch chan<- *prometheus.Desc) {
-	prometheus.DescribeByCollect(cc, ch)
+// newCollectScrape starts a scrape of collector, logging that it is calculating the metrics described. The scrape
+// must be ended with done once the collector has stopped reading.
+func newCollectScrape(collector prometheus.Collector, description string) *collectScrape {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	scrape := &collectScrape{
+		ctx:       ctx,
+		cancel:    cancel,
+		collector: collector,
+		log:       log.WithField("controller", "metrics"),
+	}
+	scrape.log.Debugf("calculating %s", description)
+	return scrape
 }
 
-var (
-	provisioningUnderwayInstallRestartsCollectorDesc = prometheus.NewDesc(
-		"hive_cluster_deployment_provision_underway_install_restarts",
-		"Number install restarts for a cluster that has been provisioning.",
-		[]string{"cluster_deployment", "namespace", "cluster_type", "condition", "reason", "platform", "image_set"},
-		nil,
-	)
-)
+// done ends the scrape.
+func (s *collectScrape) done() {
+	s.cancel()
+}
 
-func newProvisioningUnderwayInstallRestartsCollector(client client.Client, minimum int) prometheus.Collector {
-	return provisioningUnderwayInstallRestartsCollector{
-		client: client,
-		metricClusterDeploymentProvisionUnderwayInstallRestarts: provisioningUnderwayInstallRestartsCollectorDesc,
-		minRestarts: minimum,
+// list returns a listPager reading list through c within the scrape.
+func (s *collectScrape) list(c client.Client, list client.ObjectList) *listPager {
+	return &listPager{
+		ctx:    s.ctx,
+		client: c,
+		list:   list,
 	}
 }
 
-// getConditionAndReason fetches a condition and reason if some condition is found in undesired state.
-// All other conditions apart from provisionDelayConditions are tagged as unknown for metric reporting purposes
-// Reporting of the metric is to be skipped when all conditions are in their desired state
-func getConditionAndReason(conditions []hivev1.ClusterDeploymentCondition) (condition, reason string, skip bool) {
-	condition, reason = "Unknown", "Unknown"
-	for _, delayCondition := range provisioningDelayCondition {
-		if cdCondition := controllerutils.FindCondition(conditions,
-			delayCondition); cdCondition != nil {
-			if cdCondition.Status != corev1.ConditionUnknown &&
-				!controllerutils.IsConditionInDesiredState(*cdCondition) {
-				condition = string(delayCondition)
-				if cdCondition.Reason != "" {
-					reason = cdCondition.Reason
-				}
-				break
-			}
-		}
+// listFailed returns true if pages stopped because a List failed, after recording the failure and logging it unless
+// the scrape timed out. The collector should then report nothing more.
+func (s *collectScrape) listFailed(pages *listPager) bool {
+	if pages.err == nil {
+		return false
 	}
-	// skip reporting if all conditions are in their desired state
-	if condition == "Unknown" && len(conditions) > 0 {
-		if controllerutils.AreAllConditionsInDesiredState(conditions) {
-			skip = true
-		}
+	if !s.readFailed() {
+		s.log.WithError(pages.err).Errorf("error listing %s", strings.TrimSuffix(reflect.TypeOf(pages.list).Elem().Name(), "List"))
 	}
-	return condition, reason, skip
+	return true
 }
 
-// deprovisioning underway metric collected through a custom prometheus collector
-type deprovisioningUnderwayCollector struct {
-	client client.Client
-
-	// TODO: Make metric optional and allow for a minDuration to be specified by user.
-
-	// metricClusterDeploymentDeprovisionUnderwaySeconds is a prometheus metric for the number of seconds
-	// between when a deprovisioning cluster DeletionTimestamp was set and now.
-	metricClusterDeploymentDeprovisionUnderwaySeconds *prometheus.Desc
+// readFailed records that a read of the scrape failed, and returns true if that is because the scrape timed out, in
+// which case the collector should stop reading, leaving the metrics it has already emitted as the result of the
+// scrape. A timeout is logged and counted in hive_metrics_collector_timeouts_total, and any other failure in
+// hive_metrics_collector_errors_total, both under the collector's name.
+func (s *collectScrape) readFailed() bool {
+	name := collectorName(s.collector)
+	if !errors.Is(s.ctx.Err(), context.DeadlineExceeded) {
+		metricCollectorErrorsTotal.WithLabelValues(name).Inc()
+		return false
+	}
+	s.log.WithField("collector", name).WithField("timeout", collectTimeout).
+		Warn("timed out collecting metrics, reporting partial results")
+	metricCollectorTimeoutsTotal.WithLabelValues(name).Inc()
+	return true
 }
 
-// Collect collects the metrics for deprovisioningUnderwayCollector
-func (cc deprovisioningUnderwayCollector) Collect(ch chan<- prometheus.Metric) {
-	ccLog := log.WithField("controller", "metrics")
-	ccLog.Info("calculating deprovisioning underway metrics across all ClusterDeployments")
+// collectorName returns the name the health of collector is reported under, that of its type.
+func collectorName(collector prometheus.Collector) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", collector), "metrics.")
+}
 
-	// Load all ClusterDeployments so we can accumulate facts about them.
-	clusterDeployments := &hivev1.ClusterDeploymentList{}
-	err := cc.client.List(context.Background(), clusterDeployments)
-	if err != nil {
-		log.WithError(err).Error("error listing cluster deployments")
-		return
-	}
-	for _, cd := range clusterDeployments.Items {
-		if cd.DeletionTimestamp == nil {
-			continue
-		}
+// timedCollector wraps a custom collector, recording how long each of its scrapes takes in
+// hive_metrics_collector_scrape_duration_seconds under name.
+type timedCollector struct {
+	prometheus.Collector
 
-		elapsedDuration := time.Since(cd.DeletionTimestamp.Time)
+	// name is the collector label the scrape duration is recorded under.
+	name string
 
-		// For installing clusters we report the seconds since the cluster was created.
-		ch <- prometheus.MustNewConstMetric(
-			cc.metricClusterDeploymentDeprovisionUnderwaySeconds,
-			prometheus.GaugeValue,
-			elapsedDuration.Seconds(),
-			cd.Name,
-			cd.Namespace,
-			GetLabelValue(&cd, hivev1.HiveClusterTypeLabel),
-		)
+	// clock is the source of the current time.
+	clock clock.PassiveClock
+}
 
+// newTimedCollector returns collector wrapped in a timedCollector named for its type.
+func newTimedCollector(collector prometheus.Collector) prometheus.Collector {
+	return timedCollector{
+		Collector: collector,
+		name:      collectorName(collector),
+		clock:     clock.RealClock{},
 	}
+}
 
+// Unwrap returns the wrapped collector, such as to change the settings of a collector returned by NewCollectors.
+func (tc timedCollector) Unwrap() prometheus.Collector {
+	return tc.Collector
 }
 
-func (cc deprovisioningUnderwayCollector) Describe(ch chan<- *prometheus.Desc) {
-	prometheus.DescribeByCollect(cc, ch)
+// Collect collects the metrics of the wrapped collector and records how long that took, including the reads from the
+// API server, whether or not they succeeded.
+func (tc timedCollector) Collect(ch chan<- prometheus.Metric) {
+	start := tc.clock.Now()
+	defer func() {
+		metricCollectorScrapeDurationSeconds.WithLabelValues(tc.name).Set(tc.clock.Since(start).Seconds())
+	}()
+	tc.Collector.Collect(ch)
 }
 
-var (
-	metricClusterDeploymentDeprovisionUnderwaySecondsDesc = prometheus.NewDesc(
-		"hive_cluster_deployment_deprovision_underway_seconds",
-		"Length of time a cluster has been deprovisioning.",
-		[]string{"cluster_deployment", "namespace", "cluster_type"},
-		nil,
-	)
-)
+// liveSetting is a collector setting that can be changed while the collector is registered. Collectors are copied
+// by value, so they hold a pointer to the setting and every copy sees a change.
+type liveSetting[T any] struct {
+	mu    sync.RWMutex
+	value T
+}
 
-func newDeprovisioningUnderwaySecondsCollector(client client.Client) prometheus.Collector {
-	return deprovisioningUnderwayCollector{
-		client: client,
-		metricClusterDeploymentDeprovisionUnderwaySeconds: metricClusterDeploymentDeprovisionUnderwaySecondsDesc,
-	}
+// newLiveSetting returns a liveSetting holding value.
+func newLiveSetting[T any](value T) *liveSetting[T] {
+	return &liveSetting[T]{value: value}
 }
 
-// clustersync failing metric collected through a custom prometheus collector
-type clusterSyncFailingCollector struct {
-	client client.Client
+// get returns the current value of the setting.
+func (s *liveSetting[T]) get() T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.value
+}
 
-	// minDuration, when non-zero, is the minimum duration after which clustersync failure
-	// will start becoming part of this metric. When set to zero, all clustersync failures
-	// will be included in the metric.
-	minDuration time.Duration
+// set replaces the value of the setting, taking effect from the next Collect.
+func (s *liveSetting[T]) set(value T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.value = value
+}
 
-	// metricClusterSyncFailingSeconds is a prometheus metric for the number of seconds
-	// between the start of a failing clustersync and now.
-	metricClusterSyncFailingSeconds *prometheus.Desc
+// defaultCollectPageSize is the number of objects a custom collector reads per List request from the API server when
+// MetricsConfig.CollectPageSize is not set.
+const defaultCollectPageSize int64 = 500
 
-	// dynamicLabels is a collection of fixed (mandatory) and optional labels for the clusterSyncFailing metric.
-	// It also has labelList which stores the order of labels as defined in Desc. MustNewConstMetric doesn't
-	// accept a map of labels and values, so maintaining the order is needed while observing the metric.
-	dynamicLabels dynamicLabelsForCustomCollectors
-}
+// collectPageSize is the number of objects a custom collector reads per List request, or 0 to read all objects in a
+// single request, as from the informer cache. It is set from MetricsConfig.CollectPageSize when the metrics
+// controller is added with MetricsConfig.CollectFromAPIServer set.
+var collectPageSize int64
 
-// Collect collects the metrics for custerSyncFailingCollector
-func (cc clusterSyncFailingCollector) Collect(ch chan<- prometheus.Metric) {
-	ccLog := log.WithField("controller", "metrics")
-	ccLog.Info("calculating cluster sync failing seconds metrics")
+// listPager lists objects for a custom collector a page of collectPageSize at a time, so that only one page is held
+// in memory while the collector works through it. It is obtained from collectScrape.list. Each page replaces the
+// contents of list, so items must not be referenced once the next page is requested.
+type listPager struct {
+	ctx    context.Context
+	client client.Client
+	list   client.ObjectList
 
-	clusterSyncList := &hiveintv1alpha1.ClusterSyncList{}
-	err := cc.client.List(context.Background(), clusterSyncList)
-	if err != nil {
-		log.WithError(err).Error("error listing all ClusterSyncs")
-		return
-	}
+	continueToken string
+	done          bool
+	err           error
+}
 
-	clusterDeployments := &hivev1.ClusterDeploymentList{}
-	err = cc.client.List(context.Background(), clusterDeployments)
-	if err != nil {
-		log.WithError(err).Error("error listing cluster deployments")
-		return
+// next lists the next page into the pager's list, returning false once all pages have been read or a List fails,
+// in which case the error is recorded in err.
+func (p *listPager) next() bool {
+	if p.done {
+		return false
 	}
-
-	for _, cs := range clusterSyncList.Items {
-		// Failing cluster syncs
-		cond := controllerutils.FindCondition(cs.Status.Conditions, hiveintv1alpha1.ClusterSyncFailed)
-		if cond != nil && cond.Status == corev1.ConditionTrue {
-			var cdRef hivev1.ClusterDeployment
-			for _, cd := range clusterDeployments.Items {
-				if cd.Namespace == cs.Namespace && cd.Name == cs.Name {
-					cdRef = cd
-					break
-				}
-			}
-			fixedLabels := make(map[string]string, len(cc.dynamicLabels.fixedLabels))
-			fixedLabels["namespaced_name"] = cs.Namespace + "/" + cs.Name
-			if !reflect.ValueOf(cdRef).IsZero() {
-				if unreachableCondition := controllerutils.FindCondition(cdRef.Status.Conditions, hivev1.UnreachableCondition); unreachableCondition != nil {
-					fixedLabels["unreachable"] = string(unreachableCondition.Status)
-				}
-			}
-			labelValues := cc.dynamicLabels.buildLabelSlice(fixedLabels, &cdRef)
-			seconds := time.Since(cond.LastTransitionTime.Time).Seconds()
-			// check if duration crosses the threshold
-			if cc.minDuration.Seconds() <= seconds {
-				ch <- prometheus.MustNewConstMetric(
-					cc.metricClusterSyncFailingSeconds,
-					prometheus.GaugeValue,
-					seconds,
-					labelValues...,
-				)
-			}
-		}
+	if p.err = p.client.List(p.ctx, p.list, client.Limit(collectPageSize), client.Continue(p.continueToken)); p.err != nil {
+		p.done = true
+		return false
 	}
+	p.continueToken = p.list.GetContinue()
+	p.done = p.continueToken == ""
+	return true
 }
 
-func (cc clusterSyncFailingCollector) Describe(ch chan<- *prometheus.Desc) {
-	prometheus.DescribeByCollect(cc, ch)
+// apiListClient is a client.Client that lists objects from an uncached reader, which honours continue tokens so that
+// listPager reads a page at a time, and reads everything else from the wrapped client. The informer cache ignores
+// continue tokens and stops at the limit, so collectors only page when listing through an apiListClient.
+type apiListClient struct {
+	client.Client
+	apiReader client.Reader
 }
 
-func newClusterSyncFailingCollector(client client.Client, minimum time.Duration, optionalLabels map[string]string) prometheus.Collector {
-	metricName := "hive_clustersync_failing_seconds"
-	baseLabels := dynamicLabels{
-		// namespaced_name would be logged as $namespace/$name
-		// unreachable reports the status of unreachable condition of the corresponding cluster deployment
-		fixedLabels:    []string{"namespaced_name", "unreachable"},
-		optionalLabels: optionalLabels,
-	}
-	if repeatedLabels := baseLabels.getRepeatedLabels(); repeatedLabels != nil {
-		panic(fmt.Sprintf("Label(s) %v in HiveConfig.Spec.AdditionalClusterDeploymentLabels conflict with fixed label(s) for the metric %s. Please rename your label.", repeatedLabels, metricName))
-	}
-	labels := dynamicLabelsForCustomCollectors{
-		dynamicLabels: baseLabels,
-		labelList:     baseLabels.getLabelList(),
-	}
-	return clusterSyncFailingCollector{
-		client: client,
-		metricClusterSyncFailingSeconds: prometheus.NewDesc(
-			metricName,
-			"Length of time a clustersync has been failing",
-			labels.labelList,
-			nil,
-		),
-		minDuration:   minimum,
-		dynamicLabels: labels,
+// newAPIListClient returns a client listing objects from apiReader and reading everything else from c.
+func newAPIListClient(c client.Client, apiReader client.Reader) client.Client {
+	return &apiListClient{
+		Client:    c,
+		apiReader: apiReader,
 	}
 }
+
+// List implements client.Reader
+func (c *apiListClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return c.apiReader.List(ctx, list, opts...)
+}
+
+// isReconcilePaused returns whether cd is annotated for Hive's controllers to leave it alone, as during maintenance.
+func isReconcilePaused(cd *hivev1.ClusterDeployment) bool {
+	paused, err := strconv.ParseBool(cd.Annotations[constants.ReconcilePauseAnnotation])
+	return err == nil && paused
+}
@@ -0,0 +1,1114 @@
+package metrics
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/utils/clock"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/openshift/hive/apis/hive/v1/metricsconfig"
+	"github.com/openshift/hive/pkg/constants"
+	controllerutils "github.com/openshift/hive/pkg/controller/utils"
+)
+
+// custom CA metric collected through a custom prometheus collector
+type customCACollector struct {
+	client client.Client
+
+	// metricClusterDeploymentCustomCA is a prometheus metric reporting ClusterDeployments which configure
+	// additional CA certificates / trust bundles for their platform.
+	metricClusterDeploymentCustomCA constMetricDesc
+}
+
+// Collect collects the metrics for customCACollector
+func (cc customCACollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "custom CA metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if !hasCustomCA(&cd) {
+				continue
+			}
+			ch <- cc.metricClusterDeploymentCustomCA.mustNewConstMetric(
+				prometheus.GaugeValue,
+				1,
+				prometheus.Labels{
+					"cluster_deployment": cd.Name,
+					"namespace":          cd.Namespace,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+func (cc customCACollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentCustomCA.Desc
+}
+
+var (
+	metricClusterDeploymentCustomCADesc = newConstMetricDesc(
+		"hive_cluster_deployment_custom_ca",
+		"Whether the cluster deployment configures additional CA certificates for its platform.",
+		"cluster_deployment", "namespace",
+	)
+)
+
+func newCustomCACollector(client client.Client) prometheus.Collector {
+	return customCACollector{
+		client:                          client,
+		metricClusterDeploymentCustomCA: metricClusterDeploymentCustomCADesc,
+	}
+}
+
+// hasCustomCA returns true if the platform spec of the ClusterDeployment references a secret containing
+// additional CA certificates to be trusted.
+func hasCustomCA(cd *hivev1.ClusterDeployment) bool {
+	switch p := cd.Spec.Platform; {
+	case p.OpenStack != nil:
+		return p.OpenStack.CertificatesSecretRef != nil && p.OpenStack.CertificatesSecretRef.Name != ""
+	case p.VSphere != nil:
+		return p.VSphere.CertificatesSecretRef.Name != ""
+	case p.Ovirt != nil:
+		return p.Ovirt.CertificatesSecretRef.Name != ""
+	}
+	return false
+}
+
+// cluster deployments by platform and region metrics collected through a custom prometheus collector
+type platformRegionCollector struct {
+	client client.Client
+
+	// metricClusterDeploymentsTotal is a prometheus metric for the number of ClusterDeployments by platform and
+	// region.
+	metricClusterDeploymentsTotal constMetricDesc
+}
+
+// regionNone is the region reported for clusters on platforms without regions, such as vSphere or bare metal.
+const regionNone = "none"
+
+// Collect collects the metrics for platformRegionCollector
+func (cc platformRegionCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "platform and region metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	type platformRegion struct {
+		platform, region string
+	}
+	counts := map[platformRegion]int{}
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			platform, region := getClusterDeploymentPlatformAndRegion(&cd)
+			counts[platformRegion{platform: platform, region: region}]++
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+	for key, count := range counts {
+		ch <- cc.metricClusterDeploymentsTotal.mustNewConstMetric(
+			prometheus.GaugeValue,
+			float64(count),
+			prometheus.Labels{
+				"platform": key.platform,
+				"region":   key.region,
+			},
+		)
+	}
+}
+
+// getClusterDeploymentPlatformAndRegion returns the platform cd is installed on and its region, read from the
+// platform-specific spec. The region is regionNone for platforms without regions, and when a regional platform
+// leaves it unset.
+func getClusterDeploymentPlatformAndRegion(cd *hivev1.ClusterDeployment) (platform, region string) {
+	switch p := cd.Spec.Platform; {
+	case p.AlibabaCloud != nil:
+		platform, region = constants.PlatformAlibabaCloud, p.AlibabaCloud.Region
+	case p.AWS != nil:
+		platform, region = constants.PlatformAWS, p.AWS.Region
+	case p.Azure != nil:
+		platform, region = constants.PlatformAzure, p.Azure.Region
+	case p.GCP != nil:
+		platform, region = constants.PlatformGCP, p.GCP.Region
+	case p.IBMCloud != nil:
+		platform, region = constants.PlatformIBMCloud, p.IBMCloud.Region
+	case p.OpenStack != nil:
+		platform = constants.PlatformOpenStack
+	case p.VSphere != nil:
+		platform = constants.PlatformVSphere
+	case p.Ovirt != nil:
+		platform = constants.PlatformOvirt
+	case p.BareMetal != nil:
+		platform = constants.PlatformBaremetal
+	case p.AgentBareMetal != nil:
+		platform = constants.PlatformAgentBaremetal
+	case p.None != nil:
+		platform = constants.PlatformNone
+	default:
+		platform = constants.PlatformUnknown
+	}
+	if region == "" {
+		region = regionNone
+	}
+	return platform, region
+}
+
+func (cc platformRegionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentsTotal.Desc
+}
+
+var (
+	metricClusterDeploymentsTotalDesc = newConstMetricDesc(
+		"hive_cluster_deployments_total",
+		"Number of ClusterDeployments by platform and region.",
+		"platform", "region",
+	)
+)
+
+func newPlatformRegionCollector(client client.Client) prometheus.Collector {
+	return platformRegionCollector{
+		client:                        client,
+		metricClusterDeploymentsTotal: metricClusterDeploymentsTotalDesc,
+	}
+}
+
+// authentication operator degraded metric collected through a custom prometheus collector
+type authDegradedCollector struct {
+	client client.Client
+
+	// metricClusterDeploymentAuthDegraded is a prometheus metric reporting clusters whose authentication operator is
+	// degraded, which can lock users out of the cluster.
+	metricClusterDeploymentAuthDegraded constMetricDesc
+}
+
+// authenticationClusterOperator is the name of the ClusterOperator of the cluster authentication operator.
+const authenticationClusterOperator = "authentication"
+
+// Collect collects the metrics for authDegradedCollector
+func (cc authDegradedCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "authentication operator degraded metrics across all ClusterStates")
+	defer scrape.done()
+
+	// The clusterstate controller mirrors the ClusterOperators of each installed cluster into a ClusterState named
+	// after its ClusterDeployment.
+	clusterStates := &hivev1.ClusterStateList{}
+	pages := scrape.list(cc.client, clusterStates)
+	for pages.next() {
+		for _, cs := range clusterStates.Items {
+			if !isClusterOperatorDegraded(cs.Status.ClusterOperators, authenticationClusterOperator) {
+				continue
+			}
+			ch <- cc.metricClusterDeploymentAuthDegraded.mustNewConstMetric(
+				prometheus.GaugeValue,
+				1,
+				prometheus.Labels{
+					"cluster_deployment": cs.Name,
+					"namespace":          cs.Namespace,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+// isClusterOperatorDegraded returns whether the ClusterOperator named name among operators has its Degraded condition
+// set to True.
+func isClusterOperatorDegraded(operators []hivev1.ClusterOperatorState, name string) bool {
+	for _, operator := range operators {
+		if operator.Name != name {
+			continue
+		}
+		for _, cond := range operator.Conditions {
+			if cond.Type == configv1.OperatorDegraded {
+				return cond.Status == configv1.ConditionTrue
+			}
+		}
+	}
+	return false
+}
+
+func (cc authDegradedCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentAuthDegraded.Desc
+}
+
+var (
+	metricClusterDeploymentAuthDegradedDesc = newConstMetricDesc(
+		"hive_cluster_deployment_auth_degraded",
+		"Whether the authentication operator of an installed cluster is degraded.",
+		"cluster_deployment", "namespace",
+	)
+)
+
+func newAuthDegradedCollector(client client.Client) prometheus.Collector {
+	return authDegradedCollector{
+		client:                              client,
+		metricClusterDeploymentAuthDegraded: metricClusterDeploymentAuthDegradedDesc,
+	}
+}
+
+// cluster image set reference count metrics collected through a custom prometheus collector
+type imageSetReferenceCollector struct {
+	client client.Client
+
+	// metricClusterImageSetReferenceCount is a prometheus metric for the number of ClusterDeployments referencing each
+	// ClusterImageSet.
+	metricClusterImageSetReferenceCount constMetricDesc
+}
+
+// Collect collects the metrics for imageSetReferenceCollector
+func (cc imageSetReferenceCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "cluster image set reference metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	counts := map[string]int{}
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			if cd.Spec.Provisioning == nil || cd.Spec.Provisioning.ImageSetRef == nil {
+				continue
+			}
+			counts[cd.Spec.Provisioning.ImageSetRef.Name]++
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+	for imageSet, count := range counts {
+		ch <- cc.metricClusterImageSetReferenceCount.mustNewConstMetric(
+			prometheus.GaugeValue,
+			float64(count),
+			prometheus.Labels{
+				"image_set": imageSet,
+			},
+		)
+	}
+}
+
+func (cc imageSetReferenceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterImageSetReferenceCount.Desc
+}
+
+var (
+	metricClusterImageSetReferenceCountDesc = newConstMetricDesc(
+		"hive_clusterimageset_reference_count",
+		"Number of ClusterDeployments referencing a ClusterImageSet.",
+		"image_set",
+	)
+)
+
+// newImageSetReferenceCollector returns a collector reporting how many ClusterDeployments reference each
+// ClusterImageSet. ClusterImageSets referenced by no ClusterDeployment are not reported.
+func newImageSetReferenceCollector(client client.Client) prometheus.Collector {
+	return imageSetReferenceCollector{
+		client:                              client,
+		metricClusterImageSetReferenceCount: metricClusterImageSetReferenceCountDesc,
+	}
+}
+
+// cluster image set usage metrics collected through a custom prometheus collector
+type clusterImageSetUsageCollector struct {
+	client client.Client
+
+	// metricClusterImageSetInUse is a prometheus metric for whether any ClusterDeployment references a
+	// ClusterImageSet.
+	metricClusterImageSetInUse constMetricDesc
+
+	// metricClusterImageSetReferencingClusterDeployments is a prometheus metric for the number of ClusterDeployments
+	// referencing a ClusterImageSet.
+	metricClusterImageSetReferencingClusterDeployments constMetricDesc
+}
+
+// Collect collects the metrics for clusterImageSetUsageCollector
+func (cc clusterImageSetUsageCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "cluster image set usage metrics across all ClusterImageSets")
+	defer scrape.done()
+
+	// Count the references of every ClusterDeployment in a single pass, rather than looking them up per image set.
+	references := map[string]int{}
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	cdPages := scrape.list(cc.client, clusterDeployments)
+	for cdPages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			if cd.Spec.Provisioning == nil || cd.Spec.Provisioning.ImageSetRef == nil {
+				continue
+			}
+			references[cd.Spec.Provisioning.ImageSetRef.Name]++
+		}
+	}
+	if scrape.listFailed(cdPages) {
+		return
+	}
+
+	imageSets := &hivev1.ClusterImageSetList{}
+	pages := scrape.list(cc.client, imageSets)
+	for pages.next() {
+		for _, imageSet := range imageSets.Items {
+			count := references[imageSet.Name]
+			labels := prometheus.Labels{
+				"image_set":     imageSet.Name,
+				"release_image": imageSet.Spec.ReleaseImage,
+			}
+			inUse := 0
+			if count > 0 {
+				inUse = 1
+			}
+			ch <- cc.metricClusterImageSetInUse.mustNewConstMetric(prometheus.GaugeValue, float64(inUse), labels)
+			ch <- cc.metricClusterImageSetReferencingClusterDeployments.mustNewConstMetric(prometheus.GaugeValue, float64(count), labels)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+func (cc clusterImageSetUsageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterImageSetInUse.Desc
+	ch <- cc.metricClusterImageSetReferencingClusterDeployments.Desc
+}
+
+var (
+	metricClusterImageSetInUseDesc = newConstMetricDesc(
+		"hive_clusterimageset_in_use",
+		"Whether any ClusterDeployment references a ClusterImageSet.",
+		"image_set", "release_image",
+	)
+	metricClusterImageSetReferencingClusterDeploymentsDesc = newConstMetricDesc(
+		"hive_clusterimageset_referencing_clusterdeployments",
+		"Number of ClusterDeployments referencing a ClusterImageSet.",
+		"image_set", "release_image",
+	)
+)
+
+// newClusterImageSetUsageCollector returns a collector reporting, for every ClusterImageSet, whether and by how many
+// ClusterDeployments it is referenced, so that unused image sets can be found and removed.
+func newClusterImageSetUsageCollector(client client.Client) prometheus.Collector {
+	return clusterImageSetUsageCollector{
+		client:                     client,
+		metricClusterImageSetInUse: metricClusterImageSetInUseDesc,
+		metricClusterImageSetReferencingClusterDeployments: metricClusterImageSetReferencingClusterDeploymentsDesc,
+	}
+}
+
+// unknownTenant is the tenant reported for ClusterDeployments whose tenant could not be determined.
+const unknownTenant = "unknown"
+
+// cluster deployments per tenant metrics collected through a custom prometheus collector
+type tenantCollector struct {
+	client client.Client
+
+	// tenantConfig determines where the tenant of each ClusterDeployment is read from.
+	tenantConfig metricsconfig.TenantConfig
+
+	// metricClusterDeploymentsPerTenant is a prometheus metric for the number of ClusterDeployments
+	// owned by each tenant.
+	metricClusterDeploymentsPerTenant constMetricDesc
+}
+
+// Collect collects the metrics for tenantCollector
+func (cc tenantCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "per tenant metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	counts := map[string]int{}
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			counts[cc.getTenant(&cd)]++
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+	for tenant, count := range counts {
+		ch <- cc.metricClusterDeploymentsPerTenant.mustNewConstMetric(
+			prometheus.GaugeValue,
+			float64(count),
+			prometheus.Labels{
+				"tenant": tenant,
+			},
+		)
+	}
+}
+
+func (cc tenantCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentsPerTenant.Desc
+}
+
+// getTenant returns the tenant owning the ClusterDeployment, preferring the configured label over the configured
+// annotation.
+func (cc tenantCollector) getTenant(cd *hivev1.ClusterDeployment) string {
+	if key := cc.tenantConfig.LabelKey; key != "" {
+		if tenant := cd.Labels[key]; tenant != "" {
+			return tenant
+		}
+	}
+	if key := cc.tenantConfig.AnnotationKey; key != "" {
+		if tenant := cd.Annotations[key]; tenant != "" {
+			return tenant
+		}
+	}
+	return unknownTenant
+}
+
+var (
+	metricClusterDeploymentsPerTenantDesc = newConstMetricDesc(
+		"hive_cluster_deployments_per_tenant",
+		"Total number of cluster deployments owned by each tenant.",
+		"tenant",
+	)
+)
+
+func newTenantCollector(client client.Client, tenantConfig metricsconfig.TenantConfig) prometheus.Collector {
+	return tenantCollector{
+		client:                            client,
+		tenantConfig:                      tenantConfig,
+		metricClusterDeploymentsPerTenant: metricClusterDeploymentsPerTenantDesc,
+	}
+}
+
+var (
+	// hibernationTransitionalHibernatingReasons are ClusterHibernatingCondition reasons reported while a cluster is
+	// on its way to hibernating.
+	hibernationTransitionalHibernatingReasons = sets.New[string](
+		hivev1.HibernatingReasonStopping,
+		hivev1.HibernatingReasonWaitingForMachinesToStop,
+	)
+	// hibernationTransitionalReadyReasons are ClusterReadyCondition reasons reported while a cluster is on its way
+	// to running.
+	hibernationTransitionalReadyReasons = sets.New[string](
+		hivev1.ReadyReasonStartingMachines,
+		hivev1.ReadyReasonWaitingForMachines,
+		hivev1.ReadyReasonWaitingForNodes,
+		hivev1.ReadyReasonPausingForClusterOperatorsToSettle,
+		hivev1.ReadyReasonWaitingForClusterOperators,
+	)
+)
+
+// hibernation transition underway metrics collected through a custom prometheus collector
+type hibernationTransitionUnderwayCollector struct {
+	client client.Client
+
+	// clock is the source of the current time.
+	clock clock.PassiveClock
+
+	// minDuration, when non-zero, is the minimum duration after which clusters transitioning between
+	// power states will start becoming part of this metric. When set to zero, all transitioning clusters
+	// will be included in the metric.
+	minDuration time.Duration
+
+	// clusterTypeLabel is the label whose value is reported as cluster_type.
+	clusterTypeLabel clusterTypeLabel
+
+	// metricClusterDeploymentHibernationTransitionUnderwaySeconds is a prometheus metric for the number of seconds
+	// a cluster has been transitioning between Running and Hibernating.
+	metricClusterDeploymentHibernationTransitionUnderwaySeconds constMetricDesc
+}
+
+// Collect collects the metrics for hibernationTransitionUnderwayCollector
+func (cc hibernationTransitionUnderwayCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "hibernation transition underway metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+
+			hibernatingCondition := controllerutils.FindCondition(cd.Status.Conditions, hivev1.ClusterHibernatingCondition)
+			readyCondition := controllerutils.FindCondition(cd.Status.Conditions, hivev1.ClusterReadyCondition)
+			var currentState string
+			switch {
+			case hibernatingCondition != nil && hibernationTransitionalHibernatingReasons.Has(hibernatingCondition.Reason):
+				currentState = hibernatingCondition.Reason
+			case readyCondition != nil && hibernationTransitionalReadyReasons.Has(readyCondition.Reason):
+				currentState = readyCondition.Reason
+			default:
+				continue
+			}
+
+			// Stopping flips the Ready condition and resuming flips the Hibernating condition, so the most recent
+			// transition of either marks when the cluster started its current power state change.
+			var started time.Time
+			for _, cond := range []*hivev1.ClusterDeploymentCondition{hibernatingCondition, readyCondition} {
+				if cond != nil && cond.LastTransitionTime.Time.After(started) {
+					started = cond.LastTransitionTime.Time
+				}
+			}
+			elapsedDuration := cc.clock.Since(started)
+			if cc.minDuration.Seconds() > 0 && elapsedDuration < cc.minDuration {
+				continue // skip reporting the metric for clusterdeployment until the elapsed time is at least minDuration
+			}
+
+			ch <- cc.metricClusterDeploymentHibernationTransitionUnderwaySeconds.mustNewConstMetric(
+				prometheus.GaugeValue,
+				elapsedDuration.Seconds(),
+				prometheus.Labels{
+					"cluster_deployment": cd.Name,
+					"cluster_type":       cc.clusterTypeLabel.value(&cd),
+					"current_state":      currentState,
+					"namespace":          cd.Namespace,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+func (cc hibernationTransitionUnderwayCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentHibernationTransitionUnderwaySeconds.Desc
+}
+
+var (
+	metricClusterDeploymentHibernationTransitionUnderwaySecondsDesc = newConstMetricDesc(
+		"hive_cluster_deployment_hibernation_transition_underway_seconds",
+		"Length of time a cluster has been transitioning between running and hibernating.",
+		"cluster_deployment", "cluster_type", "current_state", "namespace",
+	)
+)
+
+func newHibernationTransitionUnderwayCollector(client client.Client, minimum time.Duration, clusterTypeLabelKey string) prometheus.Collector {
+	return hibernationTransitionUnderwayCollector{
+		client:           client,
+		clock:            clock.RealClock{},
+		minDuration:      minimum,
+		clusterTypeLabel: newClusterTypeLabel(clusterTypeLabelKey),
+		metricClusterDeploymentHibernationTransitionUnderwaySeconds: metricClusterDeploymentHibernationTransitionUnderwaySecondsDesc,
+	}
+}
+
+// unknownCreator is the creator reported for ClusterDeployments without a creator annotation.
+const unknownCreator = "unknown"
+
+// cluster deployments by creator metrics collected through a custom prometheus collector
+type creatorCollector struct {
+	client client.Client
+
+	// metricClusterDeploymentsByCreator is a prometheus metric for the number of ClusterDeployments
+	// created by each principal or service account.
+	metricClusterDeploymentsByCreator constMetricDesc
+}
+
+// Collect collects the metrics for creatorCollector
+func (cc creatorCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "creator metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	counts := map[string]int{}
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			creator := cd.Annotations[constants.CreatorAnnotation]
+			if creator == "" {
+				creator = unknownCreator
+			}
+			counts[creator]++
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+	for creator, count := range counts {
+		ch <- cc.metricClusterDeploymentsByCreator.mustNewConstMetric(
+			prometheus.GaugeValue,
+			float64(count),
+			prometheus.Labels{
+				"creator": creator,
+			},
+		)
+	}
+}
+
+func (cc creatorCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentsByCreator.Desc
+}
+
+var (
+	metricClusterDeploymentsByCreatorDesc = newConstMetricDesc(
+		"hive_cluster_deployments_by_creator",
+		"Total number of cluster deployments created by each principal or service account.",
+		"creator",
+	)
+)
+
+func newCreatorCollector(client client.Client) prometheus.Collector {
+	return creatorCollector{
+		client:                            client,
+		metricClusterDeploymentsByCreator: metricClusterDeploymentsByCreatorDesc,
+	}
+}
+
+// noAPIMarker is the marker reported for ClusterDeployments without an API marker annotation.
+const noAPIMarker = "none"
+
+// cluster deployments by API marker metrics collected through a custom prometheus collector
+type apiMarkerCollector struct {
+	client client.Client
+
+	// metricClusterDeploymentsByAPIMarker is a prometheus metric for the number of ClusterDeployments carrying each
+	// API marker.
+	metricClusterDeploymentsByAPIMarker constMetricDesc
+}
+
+// Collect collects the metrics for apiMarkerCollector
+func (cc apiMarkerCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "API marker metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	counts := map[string]int{}
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			marker := cd.Annotations[constants.APIMarkerAnnotation]
+			if marker == "" {
+				marker = noAPIMarker
+			}
+			counts[marker]++
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+	for marker, count := range counts {
+		ch <- cc.metricClusterDeploymentsByAPIMarker.mustNewConstMetric(
+			prometheus.GaugeValue,
+			float64(count),
+			prometheus.Labels{
+				"marker": marker,
+			},
+		)
+	}
+}
+
+func (cc apiMarkerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentsByAPIMarker.Desc
+}
+
+var (
+	metricClusterDeploymentsByAPIMarkerDesc = newConstMetricDesc(
+		"hive_cluster_deployments_by_api_marker",
+		"Total number of cluster deployments carrying each API marker.",
+		"marker",
+	)
+)
+
+func newAPIMarkerCollector(client client.Client) prometheus.Collector {
+	return apiMarkerCollector{
+		client:                              client,
+		metricClusterDeploymentsByAPIMarker: metricClusterDeploymentsByAPIMarkerDesc,
+	}
+}
+
+// pull secret expiring metric collected through a custom prometheus collector
+type pullSecretExpiringCollector struct {
+	client client.Client
+
+	// clock is the source of the current time.
+	clock clock.PassiveClock
+
+	// threshold is how close to expiry a pull secret token must be before its cluster is reported.
+	threshold time.Duration
+
+	// metricClusterDeploymentPullSecretExpiring is a prometheus metric reporting ClusterDeployments whose pull secret
+	// contains a token that has expired or will expire within threshold.
+	metricClusterDeploymentPullSecretExpiring constMetricDesc
+}
+
+// Collect collects the metrics for pullSecretExpiringCollector
+func (cc pullSecretExpiringCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "pull secret expiry metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			if cd.Spec.PullSecretRef == nil {
+				continue
+			}
+			cdLog := scrape.log.WithField("clusterDeployment", cd.Namespace+"/"+cd.Name)
+			secret := &corev1.Secret{}
+			if err := cc.client.Get(scrape.ctx, types.NamespacedName{Namespace: cd.Namespace, Name: cd.Spec.PullSecretRef.Name}, secret); err != nil {
+				if scrape.readFailed() {
+					return
+				}
+				cdLog.WithError(err).Warn("error getting pull secret")
+				continue
+			}
+			expiry, ok := getPullSecretExpiry(secret.Data[corev1.DockerConfigJsonKey])
+			if !ok {
+				continue
+			}
+			remaining := expiry.Sub(cc.clock.Now())
+			if remaining > cc.threshold {
+				continue
+			}
+			days := 0
+			if remaining > 0 {
+				days = int(remaining.Hours() / 24)
+			}
+			ch <- cc.metricClusterDeploymentPullSecretExpiring.mustNewConstMetric(
+				prometheus.GaugeValue,
+				1,
+				prometheus.Labels{
+					"cluster_deployment": cd.Name,
+					"days":               fmt.Sprint(days),
+					"namespace":          cd.Namespace,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+func (cc pullSecretExpiringCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentPullSecretExpiring.Desc
+}
+
+var (
+	metricClusterDeploymentPullSecretExpiringDesc = newConstMetricDesc(
+		"hive_cluster_deployment_pull_secret_expiring",
+		"Whether the cluster's pull secret contains a token that has expired or is about to, by whole days remaining.",
+		"cluster_deployment", "days", "namespace",
+	)
+)
+
+// newPullSecretExpiringCollector returns a collector reporting clusters whose pull secret contains a token that has
+// expired or will expire within threshold.
+func newPullSecretExpiringCollector(client client.Client, threshold time.Duration) prometheus.Collector {
+	return pullSecretExpiringCollector{
+		client:    client,
+		clock:     clock.RealClock{},
+		threshold: threshold,
+		metricClusterDeploymentPullSecretExpiring: metricClusterDeploymentPullSecretExpiringDesc,
+	}
+}
+
+// getPullSecretExpiry returns the earliest expiry of the tokens in the given docker config JSON. Only tokens that are
+// JWTs carrying an exp claim have a known expiry; false is returned if there are none.
+func getPullSecretExpiry(dockerConfigJSON []byte) (time.Time, bool) {
+	var config struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(dockerConfigJSON, &config); err != nil {
+		return time.Time{}, false
+	}
+	var earliest time.Time
+	for _, registry := range config.Auths {
+		auth, err := base64.StdEncoding.DecodeString(registry.Auth)
+		if err != nil {
+			continue
+		}
+		_, token, ok := strings.Cut(string(auth), ":")
+		if !ok {
+			continue
+		}
+		expiry, ok := getTokenExpiry(token)
+		if ok && (earliest.IsZero() || expiry.Before(earliest)) {
+			earliest = expiry
+		}
+	}
+	return earliest, !earliest.IsZero()
+}
+
+// getTokenExpiry returns the expiry recorded in the exp claim of a JWT. The signature is not verified.
+func getTokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Exp, 0), true
+}
+
+// certificate expiry metric collected through a custom prometheus collector
+type clusterCertificateExpiryCollector struct {
+	client client.Client
+
+	// clock is the source of the current time.
+	clock clock.PassiveClock
+
+	// clusterTypeLabel is the label whose value is reported as cluster_type.
+	clusterTypeLabel clusterTypeLabel
+
+	// metricClusterDeploymentCertificateValidSeconds is a prometheus metric for the number of seconds until the
+	// certificate in the cluster's admin kubeconfig expires.
+	metricClusterDeploymentCertificateValidSeconds constMetricDesc
+}
+
+// Collect collects the metrics for clusterCertificateExpiryCollector
+func (cc clusterCertificateExpiryCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "certificate expiry metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil || !cd.Spec.Installed {
+				continue
+			}
+			if cd.Spec.ClusterMetadata == nil || cd.Spec.ClusterMetadata.AdminKubeconfigSecretRef.Name == "" {
+				continue
+			}
+			cdLog := scrape.log.WithField("clusterDeployment", cd.Namespace+"/"+cd.Name)
+			secret := &corev1.Secret{}
+			if err := cc.client.Get(scrape.ctx, types.NamespacedName{Namespace: cd.Namespace, Name: cd.Spec.ClusterMetadata.AdminKubeconfigSecretRef.Name}, secret); err != nil {
+				if scrape.readFailed() {
+					return
+				}
+				cdLog.WithError(err).Warn("error getting admin kubeconfig secret")
+				continue
+			}
+			expiry, ok := getKubeconfigCertificateExpiry(secret.Data[constants.KubeconfigSecretKey])
+			if !ok {
+				continue
+			}
+			ch <- cc.metricClusterDeploymentCertificateValidSeconds.mustNewConstMetric(
+				prometheus.GaugeValue,
+				expiry.Sub(cc.clock.Now()).Seconds(),
+				prometheus.Labels{
+					"cluster_deployment": cd.Name,
+					"cluster_type":       cc.clusterTypeLabel.value(&cd),
+					"namespace":          cd.Namespace,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+func (cc clusterCertificateExpiryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentCertificateValidSeconds.Desc
+}
+
+var (
+	metricClusterDeploymentCertificateValidSecondsDesc = newConstMetricDesc(
+		"hive_cluster_deployment_certificate_valid_seconds",
+		"Seconds until the certificate in the cluster's admin kubeconfig expires. Negative once it has expired.",
+		"cluster_deployment", "cluster_type", "namespace",
+	)
+)
+
+// newClusterCertificateExpiryCollector returns a collector reporting how long the certificates in the admin
+// kubeconfig of each installed cluster remain valid.
+func newClusterCertificateExpiryCollector(client client.Client, clusterTypeLabelKey string) prometheus.Collector {
+	return clusterCertificateExpiryCollector{
+		client:           client,
+		clock:            clock.RealClock{},
+		clusterTypeLabel: newClusterTypeLabel(clusterTypeLabelKey),
+		metricClusterDeploymentCertificateValidSeconds: metricClusterDeploymentCertificateValidSecondsDesc,
+	}
+}
+
+// getKubeconfigCertificateExpiry returns the earliest expiry of the client certificates embedded in the given
+// kubeconfig. False is returned if the kubeconfig can't be parsed or embeds no client certificates.
+func getKubeconfigCertificateExpiry(kubeconfig []byte) (time.Time, bool) {
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var earliest time.Time
+	for _, authInfo := range config.AuthInfos {
+		rest := authInfo.ClientCertificateData
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				continue
+			}
+			if earliest.IsZero() || cert.NotAfter.Before(earliest) {
+				earliest = cert.NotAfter
+			}
+		}
+	}
+	return earliest, !earliest.IsZero()
+}
+
+// cluster deployments by cloud org metric collected through a custom prometheus collector
+type cloudOrgCollector struct {
+	client client.Client
+
+	// metricClusterDeploymentsByCloudOrg is a prometheus metric for the number of ClusterDeployments in each Azure
+	// subscription or GCP project.
+	metricClusterDeploymentsByCloudOrg constMetricDesc
+}
+
+// cloudOrgUnknown is used for clusters whose credentials can't be read or don't name their subscription or project.
+const cloudOrgUnknown = "unknown"
+
+// Collect collects the metrics for cloudOrgCollector
+func (cc cloudOrgCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "cloud org metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	type platformOrg struct {
+		platform, org string
+	}
+	counts := map[platformOrg]int{}
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			var platform, credentialsSecret string
+			switch p := cd.Spec.Platform; {
+			case p.Azure != nil:
+				platform, credentialsSecret = constants.PlatformAzure, p.Azure.CredentialsSecretRef.Name
+			case p.GCP != nil:
+				platform, credentialsSecret = constants.PlatformGCP, p.GCP.CredentialsSecretRef.Name
+			default:
+				continue
+			}
+			org := cloudOrgUnknown
+			secret := &corev1.Secret{}
+			switch err := cc.client.Get(scrape.ctx, types.NamespacedName{Namespace: cd.Namespace, Name: credentialsSecret}, secret); {
+			case err == nil:
+				org = getCloudOrg(platform, secret)
+			case apierrors.IsNotFound(err):
+				// Clusters whose credentials are gone are still counted, as unknown.
+			default:
+				if scrape.readFailed() {
+					return
+				}
+				scrape.log.WithError(err).WithField("clusterDeployment", cd.Namespace+"/"+cd.Name).Warn("error getting credentials")
+			}
+			counts[platformOrg{platform: platform, org: org}]++
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+	for key, count := range counts {
+		ch <- cc.metricClusterDeploymentsByCloudOrg.mustNewConstMetric(
+			prometheus.GaugeValue,
+			float64(count),
+			prometheus.Labels{
+				"platform": key.platform,
+				"org":      key.org,
+			},
+		)
+	}
+}
+
+// getCloudOrg returns the Azure subscription or GCP project named by the credentials in secret, or cloudOrgUnknown if
+// they name none.
+func getCloudOrg(platform string, secret *corev1.Secret) string {
+	var key, field string
+	switch platform {
+	case constants.PlatformAzure:
+		key, field = constants.AzureCredentialsName, "subscriptionId"
+	case constants.PlatformGCP:
+		key, field = constants.GCPCredentialsName, "project_id"
+	default:
+		return cloudOrgUnknown
+	}
+	var creds map[string]interface{}
+	if err := json.Unmarshal(secret.Data[key], &creds); err != nil {
+		return cloudOrgUnknown
+	}
+	if org, ok := creds[field].(string); ok && org != "" {
+		return org
+	}
+	return cloudOrgUnknown
+}
+
+func (cc cloudOrgCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentsByCloudOrg.Desc
+}
+
+var (
+	metricClusterDeploymentsByCloudOrgDesc = newConstMetricDesc(
+		"hive_cluster_deployments_by_cloud_org",
+		"Number of ClusterDeployments in each Azure subscription or GCP project.",
+		"platform", "org",
+	)
+)
+
+// newCloudOrgCollector returns a collector counting Azure and GCP ClusterDeployments by the subscription or project
+// named by their credentials.
+func newCloudOrgCollector(client client.Client) prometheus.Collector {
+	return cloudOrgCollector{
+		client:                             client,
+		metricClusterDeploymentsByCloudOrg: metricClusterDeploymentsByCloudOrgDesc,
+	}
+}
@@ -0,0 +1,625 @@
+package metrics
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hivev1aws "github.com/openshift/hive/apis/hive/v1/aws"
+	hivev1azure "github.com/openshift/hive/apis/hive/v1/azure"
+	hivev1gcp "github.com/openshift/hive/apis/hive/v1/gcp"
+	"github.com/openshift/hive/apis/hive/v1/metricsconfig"
+	hivev1openstack "github.com/openshift/hive/apis/hive/v1/openstack"
+	hivev1vsphere "github.com/openshift/hive/apis/hive/v1/vsphere"
+	"github.com/openshift/hive/pkg/constants"
+	testcd "github.com/openshift/hive/pkg/test/clusterdeployment"
+	testgeneric "github.com/openshift/hive/pkg/test/generic"
+	testsecret "github.com/openshift/hive/pkg/test/secret"
+	"github.com/openshift/hive/pkg/util/scheme"
+)
+
+func TestClusterDeploymentCollectors(t *testing.T) {
+	scheme := scheme.GetScheme()
+
+	cdBuilder := func(name string) testcd.Builder {
+		return testcd.FullBuilder(name, name, scheme)
+	}
+	deleted := func(b testcd.Builder) testcd.Builder {
+		return b.GenericOptions(testgeneric.Deleted(), testgeneric.WithFinalizer(testFinalizer))
+	}
+	condition := func(conditionType hivev1.ClusterDeploymentConditionType, status corev1.ConditionStatus, reason string, since time.Duration) testcd.Option {
+		return testcd.WithCondition(hivev1.ClusterDeploymentCondition{
+			Type:               conditionType,
+			Status:             status,
+			Reason:             reason,
+			LastTransitionTime: metav1.NewTime(testNow.Add(-since)),
+		})
+	}
+	imageSet := func(name string) testcd.Option {
+		return func(cd *hivev1.ClusterDeployment) {
+			cd.Spec.Provisioning = &hivev1.Provisioning{
+				ImageSetRef: &hivev1.ClusterImageSetReference{Name: name},
+			}
+		}
+	}
+	clusterImageSet := func(name string) runtime.Object {
+		return &hivev1.ClusterImageSet{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       hivev1.ClusterImageSetSpec{ReleaseImage: "quay.io/openshift-release-dev/ocp-release:" + name},
+		}
+	}
+	clusterState := func(name string, operators ...hivev1.ClusterOperatorState) runtime.Object {
+		return &hivev1.ClusterState{
+			ObjectMeta: metav1.ObjectMeta{Namespace: name, Name: name},
+			Status:     hivev1.ClusterStateStatus{ClusterOperators: operators},
+		}
+	}
+	operator := func(name string, degraded configv1.ConditionStatus) hivev1.ClusterOperatorState {
+		return hivev1.ClusterOperatorState{
+			Name: name,
+			Conditions: []configv1.ClusterOperatorStatusCondition{{
+				Type:   configv1.OperatorAvailable,
+				Status: configv1.ConditionTrue,
+			}, {
+				Type:   configv1.OperatorDegraded,
+				Status: degraded,
+			}},
+		}
+	}
+	secret := func(namespace, name, key string, data []byte) *corev1.Secret {
+		return testsecret.FullBuilder(namespace, name, scheme).Build(testsecret.WithDataKeyValue(key, data))
+	}
+
+	// Hibernation transitions.
+	managed := testgeneric.WithLabel(hivev1.HiveClusterTypeLabel, "managed")
+	hibernationTransitionUnderway := func(min time.Duration) func(client.Client) prometheus.Collector {
+		return func(c client.Client) prometheus.Collector {
+			collect := newHibernationTransitionUnderwayCollector(c, min, "").(hibernationTransitionUnderwayCollector)
+			collect.clock = clocktesting.NewFakePassiveClock(testNow)
+			return collect
+		}
+	}
+
+	// Pull secrets.
+	token := func(expiry time.Time) string {
+		claims := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":"user","exp":%d}`, expiry.Unix())))
+		return "header." + claims + ".signature"
+	}
+	pullSecret := func(namespace string, passwords ...string) runtime.Object {
+		auths := make([]string, len(passwords))
+		for i, password := range passwords {
+			auth := base64.StdEncoding.EncodeToString([]byte("user:" + password))
+			auths[i] = fmt.Sprintf(`"registry-%d.example.com":{"auth":%q}`, i, auth)
+		}
+		return testsecret.FullBuilder(namespace, "pull-secret", scheme).Build(
+			testsecret.WithType(corev1.SecretTypeDockerConfigJson),
+			testsecret.WithDataKeyValue(corev1.DockerConfigJsonKey, []byte(`{"auths":{`+strings.Join(auths, ",")+`}}`)),
+		)
+	}
+	withPullSecret := func(cd *hivev1.ClusterDeployment) {
+		cd.Spec.PullSecretRef = &corev1.LocalObjectReference{Name: "pull-secret"}
+	}
+
+	// Admin kubeconfigs.
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	kubeconfig := func(notAfter time.Time) []byte {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "system:admin"},
+			NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+			NotAfter:     notAfter,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		require.NoError(t, err)
+		config := clientcmdapi.NewConfig()
+		config.AuthInfos["admin"] = &clientcmdapi.AuthInfo{
+			ClientCertificateData: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		}
+		data, err := clientcmd.Write(*config)
+		require.NoError(t, err)
+		return data
+	}
+	adminKubeconfig := func(namespace string, data []byte) runtime.Object {
+		return secret(namespace, "admin-kubeconfig", constants.KubeconfigSecretKey, data)
+	}
+	withAdminKubeconfig := testcd.WithClusterMetadata(&hivev1.ClusterMetadata{
+		AdminKubeconfigSecretRef: corev1.LocalObjectReference{Name: "admin-kubeconfig"},
+	})
+
+	// Cloud credentials.
+	azure := func(cd *hivev1.ClusterDeployment) {
+		cd.Spec.Platform.Azure = &hivev1azure.Platform{CredentialsSecretRef: corev1.LocalObjectReference{Name: "creds"}}
+	}
+	gcp := func(cd *hivev1.ClusterDeployment) {
+		cd.Spec.Platform.GCP = &hivev1gcp.Platform{CredentialsSecretRef: corev1.LocalObjectReference{Name: "creds"}}
+	}
+	azureCredentials := func(namespace, subscription string) *corev1.Secret {
+		return secret(namespace, "creds", constants.AzureCredentialsName, []byte(fmt.Sprintf(`{"subscriptionId": %q, "clientId": "client"}`, subscription)))
+	}
+	gcpCredentials := func(namespace, project string) *corev1.Secret {
+		return secret(namespace, "creds", constants.GCPCredentialsName, []byte(fmt.Sprintf(`{"type": "service_account", "project_id": %q}`, project)))
+	}
+
+	runCollectorTests(t, []collectorTest{{
+		name:      "custom CA",
+		collector: newCustomCACollector,
+		cases: []collectorCase{{
+			name: "no custom CAs",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(testcd.WithAWSPlatform(&hivev1aws.Platform{Region: "us-east-1"})),
+				cdBuilder("cd-2").Build(testcd.WithOpenStackPlatform(&hivev1openstack.Platform{Cloud: "openstack"})),
+			},
+		}, {
+			name: "mix of custom and default CAs",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(testcd.WithAWSPlatform(&hivev1aws.Platform{Region: "us-east-1"})),
+				cdBuilder("cd-2").Build(testcd.WithOpenStackPlatform(&hivev1openstack.Platform{
+					Cloud:                 "openstack",
+					CertificatesSecretRef: &corev1.LocalObjectReference{Name: "openstack-certs"},
+				})),
+				cdBuilder("cd-3").Build(testcd.WithVSpherePlatform(&hivev1vsphere.Platform{
+					CertificatesSecretRef: corev1.LocalObjectReference{Name: "vsphere-certs"},
+				})),
+			},
+			expected: []string{
+				"cluster_deployment = cd-2 namespace = cd-2 1",
+				"cluster_deployment = cd-3 namespace = cd-3 1",
+			},
+		}},
+	}, {
+		name:      "platform and region",
+		collector: newPlatformRegionCollector,
+		cases: []collectorCase{{
+			name: "mix of platforms",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(testcd.WithAWSPlatform(&hivev1aws.Platform{Region: "us-east-1"})),
+				cdBuilder("cd-2").Build(testcd.Installed(), testcd.WithAWSPlatform(&hivev1aws.Platform{Region: "us-east-1"})),
+				cdBuilder("cd-3").Build(testcd.WithAWSPlatform(&hivev1aws.Platform{Region: "eu-west-1"})),
+				cdBuilder("cd-4").Build(testcd.WithGCPPlatform(&hivev1gcp.Platform{Region: "us-central1"})),
+				cdBuilder("cd-5").Build(testcd.WithAzurePlatform(&hivev1azure.Platform{Region: "eastus"})),
+				// Platforms without regions.
+				cdBuilder("cd-6").Build(testcd.WithOpenStackPlatform(&hivev1openstack.Platform{Cloud: "openstack"})),
+				cdBuilder("cd-7").Build(testcd.WithVSpherePlatform(&hivev1vsphere.Platform{Datacenter: "dc1"})),
+				cdBuilder("cd-8").Build(),
+				deleted(cdBuilder("cd-9")).Build(testcd.WithAWSPlatform(&hivev1aws.Platform{Region: "us-east-1"})),
+			},
+			expected: []string{
+				"platform = aws region = eu-west-1 1",
+				"platform = aws region = us-east-1 2",
+				"platform = azure region = eastus 1",
+				"platform = gcp region = us-central1 1",
+				"platform = openstack region = none 1",
+				"platform = unknown region = none 1",
+				"platform = vsphere region = none 1",
+			},
+		}},
+	}, {
+		name:      "auth degraded",
+		collector: newAuthDegradedCollector,
+		cases: []collectorCase{{
+			name: "mix of operator states",
+			existing: []runtime.Object{
+				clusterState("cd-1", operator("authentication", configv1.ConditionTrue), operator("ingress", configv1.ConditionFalse)),
+				// Healthy authentication operators.
+				clusterState("cd-2", operator("authentication", configv1.ConditionFalse), operator("ingress", configv1.ConditionFalse)),
+				clusterState("cd-3", operator("authentication", configv1.ConditionUnknown)),
+				// Other operators degraded.
+				clusterState("cd-4", operator("authentication", configv1.ConditionFalse), operator("ingress", configv1.ConditionTrue)),
+				// Operators not yet reported.
+				clusterState("cd-5"),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = cd-1 1",
+			},
+		}},
+	}, {
+		name:      "image set reference",
+		collector: newImageSetReferenceCollector,
+		cases: []collectorCase{{
+			name: "mix of references",
+			existing: []runtime.Object{
+				// Heavily referenced.
+				cdBuilder("cd-1").Build(imageSet("openshift-v4.14.0")),
+				cdBuilder("cd-2").Build(imageSet("openshift-v4.14.0"), testcd.Installed()),
+				cdBuilder("cd-3").Build(imageSet("openshift-v4.14.0")),
+				cdBuilder("cd-4").Build(imageSet("openshift-v4.14.0"), testcd.Installed()),
+				// Lightly referenced.
+				cdBuilder("cd-5").Build(imageSet("openshift-v4.15.0")),
+				// Deleted clusters no longer count against their image set.
+				deleted(cdBuilder("cd-6")).Build(imageSet("openshift-v4.13.0")),
+				// Clusters not provisioned from an image set.
+				cdBuilder("cd-7").Build(),
+			},
+			expected: []string{
+				"image_set = openshift-v4.14.0 4",
+				"image_set = openshift-v4.15.0 1",
+			},
+		}},
+	}, {
+		name:      "cluster image set usage",
+		collector: newClusterImageSetUsageCollector,
+		named:     true,
+		cases: []collectorCase{{
+			name: "mix of references",
+			existing: []runtime.Object{
+				clusterImageSet("4.13.0"),
+				clusterImageSet("4.14.0"),
+				clusterImageSet("4.15.0"),
+				// Referenced by no cluster, once its only cluster is deleted.
+				deleted(cdBuilder("cd-1")).Build(imageSet("4.13.0")),
+				// Referenced by one cluster.
+				cdBuilder("cd-2").Build(imageSet("4.14.0"), testcd.Installed()),
+				// Referenced by several clusters.
+				cdBuilder("cd-3").Build(imageSet("4.15.0")),
+				cdBuilder("cd-4").Build(imageSet("4.15.0"), testcd.Installed()),
+				cdBuilder("cd-5").Build(imageSet("4.15.0")),
+				// References to image sets that don't exist are not reported.
+				cdBuilder("cd-6").Build(imageSet("4.16.0")),
+				cdBuilder("cd-7").Build(),
+			},
+			expected: []string{
+				"hive_clusterimageset_in_use image_set = 4.13.0 release_image = quay.io/openshift-release-dev/ocp-release:4.13.0 0",
+				"hive_clusterimageset_in_use image_set = 4.14.0 release_image = quay.io/openshift-release-dev/ocp-release:4.14.0 1",
+				"hive_clusterimageset_in_use image_set = 4.15.0 release_image = quay.io/openshift-release-dev/ocp-release:4.15.0 1",
+				"hive_clusterimageset_referencing_clusterdeployments image_set = 4.13.0 release_image = quay.io/openshift-release-dev/ocp-release:4.13.0 0",
+				"hive_clusterimageset_referencing_clusterdeployments image_set = 4.14.0 release_image = quay.io/openshift-release-dev/ocp-release:4.14.0 1",
+				"hive_clusterimageset_referencing_clusterdeployments image_set = 4.15.0 release_image = quay.io/openshift-release-dev/ocp-release:4.15.0 3",
+			},
+		}},
+	}, {
+		name: "tenant",
+		collector: func(c client.Client) prometheus.Collector {
+			return newTenantCollector(c, metricsconfig.TenantConfig{LabelKey: "example.com/tenant"})
+		},
+		cases: []collectorCase{{
+			name: "tenant from label",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").GenericOptions(testgeneric.WithLabel("example.com/tenant", "tenant-a")).Build(),
+				cdBuilder("cd-2").GenericOptions(testgeneric.WithLabel("example.com/tenant", "tenant-a")).Build(),
+				cdBuilder("cd-3").GenericOptions(testgeneric.WithLabel("example.com/tenant", "tenant-b")).Build(),
+				cdBuilder("cd-4").Build(),
+			},
+			expected: []string{
+				"tenant = tenant-a 2",
+				"tenant = tenant-b 1",
+				"tenant = unknown 1",
+			},
+		}, {
+			name: "tenant from annotation",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").GenericOptions(testgeneric.WithAnnotation("example.com/tenant", "tenant-a")).Build(),
+				cdBuilder("cd-2").GenericOptions(testgeneric.WithLabel("example.com/tenant", "tenant-b")).Build(),
+			},
+			collector: func(c client.Client) prometheus.Collector {
+				return newTenantCollector(c, metricsconfig.TenantConfig{AnnotationKey: "example.com/tenant"})
+			},
+			expected: []string{
+				"tenant = tenant-a 1",
+				"tenant = unknown 1",
+			},
+		}, {
+			name: "label preferred over annotation",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").GenericOptions(
+					testgeneric.WithLabel("example.com/tenant", "tenant-a"),
+					testgeneric.WithAnnotation("example.com/owner", "tenant-b"),
+				).Build(),
+				cdBuilder("cd-2").GenericOptions(testgeneric.WithAnnotation("example.com/owner", "tenant-b")).Build(),
+			},
+			collector: func(c client.Client) prometheus.Collector {
+				return newTenantCollector(c, metricsconfig.TenantConfig{LabelKey: "example.com/tenant", AnnotationKey: "example.com/owner"})
+			},
+			expected: []string{
+				"tenant = tenant-a 1",
+				"tenant = tenant-b 1",
+			},
+		}, {
+			name: "deleted clusters are skipped",
+			existing: []runtime.Object{
+				deleted(cdBuilder("cd-1").GenericOptions(testgeneric.WithLabel("example.com/tenant", "tenant-a"))).Build(),
+			},
+		}},
+	}, {
+		name:      "hibernation transition underway",
+		collector: hibernationTransitionUnderway(0),
+		cases: []collectorCase{{
+			name: "running and hibernated clusters",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").GenericOptions(managed).Build(
+					testcd.Installed(),
+					condition(hivev1.ClusterHibernatingCondition, corev1.ConditionFalse, hivev1.HibernatingReasonResumingOrRunning, 5*time.Hour),
+					condition(hivev1.ClusterReadyCondition, corev1.ConditionTrue, hivev1.ReadyReasonRunning, 5*time.Hour),
+				),
+				cdBuilder("cd-2").GenericOptions(managed).Build(
+					testcd.Installed(),
+					condition(hivev1.ClusterHibernatingCondition, corev1.ConditionTrue, hivev1.HibernatingReasonHibernating, 5*time.Hour),
+					condition(hivev1.ClusterReadyCondition, corev1.ConditionFalse, hivev1.ReadyReasonStoppingOrHibernating, 5*time.Hour),
+				),
+				cdBuilder("cd-3").GenericOptions(managed).Build(),
+			},
+		}, {
+			name: "stopping and resuming clusters",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").GenericOptions(managed).Build(
+					testcd.Installed(),
+					condition(hivev1.ClusterHibernatingCondition, corev1.ConditionFalse, hivev1.HibernatingReasonStopping, 5*time.Hour),
+					condition(hivev1.ClusterReadyCondition, corev1.ConditionFalse, hivev1.ReadyReasonStoppingOrHibernating, 2*time.Hour),
+				),
+				cdBuilder("cd-2").GenericOptions(managed).Build(
+					testcd.Installed(),
+					condition(hivev1.ClusterHibernatingCondition, corev1.ConditionFalse, hivev1.HibernatingReasonWaitingForMachinesToStop, 5*time.Hour),
+					condition(hivev1.ClusterReadyCondition, corev1.ConditionFalse, hivev1.ReadyReasonStoppingOrHibernating, 2*time.Hour),
+				),
+				cdBuilder("cd-3").GenericOptions(managed).Build(
+					testcd.Installed(),
+					condition(hivev1.ClusterHibernatingCondition, corev1.ConditionFalse, hivev1.HibernatingReasonResumingOrRunning, 2*time.Hour),
+					condition(hivev1.ClusterReadyCondition, corev1.ConditionFalse, hivev1.ReadyReasonWaitingForMachines, 5*time.Hour),
+				),
+				cdBuilder("cd-4").GenericOptions(managed).Build(
+					testcd.Installed(),
+					condition(hivev1.ClusterHibernatingCondition, corev1.ConditionFalse, hivev1.HibernatingReasonResumingOrRunning, 2*time.Hour),
+					condition(hivev1.ClusterReadyCondition, corev1.ConditionFalse, hivev1.ReadyReasonWaitingForClusterOperators, 5*time.Hour),
+				),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 cluster_type = managed current_state = Stopping namespace = cd-1 7200",
+				"cluster_deployment = cd-2 cluster_type = managed current_state = WaitingForMachinesToStop namespace = cd-2 7200",
+				"cluster_deployment = cd-3 cluster_type = managed current_state = WaitingForMachines namespace = cd-3 7200",
+				"cluster_deployment = cd-4 cluster_type = managed current_state = WaitingForClusterOperators namespace = cd-4 7200",
+			},
+		}, {
+			name: "transitioning for less than min duration",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").GenericOptions(managed).Build(
+					testcd.Installed(),
+					condition(hivev1.ClusterHibernatingCondition, corev1.ConditionFalse, hivev1.HibernatingReasonStopping, 5*time.Hour),
+					condition(hivev1.ClusterReadyCondition, corev1.ConditionFalse, hivev1.ReadyReasonStoppingOrHibernating, 30*time.Minute),
+				),
+				cdBuilder("cd-2").GenericOptions(managed).Build(
+					testcd.Installed(),
+					condition(hivev1.ClusterHibernatingCondition, corev1.ConditionFalse, hivev1.HibernatingReasonResumingOrRunning, 2*time.Hour),
+					condition(hivev1.ClusterReadyCondition, corev1.ConditionFalse, hivev1.ReadyReasonWaitingForNodes, 5*time.Hour),
+				),
+			},
+			collector: hibernationTransitionUnderway(time.Hour),
+			expected: []string{
+				"cluster_deployment = cd-2 cluster_type = managed current_state = WaitingForNodes namespace = cd-2 7200",
+			},
+		}, {
+			name: "transitioning for exactly min duration",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").GenericOptions(managed).Build(
+					testcd.Installed(),
+					condition(hivev1.ClusterHibernatingCondition, corev1.ConditionFalse, hivev1.HibernatingReasonStopping, 5*time.Hour),
+					condition(hivev1.ClusterReadyCondition, corev1.ConditionFalse, hivev1.ReadyReasonStoppingOrHibernating, time.Hour),
+				),
+				cdBuilder("cd-2").GenericOptions(managed).Build(
+					testcd.Installed(),
+					condition(hivev1.ClusterHibernatingCondition, corev1.ConditionFalse, hivev1.HibernatingReasonStopping, 5*time.Hour),
+					condition(hivev1.ClusterReadyCondition, corev1.ConditionFalse, hivev1.ReadyReasonStoppingOrHibernating, time.Hour-time.Second),
+				),
+			},
+			collector: hibernationTransitionUnderway(time.Hour),
+			expected: []string{
+				"cluster_deployment = cd-1 cluster_type = managed current_state = Stopping namespace = cd-1 3600",
+			},
+		}, {
+			name: "deleting cluster",
+			existing: []runtime.Object{
+				deleted(cdBuilder("cd-1").GenericOptions(managed)).Build(
+					testcd.Installed(),
+					condition(hivev1.ClusterHibernatingCondition, corev1.ConditionFalse, hivev1.HibernatingReasonStopping, 5*time.Hour),
+					condition(hivev1.ClusterReadyCondition, corev1.ConditionFalse, hivev1.ReadyReasonStoppingOrHibernating, 2*time.Hour),
+				),
+			},
+		}},
+	}, {
+		name:      "creator",
+		collector: newCreatorCollector,
+		cases: []collectorCase{{
+			name: "no clusters",
+		}, {
+			name: "multiple creators",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").GenericOptions(testgeneric.WithAnnotation(constants.CreatorAnnotation, "system:serviceaccount:ci:provisioner")).Build(),
+				cdBuilder("cd-2").GenericOptions(testgeneric.WithAnnotation(constants.CreatorAnnotation, "system:serviceaccount:ci:provisioner")).Build(),
+				cdBuilder("cd-3").GenericOptions(testgeneric.WithAnnotation(constants.CreatorAnnotation, "alice")).Build(),
+				cdBuilder("cd-4").Build(),
+				cdBuilder("cd-5").GenericOptions(testgeneric.WithAnnotation(constants.CreatorAnnotation, "")).Build(),
+			},
+			expected: []string{
+				"creator = alice 1",
+				"creator = system:serviceaccount:ci:provisioner 2",
+				"creator = unknown 2",
+			},
+		}, {
+			name: "deleted clusters are skipped",
+			existing: []runtime.Object{
+				deleted(cdBuilder("cd-1").GenericOptions(testgeneric.WithAnnotation(constants.CreatorAnnotation, "alice"))).Build(),
+				cdBuilder("cd-2").GenericOptions(testgeneric.WithAnnotation(constants.CreatorAnnotation, "bob")).Build(),
+			},
+			expected: []string{
+				"creator = bob 1",
+			},
+		}},
+	}, {
+		name:      "API marker",
+		collector: newAPIMarkerCollector,
+		cases: []collectorCase{{
+			name: "no clusters",
+		}, {
+			name: "multiple markers",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").GenericOptions(testgeneric.WithAnnotation(constants.APIMarkerAnnotation, "v1alpha1")).Build(),
+				cdBuilder("cd-2").GenericOptions(testgeneric.WithAnnotation(constants.APIMarkerAnnotation, "v1")).Build(),
+				cdBuilder("cd-3").GenericOptions(testgeneric.WithAnnotation(constants.APIMarkerAnnotation, "v1")).Build(),
+				cdBuilder("cd-4").Build(),
+			},
+			expected: []string{
+				"marker = none 1",
+				"marker = v1 2",
+				"marker = v1alpha1 1",
+			},
+		}, {
+			name: "deleted clusters are skipped",
+			existing: []runtime.Object{
+				deleted(cdBuilder("cd-1").GenericOptions(testgeneric.WithAnnotation(constants.APIMarkerAnnotation, "v1alpha1"))).Build(),
+				cdBuilder("cd-2").GenericOptions(testgeneric.WithAnnotation(constants.APIMarkerAnnotation, "v1")).Build(),
+			},
+			expected: []string{
+				"marker = v1 1",
+			},
+		}},
+	}, {
+		name: "pull secret expiring",
+		collector: func(c client.Client) prometheus.Collector {
+			collect := newPullSecretExpiringCollector(c, 7*24*time.Hour).(pullSecretExpiringCollector)
+			collect.clock = clocktesting.NewFakePassiveClock(testNow)
+			return collect
+		},
+		cases: []collectorCase{{
+			name: "fresh tokens",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(withPullSecret),
+				pullSecret("cd-1", token(testNow.Add(365*24*time.Hour))),
+				cdBuilder("cd-2").Build(withPullSecret),
+				pullSecret("cd-2", "not-a-jwt"),
+			},
+		}, {
+			name: "expiring and expired tokens",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(withPullSecret),
+				pullSecret("cd-1", token(testNow.Add(365*24*time.Hour)), token(testNow.Add(3*24*time.Hour+time.Hour))),
+				cdBuilder("cd-2").Build(withPullSecret),
+				pullSecret("cd-2", token(testNow.Add(-time.Hour))),
+				cdBuilder("cd-3").Build(withPullSecret),
+				pullSecret("cd-3", token(testNow.Add(365*24*time.Hour))),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 days = 3 namespace = cd-1 1",
+				"cluster_deployment = cd-2 days = 0 namespace = cd-2 1",
+			},
+		}, {
+			name: "token expiring at exactly the threshold",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(withPullSecret),
+				pullSecret("cd-1", token(testNow.Add(7*24*time.Hour))),
+				cdBuilder("cd-2").Build(withPullSecret),
+				pullSecret("cd-2", token(testNow.Add(7*24*time.Hour+time.Second))),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 days = 7 namespace = cd-1 1",
+			},
+		}, {
+			name: "missing pull secret and deleted clusters are skipped",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(withPullSecret),
+				deleted(cdBuilder("cd-2")).Build(withPullSecret),
+				pullSecret("cd-2", token(testNow.Add(-time.Hour))),
+				cdBuilder("cd-3").Build(),
+			},
+		}},
+	}, {
+		name: "cluster certificate expiry",
+		collector: func(c client.Client) prometheus.Collector {
+			collect := newClusterCertificateExpiryCollector(c, "").(clusterCertificateExpiryCollector)
+			collect.clock = clocktesting.NewFakePassiveClock(testNow)
+			return collect
+		},
+		cases: []collectorCase{{
+			name: "mix of certificates",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(testcd.Installed(), withAdminKubeconfig),
+				adminKubeconfig("cd-1", kubeconfig(testNow.Add(-24*time.Hour))),
+				cdBuilder("cd-2").Build(testcd.Installed(), withAdminKubeconfig),
+				adminKubeconfig("cd-2", kubeconfig(testNow.Add(24*time.Hour))),
+				cdBuilder("cd-3").Build(testcd.Installed(), withAdminKubeconfig),
+				adminKubeconfig("cd-3", kubeconfig(testNow.Add(10*365*24*time.Hour))),
+				// Clusters without a readable admin kubeconfig are skipped.
+				cdBuilder("cd-4").Build(testcd.Installed(), withAdminKubeconfig),
+				cdBuilder("cd-5").Build(testcd.Installed(), withAdminKubeconfig),
+				adminKubeconfig("cd-5", []byte("not a kubeconfig")),
+				deleted(cdBuilder("cd-6")).Build(testcd.Installed(), withAdminKubeconfig),
+				adminKubeconfig("cd-6", kubeconfig(testNow.Add(-24*time.Hour))),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 cluster_type = unspecified namespace = cd-1 -86400",
+				"cluster_deployment = cd-2 cluster_type = unspecified namespace = cd-2 86400",
+				"cluster_deployment = cd-3 cluster_type = unspecified namespace = cd-3 315360000",
+			},
+		}},
+	}, {
+		name:      "cloud org",
+		collector: newCloudOrgCollector,
+		cases: []collectorCase{{
+			name: "azure subscriptions",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(azure),
+				azureCredentials("cd-1", "sub-1"),
+				cdBuilder("cd-2").Build(azure),
+				azureCredentials("cd-2", "sub-1"),
+				cdBuilder("cd-3").Build(azure),
+				azureCredentials("cd-3", "sub-2"),
+			},
+			expected: []string{
+				"org = sub-1 platform = azure 2",
+				"org = sub-2 platform = azure 1",
+			},
+		}, {
+			name: "gcp projects",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(gcp),
+				gcpCredentials("cd-1", "project-1"),
+				cdBuilder("cd-2").Build(gcp),
+				gcpCredentials("cd-2", "project-2"),
+				// Other platforms are not reported.
+				cdBuilder("cd-3").Build(testcd.WithAWSPlatform(&hivev1aws.Platform{Region: "us-east-1"})),
+			},
+			expected: []string{
+				"org = project-1 platform = gcp 1",
+				"org = project-2 platform = gcp 1",
+			},
+		}, {
+			name: "unknown orgs",
+			existing: []runtime.Object{
+				// No credentials.
+				cdBuilder("cd-1").Build(azure),
+				// Credentials without a subscription.
+				cdBuilder("cd-2").Build(azure),
+				secret("cd-2", "creds", constants.AzureCredentialsName, []byte(`{"clientId": "client"}`)),
+				// Credentials that aren't JSON.
+				cdBuilder("cd-3").Build(gcp),
+				secret("cd-3", "creds", constants.GCPCredentialsName, []byte("not json")),
+				deleted(cdBuilder("cd-4")).Build(gcp),
+				gcpCredentials("cd-4", "project-1"),
+			},
+			expected: []string{
+				"org = unknown platform = azure 2",
+				"org = unknown platform = gcp 1",
+			},
+		}},
+	}})
+}
@@ -0,0 +1,443 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	controllerutils "github.com/openshift/hive/pkg/controller/utils"
+)
+
+// clusterpool last creation failed metric collected through a custom prometheus collector
+type clusterPoolLastCreationFailedCollector struct {
+	client client.Client
+
+	// metricClusterPoolLastCreationFailed is a prometheus metric reporting ClusterPools whose most recently
+	// created ClusterDeployment failed to provision.
+	metricClusterPoolLastCreationFailed constMetricDesc
+}
+
+// Collect collects the metrics for clusterPoolLastCreationFailedCollector
+func (cc clusterPoolLastCreationFailedCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "last creation failed metrics across all ClusterPools")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+
+	// Find the most recently created ClusterDeployment for each pool.
+	latest := map[types.NamespacedName]*hivev1.ClusterDeployment{}
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			poolRef := cd.Spec.ClusterPoolRef
+			if poolRef == nil {
+				continue
+			}
+			key := types.NamespacedName{Namespace: poolRef.Namespace, Name: poolRef.PoolName}
+			if cur, ok := latest[key]; !ok || cur.CreationTimestamp.Before(&cd.CreationTimestamp) {
+				// The next page replaces the list's items, so keep a copy.
+				latest[key] = cd.DeepCopy()
+			}
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+
+	for pool, cd := range latest {
+		cond := controllerutils.FindCondition(cd.Status.Conditions, hivev1.ProvisionFailedCondition)
+		if cond == nil || cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		reason := cond.Reason
+		if reason == "" {
+			reason = "Unknown"
+		}
+		ch <- cc.metricClusterPoolLastCreationFailed.mustNewConstMetric(
+			prometheus.GaugeValue,
+			1,
+			prometheus.Labels{
+				"namespace": pool.Namespace,
+				"pool":      pool.Name,
+				"reason":    reason,
+			},
+		)
+	}
+}
+
+func (cc clusterPoolLastCreationFailedCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterPoolLastCreationFailed.Desc
+}
+
+var (
+	metricClusterPoolLastCreationFailedDesc = newConstMetricDesc(
+		"hive_clusterpool_last_creation_failed",
+		"Whether the most recent cluster created for the pool failed to provision.",
+		"namespace", "pool", "reason",
+	)
+)
+
+func newClusterPoolLastCreationFailedCollector(client client.Client) prometheus.Collector {
+	return clusterPoolLastCreationFailedCollector{
+		client:                              client,
+		metricClusterPoolLastCreationFailed: metricClusterPoolLastCreationFailedDesc,
+	}
+}
+
+// cluster pool condition metric collected through a custom prometheus collector
+type clusterPoolConditionCollector struct {
+	client client.Client
+
+	// metricClusterPoolCondition is a prometheus metric reporting the conditions of ClusterPools that are in an
+	// undesired state.
+	metricClusterPoolCondition constMetricDesc
+}
+
+// clusterPoolConditions are the ClusterPool conditions reported by clusterPoolConditionCollector.
+var clusterPoolConditions = []hivev1.ClusterPoolConditionType{
+	hivev1.ClusterPoolMissingDependenciesCondition,
+	hivev1.ClusterPoolCapacityAvailableCondition,
+	hivev1.ClusterPoolAllClustersCurrentCondition,
+	hivev1.ClusterPoolInventoryValidCondition,
+	hivev1.ClusterPoolDeletionPossibleCondition,
+}
+
+// Collect collects the metrics for clusterPoolConditionCollector
+func (cc clusterPoolConditionCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "condition metrics across all ClusterPools")
+	defer scrape.done()
+
+	pools := &hivev1.ClusterPoolList{}
+	pages := scrape.list(cc.client, pools)
+	buffer := cc.metricClusterPoolCondition.newBuffer()
+	for pages.next() {
+		for _, pool := range pools.Items {
+			for _, conditionType := range clusterPoolConditions {
+				cond := controllerutils.FindCondition(pool.Status.Conditions, conditionType)
+				// Conditions the controller hasn't evaluated yet are Unknown, which is neither desired nor not.
+				if cond == nil || cond.Status == corev1.ConditionUnknown ||
+					controllerutils.IsClusterPoolConditionInDesiredState(*cond) {
+					continue
+				}
+				reason := cond.Reason
+				if reason == "" {
+					reason = "Unknown"
+				}
+				buffer.labels["clusterpool_namespace"] = pool.Namespace
+				buffer.labels["clusterpool_name"] = pool.Name
+				buffer.labels["condition"] = string(conditionType)
+				buffer.labels["reason"] = reason
+				ch <- buffer.mustNewConstMetric(prometheus.GaugeValue, 1)
+			}
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+func (cc clusterPoolConditionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterPoolCondition.Desc
+}
+
+var (
+	metricClusterPoolConditionDesc = newConstMetricDesc(
+		"hive_clusterpool_condition",
+		"ClusterPool conditions in an undesired state, such as CapacityAvailable being False.",
+		"clusterpool_namespace", "clusterpool_name", "condition", "reason",
+	)
+)
+
+// newClusterPoolConditionCollector returns a collector reporting, for each ClusterPool, the conditions among
+// clusterPoolConditions that are in an undesired state according to their polarity, with their reason.
+func newClusterPoolConditionCollector(client client.Client) prometheus.Collector {
+	return clusterPoolConditionCollector{
+		client:                     client,
+		metricClusterPoolCondition: metricClusterPoolConditionDesc,
+	}
+}
+
+// cluster pool capacity metrics collected through a custom prometheus collector
+type clusterPoolCapacityCollector struct {
+	client client.Client
+
+	// clock is the source of the current time.
+	clock clock.PassiveClock
+
+	// maxUnclaimedAge is the age after which an unclaimed cluster in a pool is reported as stale.
+	maxUnclaimedAge time.Duration
+
+	// metricClusterPoolSize is a prometheus metric for the configured size of each ClusterPool.
+	metricClusterPoolSize constMetricDesc
+	// metricClusterPoolReady is a prometheus metric for the number of unclaimed clusters of each ClusterPool that
+	// are running and ready to be claimed.
+	metricClusterPoolReady constMetricDesc
+	// metricClusterPoolStandby is a prometheus metric for the number of unclaimed clusters of each ClusterPool that
+	// are installed but not running.
+	metricClusterPoolStandby constMetricDesc
+	// metricClusterPoolStaleUnclaimed is a prometheus metric for the number of unclaimed clusters of each
+	// ClusterPool older than maxUnclaimedAge.
+	metricClusterPoolStaleUnclaimed constMetricDesc
+}
+
+// Collect collects the metrics for clusterPoolCapacityCollector
+func (cc clusterPoolCapacityCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "capacity metrics across all ClusterPools")
+	defer scrape.done()
+
+	staleUnclaimed := map[types.NamespacedName]int{}
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	cdPages := scrape.list(cc.client, clusterDeployments)
+	for cdPages.next() {
+		for _, cd := range clusterDeployments.Items {
+			poolRef := cd.Spec.ClusterPoolRef
+			if poolRef == nil || poolRef.ClaimName != "" || cd.DeletionTimestamp != nil {
+				continue
+			}
+			if cc.clock.Since(cd.CreationTimestamp.Time) > cc.maxUnclaimedAge {
+				staleUnclaimed[types.NamespacedName{Namespace: poolRef.Namespace, Name: poolRef.PoolName}]++
+			}
+		}
+	}
+	if scrape.listFailed(cdPages) {
+		return
+	}
+
+	clusterPools := &hivev1.ClusterPoolList{}
+	poolPages := scrape.list(cc.client, clusterPools)
+	for poolPages.next() {
+		for _, pool := range clusterPools.Items {
+			labels := prometheus.Labels{
+				"clusterpool_namespace": pool.Namespace,
+				"clusterpool_name":      pool.Name,
+			}
+			ch <- cc.metricClusterPoolSize.mustNewConstMetric(prometheus.GaugeValue, float64(pool.Spec.Size), labels)
+			ch <- cc.metricClusterPoolReady.mustNewConstMetric(prometheus.GaugeValue, float64(pool.Status.Ready), labels)
+			ch <- cc.metricClusterPoolStandby.mustNewConstMetric(prometheus.GaugeValue, float64(pool.Status.Standby), labels)
+			ch <- cc.metricClusterPoolStaleUnclaimed.mustNewConstMetric(
+				prometheus.GaugeValue,
+				float64(staleUnclaimed[types.NamespacedName{Namespace: pool.Namespace, Name: pool.Name}]),
+				labels,
+			)
+		}
+	}
+	if scrape.listFailed(poolPages) {
+		return
+	}
+}
+
+func (cc clusterPoolCapacityCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterPoolSize.Desc
+	ch <- cc.metricClusterPoolReady.Desc
+	ch <- cc.metricClusterPoolStandby.Desc
+	ch <- cc.metricClusterPoolStaleUnclaimed.Desc
+}
+
+var (
+	metricClusterPoolSizeDesc = newConstMetricDesc(
+		"hive_clusterpool_size",
+		"Configured number of unclaimed clusters to keep in the pool.",
+		"clusterpool_namespace", "clusterpool_name",
+	)
+	metricClusterPoolReadyDesc = newConstMetricDesc(
+		"hive_clusterpool_ready",
+		"Number of unclaimed clusters in the pool that are running and ready to be claimed.",
+		"clusterpool_namespace", "clusterpool_name",
+	)
+	metricClusterPoolStandbyDesc = newConstMetricDesc(
+		"hive_clusterpool_standby",
+		"Number of unclaimed clusters in the pool that are installed but not running.",
+		"clusterpool_namespace", "clusterpool_name",
+	)
+	metricClusterPoolStaleUnclaimedDesc = newConstMetricDesc(
+		"hive_clusterpool_stale_unclaimed",
+		"Number of unclaimed clusters in the pool that are older than the maximum unclaimed age.",
+		"clusterpool_namespace", "clusterpool_name",
+	)
+)
+
+// newClusterPoolCapacityCollector returns a collector reporting the capacity of each ClusterPool, counting unclaimed
+// clusters older than maxUnclaimedAge as stale.
+func newClusterPoolCapacityCollector(client client.Client, maxUnclaimedAge time.Duration) prometheus.Collector {
+	return clusterPoolCapacityCollector{
+		client:                          client,
+		clock:                           clock.RealClock{},
+		maxUnclaimedAge:                 maxUnclaimedAge,
+		metricClusterPoolSize:           metricClusterPoolSizeDesc,
+		metricClusterPoolReady:          metricClusterPoolReadyDesc,
+		metricClusterPoolStandby:        metricClusterPoolStandbyDesc,
+		metricClusterPoolStaleUnclaimed: metricClusterPoolStaleUnclaimedDesc,
+	}
+}
+
+// cluster pool empty under demand metric collected through a custom prometheus collector
+type clusterPoolEmptyUnderDemandCollector struct {
+	client client.Client
+
+	// clock is the source of the current time.
+	clock clock.PassiveClock
+
+	// metricClusterPoolEmptyUnderDemand is a prometheus metric for how long a ClusterPool with no ready clusters has
+	// had claims waiting for a cluster.
+	metricClusterPoolEmptyUnderDemand constMetricDesc
+}
+
+// Collect collects the metrics for clusterPoolEmptyUnderDemandCollector
+func (cc clusterPoolEmptyUnderDemandCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "empty under demand metrics across all ClusterPools")
+	defer scrape.done()
+
+	// A claim is pending until it has been assigned a cluster, so the oldest pending claim of a pool marks how long
+	// the pool has been unable to meet its demand.
+	oldestPending := map[types.NamespacedName]time.Time{}
+	clusterClaims := &hivev1.ClusterClaimList{}
+	claimPages := scrape.list(cc.client, clusterClaims)
+	for claimPages.next() {
+		for _, claim := range clusterClaims.Items {
+			if claim.DeletionTimestamp != nil || claim.Spec.Namespace != "" {
+				continue
+			}
+			key := types.NamespacedName{Namespace: claim.Namespace, Name: claim.Spec.ClusterPoolName}
+			if oldest, ok := oldestPending[key]; !ok || claim.CreationTimestamp.Time.Before(oldest) {
+				oldestPending[key] = claim.CreationTimestamp.Time
+			}
+		}
+	}
+	if scrape.listFailed(claimPages) {
+		return
+	}
+	if len(oldestPending) == 0 {
+		return
+	}
+
+	clusterPools := &hivev1.ClusterPoolList{}
+	poolPages := scrape.list(cc.client, clusterPools)
+	for poolPages.next() {
+		for _, pool := range clusterPools.Items {
+			if pool.Status.Ready > 0 {
+				continue
+			}
+			oldest, ok := oldestPending[types.NamespacedName{Namespace: pool.Namespace, Name: pool.Name}]
+			if !ok {
+				continue
+			}
+			ch <- cc.metricClusterPoolEmptyUnderDemand.mustNewConstMetric(
+				prometheus.GaugeValue,
+				cc.clock.Since(oldest).Seconds(),
+				prometheus.Labels{
+					"namespace": pool.Namespace,
+					"pool":      pool.Name,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(poolPages) {
+		return
+	}
+}
+
+func (cc clusterPoolEmptyUnderDemandCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterPoolEmptyUnderDemand.Desc
+}
+
+var (
+	metricClusterPoolEmptyUnderDemandDesc = newConstMetricDesc(
+		"hive_clusterpool_empty_under_demand_seconds",
+		"Length of time a ClusterPool with no ready clusters has had claims waiting for a cluster.",
+		"namespace", "pool",
+	)
+)
+
+// newClusterPoolEmptyUnderDemandCollector returns a collector reporting ClusterPools with no ready clusters and
+// unassigned claims, along with the age of the oldest such claim.
+func newClusterPoolEmptyUnderDemandCollector(client client.Client) prometheus.Collector {
+	return clusterPoolEmptyUnderDemandCollector{
+		client:                            client,
+		clock:                             clock.RealClock{},
+		metricClusterPoolEmptyUnderDemand: metricClusterPoolEmptyUnderDemandDesc,
+	}
+}
+
+// pending cluster claims metrics collected through a custom prometheus collector
+type pendingClusterClaimCollector struct {
+	client client.Client
+
+	// clock is the source of the current time.
+	clock clock.PassiveClock
+
+	// minDuration, when non-zero, is the minimum duration after which a pending ClusterClaim
+	// will start becoming part of this metric. When set to zero, all pending ClusterClaims
+	// will be included in the metric.
+	minDuration time.Duration
+
+	// metricClusterClaimPendingSeconds is a prometheus metric for the number of seconds a ClusterClaim has been
+	// waiting to be assigned a cluster.
+	metricClusterClaimPendingSeconds constMetricDesc
+}
+
+// Collect collects the metrics for pendingClusterClaimCollector
+func (cc pendingClusterClaimCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "pending metrics across all ClusterClaims")
+	defer scrape.done()
+
+	clusterClaims := &hivev1.ClusterClaimList{}
+	pages := scrape.list(cc.client, clusterClaims)
+	for pages.next() {
+		for _, claim := range clusterClaims.Items {
+			if claim.DeletionTimestamp != nil || claim.Spec.Namespace != "" {
+				continue
+			}
+			// A claim that has not reported being pending has been waiting since it was created.
+			since := claim.CreationTimestamp.Time
+			if cond := controllerutils.FindCondition(claim.Status.Conditions, hivev1.ClusterClaimPendingCondition); cond != nil && cond.Status == corev1.ConditionTrue {
+				since = cond.LastTransitionTime.Time
+			}
+			elapsedDuration := cc.clock.Since(since)
+			if elapsedDuration < cc.minDuration {
+				continue
+			}
+			ch <- cc.metricClusterClaimPendingSeconds.mustNewConstMetric(
+				prometheus.GaugeValue,
+				elapsedDuration.Seconds(),
+				prometheus.Labels{
+					"clusterclaim_namespace": claim.Namespace,
+					"clusterclaim_name":      claim.Name,
+					"clusterpool_name":       claim.Spec.ClusterPoolName,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+func (cc pendingClusterClaimCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterClaimPendingSeconds.Desc
+}
+
+var (
+	metricClusterClaimPendingSecondsDesc = newConstMetricDesc(
+		"hive_clusterclaim_pending_seconds",
+		"Length of time a ClusterClaim has been waiting to be assigned a cluster.",
+		"clusterclaim_namespace", "clusterclaim_name", "clusterpool_name",
+	)
+)
+
+// newPendingClusterClaimCollector returns a collector reporting ClusterClaims that have not been assigned a cluster
+// for at least minimum.
+func newPendingClusterClaimCollector(client client.Client, minimum time.Duration) prometheus.Collector {
+	return pendingClusterClaimCollector{
+		client:                           client,
+		clock:                            clock.RealClock{},
+		minDuration:                      minimum,
+		metricClusterClaimPendingSeconds: metricClusterClaimPendingSecondsDesc,
+	}
+}
@@ -0,0 +1,263 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	testcc "github.com/openshift/hive/pkg/test/clusterclaim"
+	testcd "github.com/openshift/hive/pkg/test/clusterdeployment"
+	testclusterpool "github.com/openshift/hive/pkg/test/clusterpool"
+	testgeneric "github.com/openshift/hive/pkg/test/generic"
+	"github.com/openshift/hive/pkg/util/scheme"
+)
+
+func TestClusterPoolCollectors(t *testing.T) {
+	scheme := scheme.GetScheme()
+
+	cdBuilder := func(name string, age time.Duration) testcd.Builder {
+		return testcd.FullBuilder(name, name, scheme).
+			GenericOptions(testgeneric.WithCreationTimestamp(testNow.Add(-age)))
+	}
+	poolBuilder := func(name string) testclusterpool.Builder {
+		return testclusterpool.FullBuilder("pools", name, scheme)
+	}
+	claimBuilder := func(namespace, name string, age time.Duration) testcc.Builder {
+		return testcc.FullBuilder(namespace, name, scheme).
+			GenericOptions(testgeneric.WithCreationTimestamp(testNow.Add(-age)))
+	}
+	deleted := []testgeneric.Option{testgeneric.Deleted(), testgeneric.WithFinalizer(testFinalizer)}
+	withStatus := func(ready, standby int32) testclusterpool.Option {
+		return func(pool *hivev1.ClusterPool) {
+			pool.Status.Ready = ready
+			pool.Status.Standby = standby
+		}
+	}
+
+	// Last creation failures.
+	provisionFailed := testcd.WithCondition(hivev1.ClusterDeploymentCondition{
+		Type:   hivev1.ProvisionFailedCondition,
+		Status: corev1.ConditionTrue,
+		Reason: "FailedDueToQuotas",
+	})
+	provisionSucceeded := testcd.WithCondition(hivev1.ClusterDeploymentCondition{
+		Type:   hivev1.ProvisionFailedCondition,
+		Status: corev1.ConditionFalse,
+		Reason: "ProvisionSucceeded",
+	})
+
+	// Pool conditions.
+	condition := func(conditionType hivev1.ClusterPoolConditionType, status corev1.ConditionStatus, reason string) testclusterpool.Option {
+		return testclusterpool.WithCondition(hivev1.ClusterPoolCondition{
+			Type:   conditionType,
+			Status: status,
+			Reason: reason,
+		})
+	}
+
+	// Pending claims.
+	pending := func(since time.Duration) testcc.Option {
+		return testcc.WithCondition(hivev1.ClusterClaimCondition{
+			Type:               hivev1.ClusterClaimPendingCondition,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(testNow.Add(-since)),
+		})
+	}
+	assigned := func(claim *hivev1.ClusterClaim) {
+		claim.Spec.Namespace = "cd-1"
+	}
+	claims := []runtime.Object{
+		claimBuilder("claims", "long-pending", 3*time.Hour).Build(testcc.WithPool("pool"), pending(2*time.Hour)),
+		claimBuilder("claims", "recently-pending", 3*time.Hour).Build(testcc.WithPool("pool"), pending(5*time.Minute)),
+		// Without the condition, the claim has been waiting since it was created.
+		claimBuilder("claims", "never-reported", 3*time.Hour).Build(testcc.WithPool("pool")),
+		claimBuilder("claims", "assigned", 3*time.Hour).Build(testcc.WithPool("pool"), pending(2*time.Hour), assigned),
+		claimBuilder("claims", "deleted", 3*time.Hour).GenericOptions(deleted...).Build(testcc.WithPool("pool"), pending(2*time.Hour)),
+	}
+	pendingClusterClaim := func(min time.Duration) func(client.Client) prometheus.Collector {
+		return func(c client.Client) prometheus.Collector {
+			collect := newPendingClusterClaimCollector(c, min).(pendingClusterClaimCollector)
+			collect.clock = clocktesting.NewFakePassiveClock(testNow)
+			return collect
+		}
+	}
+
+	runCollectorTests(t, []collectorTest{{
+		name:      "last creation failed",
+		collector: newClusterPoolLastCreationFailedCollector,
+		cases: []collectorCase{{
+			name: "no pools",
+			existing: []runtime.Object{
+				cdBuilder("cd-1", 0).Build(provisionFailed),
+			},
+		}, {
+			name: "succeeding pool",
+			existing: []runtime.Object{
+				cdBuilder("cd-1", 2*time.Hour).Build(testcd.WithUnclaimedClusterPoolReference("pool-ns", "pool"), provisionFailed),
+				cdBuilder("cd-2", time.Hour).Build(testcd.WithUnclaimedClusterPoolReference("pool-ns", "pool"), provisionSucceeded),
+			},
+		}, {
+			name: "failing pool",
+			existing: []runtime.Object{
+				cdBuilder("cd-1", 2*time.Hour).Build(testcd.WithUnclaimedClusterPoolReference("pool-ns", "pool"), provisionSucceeded),
+				cdBuilder("cd-2", time.Hour).Build(testcd.WithUnclaimedClusterPoolReference("pool-ns", "pool"), provisionFailed),
+			},
+			expected: []string{
+				"namespace = pool-ns pool = pool reason = FailedDueToQuotas 1",
+			},
+		}, {
+			name: "mix of failing and succeeding pools",
+			existing: []runtime.Object{
+				cdBuilder("cd-1", time.Hour).Build(testcd.WithUnclaimedClusterPoolReference("pool-ns", "failing"), provisionFailed),
+				cdBuilder("cd-2", time.Hour).Build(testcd.WithUnclaimedClusterPoolReference("pool-ns", "succeeding"), provisionSucceeded),
+				cdBuilder("cd-3", time.Hour).Build(testcd.WithUnclaimedClusterPoolReference("pool-ns", "installing")),
+				cdBuilder("cd-4", time.Hour).Build(testcd.WithUnclaimedClusterPoolReference("other-ns", "failing"), provisionFailed),
+			},
+			expected: []string{
+				"namespace = other-ns pool = failing reason = FailedDueToQuotas 1",
+				"namespace = pool-ns pool = failing reason = FailedDueToQuotas 1",
+			},
+		}},
+	}, {
+		name:      "condition",
+		collector: newClusterPoolConditionCollector,
+		cases: []collectorCase{{
+			name: "mix of conditions",
+			existing: []runtime.Object{
+				poolBuilder("healthy").Build(
+					condition(hivev1.ClusterPoolMissingDependenciesCondition, corev1.ConditionFalse, "Dependencies"),
+					condition(hivev1.ClusterPoolCapacityAvailableCondition, corev1.ConditionTrue, "Available"),
+					condition(hivev1.ClusterPoolAllClustersCurrentCondition, corev1.ConditionTrue, "ClusterDeploymentsCurrent"),
+					condition(hivev1.ClusterPoolInventoryValidCondition, corev1.ConditionTrue, hivev1.InventoryReasonValid),
+				),
+				// Conditions that haven't been evaluated yet are not reported.
+				poolBuilder("new").Build(
+					condition(hivev1.ClusterPoolCapacityAvailableCondition, corev1.ConditionUnknown, "Initialized"),
+					condition(hivev1.ClusterPoolDeletionPossibleCondition, corev1.ConditionUnknown, "Initialized"),
+				),
+				poolBuilder("full").Build(
+					condition(hivev1.ClusterPoolCapacityAvailableCondition, corev1.ConditionFalse, "MaxCapacity"),
+					condition(hivev1.ClusterPoolAllClustersCurrentCondition, corev1.ConditionFalse, "SomeClusterDeploymentsStale"),
+				),
+				// Negative polarity conditions are undesired when True.
+				poolBuilder("missing-dependencies").Build(
+					condition(hivev1.ClusterPoolMissingDependenciesCondition, corev1.ConditionTrue, ""),
+				),
+				poolBuilder("invalid-inventory").Build(
+					condition(hivev1.ClusterPoolInventoryValidCondition, corev1.ConditionFalse, hivev1.InventoryReasonInvalid),
+				),
+			},
+			expected: []string{
+				"clusterpool_name = full clusterpool_namespace = pools condition = AllClustersCurrent reason = SomeClusterDeploymentsStale 1",
+				"clusterpool_name = full clusterpool_namespace = pools condition = CapacityAvailable reason = MaxCapacity 1",
+				"clusterpool_name = invalid-inventory clusterpool_namespace = pools condition = InventoryValid reason = Invalid 1",
+				"clusterpool_name = missing-dependencies clusterpool_namespace = pools condition = MissingDependencies reason = Unknown 1",
+			},
+		}},
+	}, {
+		name: "capacity",
+		collector: func(c client.Client) prometheus.Collector {
+			collect := newClusterPoolCapacityCollector(c, 24*time.Hour).(clusterPoolCapacityCollector)
+			collect.clock = clocktesting.NewFakePassiveClock(testNow)
+			return collect
+		},
+		named: true,
+		cases: []collectorCase{{
+			name: "no pools",
+		}, {
+			name: "pools in different states",
+			existing: []runtime.Object{
+				poolBuilder("full").Build(testclusterpool.WithSize(3), withStatus(3, 0)),
+				poolBuilder("drained").Build(testclusterpool.WithSize(2), withStatus(0, 0)),
+				poolBuilder("hibernating").Build(testclusterpool.WithSize(4), withStatus(1, 3)),
+				cdBuilder("full-1", time.Hour).Build(testcd.WithClusterPoolReference("pools", "full", "")),
+				cdBuilder("full-2", 48*time.Hour).Build(testcd.WithClusterPoolReference("pools", "full", "")),
+				cdBuilder("full-3", 72*time.Hour).Build(testcd.WithClusterPoolReference("pools", "full", "")),
+				// Claimed clusters are never stale.
+				cdBuilder("full-4", 72*time.Hour).Build(testcd.WithClusterPoolReference("pools", "full", "claim")),
+				cdBuilder("hibernating-1", 72*time.Hour).Build(testcd.WithClusterPoolReference("pools", "hibernating", "")),
+				// Clusters are stale once they are older than the max unclaimed age.
+				cdBuilder("hibernating-2", 24*time.Hour).Build(testcd.WithClusterPoolReference("pools", "hibernating", "")),
+				// Clusters not in a pool are ignored.
+				cdBuilder("cd-1", 72*time.Hour).Build(),
+			},
+			expected: []string{
+				"hive_clusterpool_size clusterpool_name = drained clusterpool_namespace = pools 2",
+				"hive_clusterpool_ready clusterpool_name = drained clusterpool_namespace = pools 0",
+				"hive_clusterpool_standby clusterpool_name = drained clusterpool_namespace = pools 0",
+				"hive_clusterpool_stale_unclaimed clusterpool_name = drained clusterpool_namespace = pools 0",
+				"hive_clusterpool_size clusterpool_name = full clusterpool_namespace = pools 3",
+				"hive_clusterpool_ready clusterpool_name = full clusterpool_namespace = pools 3",
+				"hive_clusterpool_standby clusterpool_name = full clusterpool_namespace = pools 0",
+				"hive_clusterpool_stale_unclaimed clusterpool_name = full clusterpool_namespace = pools 2",
+				"hive_clusterpool_size clusterpool_name = hibernating clusterpool_namespace = pools 4",
+				"hive_clusterpool_ready clusterpool_name = hibernating clusterpool_namespace = pools 1",
+				"hive_clusterpool_standby clusterpool_name = hibernating clusterpool_namespace = pools 3",
+				"hive_clusterpool_stale_unclaimed clusterpool_name = hibernating clusterpool_namespace = pools 1",
+			},
+		}},
+	}, {
+		name: "empty under demand",
+		collector: func(c client.Client) prometheus.Collector {
+			collect := newClusterPoolEmptyUnderDemandCollector(c).(clusterPoolEmptyUnderDemandCollector)
+			collect.clock = clocktesting.NewFakePassiveClock(testNow)
+			return collect
+		},
+		cases: []collectorCase{{
+			name: "buffered pools",
+			existing: []runtime.Object{
+				poolBuilder("pool-1").Build(testclusterpool.WithSize(2), withStatus(2, 0)),
+				claimBuilder("pools", "claim-1", time.Hour).Build(testcc.WithPool("pool-1")),
+				// Pools without pending claims are not under demand.
+				poolBuilder("pool-2").Build(testclusterpool.WithSize(2), withStatus(0, 0)),
+				claimBuilder("pools", "claim-2", time.Hour).Build(testcc.WithPool("pool-2"), testcc.WithCluster("cd-1")),
+			},
+		}, {
+			name: "empty with demand",
+			existing: []runtime.Object{
+				poolBuilder("pool-1").Build(testclusterpool.WithSize(2), withStatus(0, 0)),
+				claimBuilder("pools", "claim-1", 10*time.Minute).Build(testcc.WithPool("pool-1")),
+				claimBuilder("pools", "claim-2", time.Hour).Build(testcc.WithPool("pool-1")),
+				claimBuilder("pools", "claim-3", 2*time.Hour).Build(testcc.WithPool("pool-1"), testcc.WithCluster("cd-1")),
+				claimBuilder("pools", "claim-4", 3*time.Hour).GenericOptions(deleted...).Build(testcc.WithPool("pool-1")),
+				poolBuilder("pool-2").Build(testclusterpool.WithSize(2), withStatus(0, 0)),
+				claimBuilder("pools", "claim-5", 5*time.Minute).Build(testcc.WithPool("pool-2")),
+			},
+			expected: []string{
+				"namespace = pools pool = pool-1 3600",
+				"namespace = pools pool = pool-2 300",
+			},
+		}},
+	}, {
+		name:      "pending cluster claim",
+		collector: pendingClusterClaim(0),
+		cases: []collectorCase{{
+			name:     "all pending claims",
+			existing: claims,
+			expected: []string{
+				"clusterclaim_name = long-pending clusterclaim_namespace = claims clusterpool_name = pool 7200",
+				"clusterclaim_name = never-reported clusterclaim_namespace = claims clusterpool_name = pool 10800",
+				"clusterclaim_name = recently-pending clusterclaim_namespace = claims clusterpool_name = pool 300",
+			},
+		}, {
+			name:      "threshold",
+			existing:  claims,
+			collector: pendingClusterClaim(2 * time.Hour),
+			expected: []string{
+				"clusterclaim_name = long-pending clusterclaim_namespace = claims clusterpool_name = pool 7200",
+				"clusterclaim_name = never-reported clusterclaim_namespace = claims clusterpool_name = pool 10800",
+			},
+		}, {
+			name:      "threshold above all claims",
+			existing:  claims,
+			collector: pendingClusterClaim(4 * time.Hour),
+		}},
+	}})
+}
@@ -0,0 +1,870 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apihelpers "github.com/openshift/hive/apis/helpers"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hiveintv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
+	"github.com/openshift/hive/pkg/constants"
+	controllerutils "github.com/openshift/hive/pkg/controller/utils"
+)
+
+// clustersync failing metric collected through a custom prometheus collector
+type clusterSyncFailingCollector struct {
+	client client.Client
+
+	// clock is the source of the current time.
+	clock clock.PassiveClock
+
+	// minDuration, when non-zero, is the minimum duration after which clustersync failure
+	// will start becoming part of this metric. When set to zero, all clustersync failures
+	// will be included in the metric.
+	minDuration time.Duration
+
+	// excludedNamespaces filters out objects in namespaces that should not be reported.
+	excludedNamespaces namespaceFilter
+
+	// metricClusterSyncFailingSeconds is a prometheus metric for the number of seconds
+	// between the start of a failing clustersync and now.
+	metricClusterSyncFailingSeconds constMetricDesc
+	// metricClusterSyncFailingTotal is a prometheus metric for the number of ClusterSyncs reported by
+	// metricClusterSyncFailingSeconds.
+	metricClusterSyncFailingTotal constMetricDesc
+	// metricClusterSyncOldestFailingSeconds is a prometheus metric for the largest value reported by
+	// metricClusterSyncFailingSeconds, or 0 when there is none.
+	metricClusterSyncOldestFailingSeconds constMetricDesc
+
+	// dynamicLabels is a collection of fixed (mandatory) and optional labels for the clusterSyncFailing metric.
+	dynamicLabels dynamicLabels
+}
+
+// Collect collects the metrics for custerSyncFailingCollector
+func (cc clusterSyncFailingCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "cluster sync failing seconds metrics")
+	defer scrape.done()
+
+	// Only failing ClusterSyncs are reported, so remember those and the ClusterDeployments they belong to rather than
+	// holding on to every object.
+	var failing []hiveintv1alpha1.ClusterSync
+	failingNames := sets.New[types.NamespacedName]()
+	clusterSyncList := &hiveintv1alpha1.ClusterSyncList{}
+	csPages := scrape.list(cc.client, clusterSyncList)
+	for csPages.next() {
+		for _, cs := range clusterSyncList.Items {
+			if cc.excludedNamespaces.excludes(cs.Namespace) {
+				continue
+			}
+			cond := controllerutils.FindCondition(cs.Status.Conditions, hiveintv1alpha1.ClusterSyncFailed)
+			if cond != nil && cond.Status == corev1.ConditionTrue {
+				failing = append(failing, *cs.DeepCopy())
+				failingNames.Insert(types.NamespacedName{Namespace: cs.Namespace, Name: cs.Name})
+			}
+		}
+	}
+	if scrape.listFailed(csPages) {
+		return
+	}
+
+	failingCDs := map[types.NamespacedName]*hivev1.ClusterDeployment{}
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	cdPages := scrape.list(cc.client, clusterDeployments)
+	for cdPages.next() {
+		for _, cd := range clusterDeployments.Items {
+			key := types.NamespacedName{Namespace: cd.Namespace, Name: cd.Name}
+			if failingNames.Has(key) {
+				failingCDs[key] = cd.DeepCopy()
+			}
+		}
+	}
+	if scrape.listFailed(cdPages) {
+		return
+	}
+
+	var failingTotal int
+	var oldestFailingSeconds float64
+	for _, cs := range failing {
+		// Failing cluster syncs
+		cond := controllerutils.FindCondition(cs.Status.Conditions, hiveintv1alpha1.ClusterSyncFailed)
+		var cdRef hivev1.ClusterDeployment
+		if cd, ok := failingCDs[types.NamespacedName{Namespace: cs.Namespace, Name: cs.Name}]; ok {
+			cdRef = *cd
+		}
+		fixedLabels := make(map[string]string, len(cc.dynamicLabels.fixedLabels))
+		fixedLabels["namespaced_name"] = cs.Namespace + "/" + cs.Name
+		if !reflect.ValueOf(cdRef).IsZero() {
+			if unreachableCondition := controllerutils.FindCondition(cdRef.Status.Conditions, hivev1.UnreachableCondition); unreachableCondition != nil {
+				fixedLabels["unreachable"] = string(unreachableCondition.Status)
+			}
+		}
+		labels := cc.dynamicLabels.buildLabels(fixedLabels, &cdRef)
+		seconds := cc.clock.Since(cond.LastTransitionTime.Time).Seconds()
+		// check if duration crosses the threshold
+		if cc.minDuration.Seconds() <= seconds {
+			ch <- cc.metricClusterSyncFailingSeconds.mustNewConstMetric(
+				prometheus.GaugeValue,
+				seconds,
+				labels,
+			)
+			failingTotal++
+			oldestFailingSeconds = math.Max(oldestFailingSeconds, seconds)
+		}
+	}
+	ch <- cc.metricClusterSyncFailingTotal.mustNewConstMetric(prometheus.GaugeValue, float64(failingTotal), nil)
+	ch <- cc.metricClusterSyncOldestFailingSeconds.mustNewConstMetric(prometheus.GaugeValue, oldestFailingSeconds, nil)
+}
+
+func (cc clusterSyncFailingCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterSyncFailingSeconds.Desc
+	ch <- cc.metricClusterSyncFailingTotal.Desc
+	ch <- cc.metricClusterSyncOldestFailingSeconds.Desc
+}
+
+var (
+	metricClusterSyncFailingTotalDesc = newConstMetricDesc(
+		"hive_clustersync_failing_total",
+		"Number of ClusterSyncs that have been failing for at least the minimum duration.",
+	)
+	metricClusterSyncOldestFailingSecondsDesc = newConstMetricDesc(
+		"hive_clustersync_oldest_failing_seconds",
+		"Length of time the longest failing ClusterSync has been failing, or 0 if none has been failing for at least the minimum duration.",
+	)
+)
+
+func newClusterSyncFailingCollector(client client.Client, minimum time.Duration, optionalLabels map[string]string, excludedNamespaces []string) prometheus.Collector {
+	metricName := "hive_clustersync_failing_seconds"
+	baseLabels := dynamicLabels{
+		// namespaced_name would be logged as $namespace/$name
+		// unreachable reports the status of unreachable condition of the corresponding cluster deployment
+		fixedLabels:    []string{"namespaced_name", "unreachable"},
+		optionalLabels: optionalLabels,
+	}
+	if repeatedLabels := baseLabels.getRepeatedLabels(); repeatedLabels != nil {
+		panic(fmt.Sprintf("Label(s) %v in HiveConfig.Spec.AdditionalClusterDeploymentLabels conflict with fixed label(s) for the metric %s. Please rename your label.", repeatedLabels, metricName))
+	}
+	return clusterSyncFailingCollector{
+		client: client,
+		clock:  clock.RealClock{},
+		metricClusterSyncFailingSeconds: newConstMetricDesc(
+			metricName,
+			"Length of time a clustersync has been failing",
+			baseLabels.getLabelList()...,
+		),
+		metricClusterSyncFailingTotal:         metricClusterSyncFailingTotalDesc,
+		metricClusterSyncOldestFailingSeconds: metricClusterSyncOldestFailingSecondsDesc,
+		minDuration:                           minimum,
+		excludedNamespaces:                    newNamespaceFilter(excludedNamespaces),
+		dynamicLabels:                         baseLabels,
+	}
+}
+
+const (
+	// syncSetTypeSyncSet is used for SyncSets applied by a ClusterSync.
+	syncSetTypeSyncSet = "syncset"
+	// syncSetTypeSelectorSyncSet is used for SelectorSyncSets applied by a ClusterSync.
+	syncSetTypeSelectorSyncSet = "selectorsyncset"
+)
+
+// failing syncset resources metric collected through a custom prometheus collector
+type failingSyncSetResourcesCollector struct {
+	client client.Client
+
+	// clock is the source of the current time.
+	clock clock.PassiveClock
+
+	// minDuration, when non-zero, is the minimum duration after which a failing SyncSet or SelectorSyncSet
+	// will start becoming part of this metric. When set to zero, all failing SyncSets and SelectorSyncSets
+	// will be included in the metric.
+	minDuration time.Duration
+
+	// metricSyncSetFailingSeconds is a prometheus metric for the number of seconds a SyncSet or
+	// SelectorSyncSet has been failing to apply to the cluster of a ClusterSync.
+	metricSyncSetFailingSeconds constMetricDesc
+}
+
+// Collect collects the metrics for failingSyncSetResourcesCollector
+func (cc failingSyncSetResourcesCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "failing syncset metrics across all ClusterSyncs")
+	defer scrape.done()
+
+	clusterSyncList := &hiveintv1alpha1.ClusterSyncList{}
+	pages := scrape.list(cc.client, clusterSyncList)
+	for pages.next() {
+		for _, cs := range clusterSyncList.Items {
+			cc.collectSyncStatuses(ch, &cs, syncSetTypeSyncSet, cs.Status.SyncSets)
+			cc.collectSyncStatuses(ch, &cs, syncSetTypeSelectorSyncSet, cs.Status.SelectorSyncSets)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+// collectSyncStatuses reports the statuses of the given type that have been failing for at least minDuration.
+func (cc failingSyncSetResourcesCollector) collectSyncStatuses(ch chan<- prometheus.Metric, cs *hiveintv1alpha1.ClusterSync, syncSetType string, statuses []hiveintv1alpha1.SyncStatus) {
+	for _, status := range statuses {
+		if status.Result != hiveintv1alpha1.FailureSyncSetResult {
+			continue
+		}
+		// LastTransitionTime is when the status last changed, i.e. when it started failing.
+		seconds := cc.clock.Since(status.LastTransitionTime.Time).Seconds()
+		if seconds < cc.minDuration.Seconds() {
+			continue
+		}
+		ch <- cc.metricSyncSetFailingSeconds.mustNewConstMetric(
+			prometheus.GaugeValue,
+			seconds,
+			prometheus.Labels{
+				"clustersync_namespace": cs.Namespace,
+				"clustersync_name":      cs.Name,
+				"syncset_name":          status.Name,
+				"syncset_type":          syncSetType,
+			},
+		)
+	}
+}
+
+func (cc failingSyncSetResourcesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricSyncSetFailingSeconds.Desc
+}
+
+var (
+	metricSyncSetFailingSecondsDesc = newConstMetricDesc(
+		"hive_clustersync_syncset_failing_seconds",
+		"Length of time a SyncSet or SelectorSyncSet has been failing to apply to a cluster",
+		"clustersync_namespace", "clustersync_name", "syncset_name", "syncset_type",
+	)
+)
+
+// newFailingSyncSetResourcesCollector returns a collector reporting SyncSets and SelectorSyncSets that have been
+// failing to apply for at least minimum.
+func newFailingSyncSetResourcesCollector(client client.Client, minimum time.Duration) prometheus.Collector {
+	return failingSyncSetResourcesCollector{
+		client:                      client,
+		clock:                       clock.RealClock{},
+		minDuration:                 minimum,
+		metricSyncSetFailingSeconds: metricSyncSetFailingSecondsDesc,
+	}
+}
+
+// syncIdentityProviderFailingCollector reports clusters whose identity providers, distributed by SyncIdentityProviders
+// and SelectorSyncIdentityProviders, are failing to apply.
+type syncIdentityProviderFailingCollector struct {
+	client client.Client
+
+	// clock is the source of the current time.
+	clock clock.PassiveClock
+
+	// metricSyncIdentityProviderFailingSeconds is a prometheus metric for the number of seconds the identity provider
+	// SyncSet of a cluster has been failing to apply.
+	metricSyncIdentityProviderFailingSeconds constMetricDesc
+}
+
+// Collect collects the metrics for syncIdentityProviderFailingCollector
+func (cc syncIdentityProviderFailingCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "identity provider sync failing metrics across all ClusterSyncs")
+	defer scrape.done()
+
+	clusterSyncList := &hiveintv1alpha1.ClusterSyncList{}
+	pages := scrape.list(cc.client, clusterSyncList)
+	for pages.next() {
+		for _, cs := range clusterSyncList.Items {
+			// The syncidentityprovider controller applies the identity providers of each cluster through a single
+			// SyncSet named after the ClusterDeployment, which shares its name with the ClusterSync.
+			idpSyncSetName := apihelpers.GetResourceName(cs.Name, constants.IdentityProviderSuffix)
+			for _, status := range cs.Status.SyncSets {
+				if status.Name != idpSyncSetName || status.Result != hiveintv1alpha1.FailureSyncSetResult {
+					continue
+				}
+				ch <- cc.metricSyncIdentityProviderFailingSeconds.mustNewConstMetric(
+					prometheus.GaugeValue,
+					cc.clock.Since(status.LastTransitionTime.Time).Seconds(),
+					prometheus.Labels{
+						"cluster_deployment": cs.Name,
+						"namespace":          cs.Namespace,
+					},
+				)
+			}
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+func (cc syncIdentityProviderFailingCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricSyncIdentityProviderFailingSeconds.Desc
+}
+
+var (
+	metricSyncIdentityProviderFailingSecondsDesc = newConstMetricDesc(
+		"hive_syncidentityprovider_failing_seconds",
+		"Length of time the identity providers of a cluster have been failing to apply.",
+		"cluster_deployment", "namespace",
+	)
+)
+
+// newSyncIdentityProviderFailingCollector returns a collector reporting clusters whose identity provider SyncSet is
+// failing to apply.
+func newSyncIdentityProviderFailingCollector(client client.Client) prometheus.Collector {
+	return syncIdentityProviderFailingCollector{
+		client:                                   client,
+		clock:                                    clock.RealClock{},
+		metricSyncIdentityProviderFailingSeconds: metricSyncIdentityProviderFailingSecondsDesc,
+	}
+}
+
+// selector syncset namespace span metric collected through a custom prometheus collector
+type selectorSyncSetNamespaceSpanCollector struct {
+	client client.Client
+
+	// maximum is how many namespaces the ClusterDeployments matched by a SelectorSyncSet may span before it is
+	// reported.
+	maximum int
+
+	// metricSelectorSyncSetNamespaceSpan is a prometheus metric for the number of distinct namespaces holding the
+	// ClusterDeployments matched by a SelectorSyncSet.
+	metricSelectorSyncSetNamespaceSpan constMetricDesc
+}
+
+// Collect collects the metrics for selectorSyncSetNamespaceSpanCollector
+func (cc selectorSyncSetNamespaceSpanCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "namespace span metrics across all SelectorSyncSets")
+	defer scrape.done()
+
+	type selectorSyncSet struct {
+		name       string
+		selector   labels.Selector
+		namespaces sets.Set[string]
+	}
+	var selectorSyncSets []selectorSyncSet
+	sssList := &hivev1.SelectorSyncSetList{}
+	sssPages := scrape.list(cc.client, sssList)
+	for sssPages.next() {
+		for _, sss := range sssList.Items {
+			selector, err := metav1.LabelSelectorAsSelector(&sss.Spec.ClusterDeploymentSelector)
+			if err != nil {
+				scrape.log.WithError(err).WithField("selectorSyncSet", sss.Name).Warn("invalid cluster deployment selector")
+				continue
+			}
+			selectorSyncSets = append(selectorSyncSets, selectorSyncSet{name: sss.Name, selector: selector, namespaces: sets.New[string]()})
+		}
+	}
+	if scrape.listFailed(sssPages) {
+		return
+	}
+	if len(selectorSyncSets) == 0 {
+		return
+	}
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	cdPages := scrape.list(cc.client, clusterDeployments)
+	for cdPages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			cdLabels := labels.Set(cd.Labels)
+			for _, sss := range selectorSyncSets {
+				if sss.selector.Matches(cdLabels) {
+					sss.namespaces.Insert(cd.Namespace)
+				}
+			}
+		}
+	}
+	if scrape.listFailed(cdPages) {
+		return
+	}
+
+	for _, sss := range selectorSyncSets {
+		span := sss.namespaces.Len()
+		if span <= cc.maximum {
+			continue
+		}
+		ch <- cc.metricSelectorSyncSetNamespaceSpan.mustNewConstMetric(
+			prometheus.GaugeValue,
+			float64(span),
+			prometheus.Labels{
+				"name":            sss.name,
+				"namespace_count": strconv.Itoa(span),
+			},
+		)
+	}
+}
+
+func (cc selectorSyncSetNamespaceSpanCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricSelectorSyncSetNamespaceSpan.Desc
+}
+
+var (
+	metricSelectorSyncSetNamespaceSpanDesc = newConstMetricDesc(
+		"hive_selectorsyncset_namespace_span",
+		"Number of namespaces holding the clusters matched by a SelectorSyncSet, for those spanning more than the configured maximum.",
+		"name", "namespace_count",
+	)
+)
+
+// newSelectorSyncSetNamespaceSpanCollector returns a collector reporting SelectorSyncSets matching ClusterDeployments
+// in more than maximum namespaces.
+func newSelectorSyncSetNamespaceSpanCollector(client client.Client, maximum int) prometheus.Collector {
+	return selectorSyncSetNamespaceSpanCollector{
+		client:                             client,
+		maximum:                            maximum,
+		metricSelectorSyncSetNamespaceSpan: metricSelectorSyncSetNamespaceSpanDesc,
+	}
+}
+
+// create only syncset metrics collected through a custom prometheus collector
+type syncSetCreateOnlyCollector struct {
+	client client.Client
+
+	// metricSyncSetCreateOnlyTotal is a prometheus metric for the number of SyncSets using the CreateOnly
+	// apply behavior, which means drift in the resources they create is never corrected.
+	metricSyncSetCreateOnlyTotal constMetricDesc
+}
+
+// Collect collects the metrics for syncSetCreateOnlyCollector
+func (cc syncSetCreateOnlyCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "create only metrics across all SyncSets")
+	defer scrape.done()
+
+	syncSets := &hivev1.SyncSetList{}
+	pages := scrape.list(cc.client, syncSets)
+	count := 0
+	for pages.next() {
+		for _, ss := range syncSets.Items {
+			if ss.DeletionTimestamp != nil {
+				continue
+			}
+			if ss.Spec.ApplyBehavior == hivev1.CreateOnlySyncSetApplyBehavior {
+				count++
+			}
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+	ch <- cc.metricSyncSetCreateOnlyTotal.mustNewConstMetric(
+		prometheus.GaugeValue,
+		float64(count),
+		prometheus.Labels{},
+	)
+}
+
+func (cc syncSetCreateOnlyCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricSyncSetCreateOnlyTotal.Desc
+}
+
+var (
+	metricSyncSetCreateOnlyTotalDesc = newConstMetricDesc(
+		"hive_syncset_create_only_total",
+		"Total number of SyncSets with the CreateOnly apply behavior.",
+	)
+)
+
+func newSyncSetCreateOnlyCollector(client client.Client) prometheus.Collector {
+	return syncSetCreateOnlyCollector{
+		client:                       client,
+		metricSyncSetCreateOnlyTotal: metricSyncSetCreateOnlyTotalDesc,
+	}
+}
+
+// failing cluster syncs with pending deletes metric collected through a custom prometheus collector
+type clusterSyncPendingDeletesCollector struct {
+	client client.Client
+
+	// metricClusterSyncFailingWithPendingDeletes is a prometheus metric for ClusterSyncs that are failing and also
+	// have resources of a SyncSet or SelectorSyncSet left to delete from the cluster.
+	metricClusterSyncFailingWithPendingDeletes constMetricDesc
+}
+
+// Collect collects the metrics for clusterSyncPendingDeletesCollector
+func (cc clusterSyncPendingDeletesCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "failing clustersyncs with pending deletes across all ClusterSyncs")
+	defer scrape.done()
+
+	clusterSyncList := &hiveintv1alpha1.ClusterSyncList{}
+	pages := scrape.list(cc.client, clusterSyncList)
+	for pages.next() {
+		for _, cs := range clusterSyncList.Items {
+			cond := controllerutils.FindCondition(cs.Status.Conditions, hiveintv1alpha1.ClusterSyncFailed)
+			if cond == nil || cond.Status != corev1.ConditionTrue {
+				continue
+			}
+			if !hasPendingDeletes(cs.Status.SyncSets) && !hasPendingDeletes(cs.Status.SelectorSyncSets) {
+				continue
+			}
+			ch <- cc.metricClusterSyncFailingWithPendingDeletes.mustNewConstMetric(
+				prometheus.GaugeValue,
+				1,
+				prometheus.Labels{
+					"namespaced_name": cs.Namespace + "/" + cs.Name,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+// hasPendingDeletes returns whether any of the statuses failed to delete resources from the cluster. The resources of
+// a SyncSet that no longer applies to the cluster are only kept in its status while deleting them is failing, so a
+// failed status with resources to delete is a deletion that has yet to succeed.
+func hasPendingDeletes(statuses []hiveintv1alpha1.SyncStatus) bool {
+	for _, status := range statuses {
+		if status.Result == hiveintv1alpha1.FailureSyncSetResult && len(status.ResourcesToDelete) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (cc clusterSyncPendingDeletesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterSyncFailingWithPendingDeletes.Desc
+}
+
+var (
+	metricClusterSyncFailingWithPendingDeletesDesc = newConstMetricDesc(
+		"hive_clustersync_failing_with_pending_deletes",
+		"Failing ClusterSyncs that also have resources left to delete from the cluster.",
+		"namespaced_name",
+	)
+)
+
+func newClusterSyncPendingDeletesCollector(client client.Client) prometheus.Collector {
+	return clusterSyncPendingDeletesCollector{
+		client: client,
+		metricClusterSyncFailingWithPendingDeletes: metricClusterSyncFailingWithPendingDeletesDesc,
+	}
+}
+
+// cluster sync CRD ordering failures metric collected through a custom prometheus collector
+type clusterSyncCRDOrderingCollector struct {
+	client client.Client
+
+	// metricClusterSyncCRDOrderingFailures is a prometheus metric for ClusterSyncs with a SyncSet or SelectorSyncSet
+	// failing to apply a custom resource because its CustomResourceDefinition is not established yet.
+	metricClusterSyncCRDOrderingFailures constMetricDesc
+}
+
+// crdOrderingFailureRegex matches the errors the API server and the REST mapper return for a custom resource whose
+// CustomResourceDefinition does not exist yet, as when a SyncSet carries both and the CRD is still being established.
+var crdOrderingFailureRegex = regexp.MustCompile(`no matches for kind|ensure CRDs are installed first|the server could not find the requested resource`)
+
+// Collect collects the metrics for clusterSyncCRDOrderingCollector
+func (cc clusterSyncCRDOrderingCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "CRD ordering failures across all ClusterSyncs")
+	defer scrape.done()
+
+	clusterSyncList := &hiveintv1alpha1.ClusterSyncList{}
+	pages := scrape.list(cc.client, clusterSyncList)
+	for pages.next() {
+		for _, cs := range clusterSyncList.Items {
+			if !hasCRDOrderingFailure(cs.Status.SyncSets) && !hasCRDOrderingFailure(cs.Status.SelectorSyncSets) {
+				continue
+			}
+			ch <- cc.metricClusterSyncCRDOrderingFailures.mustNewConstMetric(
+				prometheus.GaugeValue,
+				1,
+				prometheus.Labels{
+					"namespaced_name": cs.Namespace + "/" + cs.Name,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+// hasCRDOrderingFailure returns whether any of the statuses failed because a custom resource was applied before its
+// CustomResourceDefinition.
+func hasCRDOrderingFailure(statuses []hiveintv1alpha1.SyncStatus) bool {
+	for _, status := range statuses {
+		if status.Result == hiveintv1alpha1.FailureSyncSetResult && crdOrderingFailureRegex.MatchString(status.FailureMessage) {
+			return true
+		}
+	}
+	return false
+}
+
+func (cc clusterSyncCRDOrderingCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterSyncCRDOrderingFailures.Desc
+}
+
+var (
+	metricClusterSyncCRDOrderingFailuresDesc = newConstMetricDesc(
+		"hive_clustersync_crd_ordering_failures",
+		"ClusterSyncs failing to apply custom resources whose CustomResourceDefinitions are not established yet.",
+		"namespaced_name",
+	)
+)
+
+func newClusterSyncCRDOrderingCollector(client client.Client) prometheus.Collector {
+	return clusterSyncCRDOrderingCollector{
+		client:                               client,
+		metricClusterSyncCRDOrderingFailures: metricClusterSyncCRDOrderingFailuresDesc,
+	}
+}
+
+// cluster sync failing while hibernating metric collected through a custom prometheus collector
+type clusterSyncFailingExpectedCollector struct {
+	client client.Client
+
+	// metricClusterSyncFailingExpected is a prometheus metric for failing ClusterSyncs whose ClusterDeployment is
+	// hibernating, where syncing is expected to fail.
+	metricClusterSyncFailingExpected constMetricDesc
+}
+
+// Collect collects the metrics for clusterSyncFailingExpectedCollector
+func (cc clusterSyncFailingExpectedCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "expected failures across all ClusterSyncs")
+	defer scrape.done()
+
+	failing := sets.New[types.NamespacedName]()
+	clusterSyncList := &hiveintv1alpha1.ClusterSyncList{}
+	csPages := scrape.list(cc.client, clusterSyncList)
+	for csPages.next() {
+		for _, cs := range clusterSyncList.Items {
+			cond := controllerutils.FindCondition(cs.Status.Conditions, hiveintv1alpha1.ClusterSyncFailed)
+			if cond != nil && cond.Status == corev1.ConditionTrue {
+				failing.Insert(types.NamespacedName{Namespace: cs.Namespace, Name: cs.Name})
+			}
+		}
+	}
+	if scrape.listFailed(csPages) {
+		return
+	}
+	if failing.Len() == 0 {
+		return
+	}
+
+	// A ClusterSync has the name and namespace of its ClusterDeployment.
+	var expected []types.NamespacedName
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	cdPages := scrape.list(cc.client, clusterDeployments)
+	for cdPages.next() {
+		for _, cd := range clusterDeployments.Items {
+			key := types.NamespacedName{Namespace: cd.Namespace, Name: cd.Name}
+			if cd.Spec.PowerState == hivev1.ClusterPowerStateHibernating && failing.Has(key) {
+				expected = append(expected, key)
+			}
+		}
+	}
+	if scrape.listFailed(cdPages) {
+		return
+	}
+
+	for _, key := range expected {
+		ch <- cc.metricClusterSyncFailingExpected.mustNewConstMetric(
+			prometheus.GaugeValue,
+			1,
+			prometheus.Labels{
+				"namespaced_name": key.String(),
+			},
+		)
+	}
+}
+
+func (cc clusterSyncFailingExpectedCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterSyncFailingExpected.Desc
+}
+
+var (
+	metricClusterSyncFailingExpectedDesc = newConstMetricDesc(
+		"hive_clustersync_failing_expected",
+		"Failing ClusterSyncs whose ClusterDeployment is hibernating, so the failure is expected.",
+		"namespaced_name",
+	)
+)
+
+func newClusterSyncFailingExpectedCollector(client client.Client) prometheus.Collector {
+	return clusterSyncFailingExpectedCollector{
+		client:                           client,
+		metricClusterSyncFailingExpected: metricClusterSyncFailingExpectedDesc,
+	}
+}
+
+// cluster sync resources metric collected through a custom prometheus collector
+type clusterSyncResourcesCollector struct {
+	client client.Client
+
+	// metricClusterSyncResourcesSuccess is a prometheus metric for the number of SyncSets and SelectorSyncSets a
+	// ClusterSync last applied successfully.
+	metricClusterSyncResourcesSuccess constMetricDesc
+	// metricClusterSyncResourcesFailure is a prometheus metric for the number of SyncSets and SelectorSyncSets a
+	// ClusterSync last failed to apply.
+	metricClusterSyncResourcesFailure constMetricDesc
+}
+
+// Collect collects the metrics for clusterSyncResourcesCollector
+func (cc clusterSyncResourcesCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "sync results across all ClusterSyncs")
+	defer scrape.done()
+
+	clusterSyncList := &hiveintv1alpha1.ClusterSyncList{}
+	pages := scrape.list(cc.client, clusterSyncList)
+	for pages.next() {
+		for _, cs := range clusterSyncList.Items {
+			results := map[hiveintv1alpha1.SyncSetResult]int{}
+			for _, statuses := range [][]hiveintv1alpha1.SyncStatus{cs.Status.SyncSets, cs.Status.SelectorSyncSets} {
+				for _, status := range statuses {
+					results[status.Result]++
+				}
+			}
+			labels := prometheus.Labels{
+				"namespaced_name": cs.Namespace + "/" + cs.Name,
+			}
+			ch <- cc.metricClusterSyncResourcesSuccess.mustNewConstMetric(
+				prometheus.GaugeValue,
+				float64(results[hiveintv1alpha1.SuccessSyncSetResult]),
+				labels,
+			)
+			ch <- cc.metricClusterSyncResourcesFailure.mustNewConstMetric(
+				prometheus.GaugeValue,
+				float64(results[hiveintv1alpha1.FailureSyncSetResult]),
+				labels,
+			)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+func (cc clusterSyncResourcesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterSyncResourcesSuccess.Desc
+	ch <- cc.metricClusterSyncResourcesFailure.Desc
+}
+
+var (
+	metricClusterSyncResourcesSuccessDesc = newConstMetricDesc(
+		"hive_clustersync_resources_success",
+		"Number of SyncSets and SelectorSyncSets a ClusterSync last applied successfully.",
+		"namespaced_name",
+	)
+	metricClusterSyncResourcesFailureDesc = newConstMetricDesc(
+		"hive_clustersync_resources_failure",
+		"Number of SyncSets and SelectorSyncSets a ClusterSync last failed to apply.",
+		"namespaced_name",
+	)
+)
+
+func newClusterSyncResourcesCollector(client client.Client) prometheus.Collector {
+	return clusterSyncResourcesCollector{
+		client:                            client,
+		metricClusterSyncResourcesSuccess: metricClusterSyncResourcesSuccessDesc,
+		metricClusterSyncResourcesFailure: metricClusterSyncResourcesFailureDesc,
+	}
+}
+
+// installed never synced metric collected through a custom prometheus collector
+type installedNeverSyncedCollector struct {
+	client client.Client
+
+	// clock is the source of the current time.
+	clock clock.PassiveClock
+
+	// minDuration, when non-zero, is how long a cluster must have been installed without its ClusterSync succeeding
+	// before it is reported. Clusters installed for exactly minDuration are reported.
+	minDuration time.Duration
+
+	// metricClusterDeploymentInstalledNeverSyncedSeconds is a prometheus metric for the number of seconds since an
+	// installed cluster, whose SyncSets and SelectorSyncSets have never all been applied, was installed.
+	metricClusterDeploymentInstalledNeverSyncedSeconds constMetricDesc
+}
+
+// Collect collects the metrics for installedNeverSyncedCollector
+func (cc installedNeverSyncedCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "installed never synced metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	// A ClusterSync has the name and namespace of its ClusterDeployment, so only those that have succeeded need to be
+	// remembered for the single pass through the ClusterDeployments.
+	synced := sets.New[types.NamespacedName]()
+	clusterSyncList := &hiveintv1alpha1.ClusterSyncList{}
+	csPages := scrape.list(cc.client, clusterSyncList)
+	for csPages.next() {
+		for _, cs := range clusterSyncList.Items {
+			if cs.Status.FirstSuccessTime != nil {
+				synced.Insert(types.NamespacedName{Namespace: cs.Namespace, Name: cs.Name})
+			}
+		}
+	}
+	if scrape.listFailed(csPages) {
+		return
+	}
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	cdPages := scrape.list(cc.client, clusterDeployments)
+	for cdPages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil || !cd.Spec.Installed {
+				continue
+			}
+			if synced.Has(types.NamespacedName{Namespace: cd.Namespace, Name: cd.Name}) {
+				continue
+			}
+			// Clusters installed before Hive recorded the install time are counted from their creation.
+			installed := cd.CreationTimestamp.Time
+			if cd.Status.InstalledTimestamp != nil {
+				installed = cd.Status.InstalledTimestamp.Time
+			}
+			elapsed := cc.clock.Since(installed)
+			if elapsed < cc.minDuration {
+				continue
+			}
+			ch <- cc.metricClusterDeploymentInstalledNeverSyncedSeconds.mustNewConstMetric(
+				prometheus.GaugeValue,
+				elapsed.Seconds(),
+				prometheus.Labels{
+					"cluster_deployment": cd.Name,
+					"namespace":          cd.Namespace,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(cdPages) {
+		return
+	}
+}
+
+func (cc installedNeverSyncedCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentInstalledNeverSyncedSeconds.Desc
+}
+
+var (
+	metricClusterDeploymentInstalledNeverSyncedSecondsDesc = newConstMetricDesc(
+		"hive_cluster_deployment_installed_never_synced_seconds",
+		"Length of time a cluster has been installed without its SyncSets and SelectorSyncSets ever all being applied.",
+		"cluster_deployment", "namespace",
+	)
+)
+
+// newInstalledNeverSyncedCollector returns a collector reporting clusters installed for at least minimum whose
+// ClusterSync has never succeeded, or which have no ClusterSync.
+func newInstalledNeverSyncedCollector(client client.Client, minimum time.Duration) prometheus.Collector {
+	return installedNeverSyncedCollector{
+		client:      client,
+		clock:       clock.RealClock{},
+		minDuration: minimum,
+		metricClusterDeploymentInstalledNeverSyncedSeconds: metricClusterDeploymentInstalledNeverSyncedSecondsDesc,
+	}
+}
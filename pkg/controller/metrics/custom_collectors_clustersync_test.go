@@ -0,0 +1,395 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hiveintv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
+	testcd "github.com/openshift/hive/pkg/test/clusterdeployment"
+	testcs "github.com/openshift/hive/pkg/test/clustersync"
+	testgeneric "github.com/openshift/hive/pkg/test/generic"
+	testsss "github.com/openshift/hive/pkg/test/selectorsyncset"
+	testsyncset "github.com/openshift/hive/pkg/test/syncset"
+	"github.com/openshift/hive/pkg/util/scheme"
+)
+
+func TestClusterSyncCollectors(t *testing.T) {
+	scheme := scheme.GetScheme()
+
+	cdBuilder := func(name string) testcd.Builder {
+		return testcd.FullBuilder(name, name, scheme)
+	}
+	csBuilder := func(name string) testcs.Builder {
+		return testcs.FullBuilder(name, name, scheme)
+	}
+	ssBuilder := func(name string) testsyncset.Builder {
+		return testsyncset.FullBuilder("ns", name, scheme)
+	}
+	deleted := []testgeneric.Option{testgeneric.Deleted(), testgeneric.WithFinalizer(testFinalizer)}
+	syncStatus := func(name string, result hiveintv1alpha1.SyncSetResult, since time.Time) hiveintv1alpha1.SyncStatus {
+		return hiveintv1alpha1.SyncStatus{
+			Name:               name,
+			Result:             result,
+			LastTransitionTime: metav1.NewTime(since),
+		}
+	}
+	failed := func(message string) hiveintv1alpha1.SyncStatus {
+		return hiveintv1alpha1.SyncStatus{
+			Name:           "ss",
+			Result:         hiveintv1alpha1.FailureSyncSetResult,
+			FailureMessage: message,
+		}
+	}
+	resources := []hiveintv1alpha1.SyncResourceReference{{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Namespace:  "default",
+		Name:       "cm",
+	}}
+	failedDeletes := hiveintv1alpha1.SyncStatus{
+		Name:              "removed",
+		Result:            hiveintv1alpha1.FailureSyncSetResult,
+		FailureMessage:    "failed to delete resources",
+		ResourcesToDelete: resources,
+	}
+	applied := hiveintv1alpha1.SyncStatus{
+		Name:              "applied",
+		Result:            hiveintv1alpha1.SuccessSyncSetResult,
+		ResourcesToDelete: resources,
+	}
+	installedCD := func(name string, age time.Duration) runtime.Object {
+		return cdBuilder(name).Build(testcd.Installed(), testcd.InstalledTimestamp(testNow.Add(-age)))
+	}
+	createdCD := func(name string, age time.Duration, opts ...testcd.Option) runtime.Object {
+		return cdBuilder(name).GenericOptions(testgeneric.WithCreationTimestamp(testNow.Add(-age))).Build(opts...)
+	}
+	failingSyncSetResources := func(min time.Duration) func(client.Client) prometheus.Collector {
+		return func(c client.Client) prometheus.Collector {
+			collect := newFailingSyncSetResourcesCollector(c, min).(failingSyncSetResourcesCollector)
+			collect.clock = clocktesting.NewFakePassiveClock(testNow)
+			return collect
+		}
+	}
+	selectorSyncSetNamespaceSpan := func(maximum int) func(client.Client) prometheus.Collector {
+		return func(c client.Client) prometheus.Collector {
+			return newSelectorSyncSetNamespaceSpanCollector(c, maximum)
+		}
+	}
+
+	failingSyncSets := []runtime.Object{
+		testcs.FullBuilder("ns-1", "cd-1", scheme).Build(
+			testcs.WithSyncSetStatus(syncStatus("ss-succeeding", hiveintv1alpha1.SuccessSyncSetResult, testNow.Add(-2*time.Hour))),
+			testcs.WithSyncSetStatus(syncStatus("ss-failing-long", hiveintv1alpha1.FailureSyncSetResult, testNow.Add(-2*time.Hour))),
+			testcs.WithSyncSetStatus(syncStatus("ss-failing-recent", hiveintv1alpha1.FailureSyncSetResult, testNow.Add(-time.Minute))),
+			testcs.WithSelectorSyncSetStatus(syncStatus("sss-succeeding", hiveintv1alpha1.SuccessSyncSetResult, testNow.Add(-2*time.Hour))),
+			testcs.WithSelectorSyncSetStatus(syncStatus("sss-failing-long", hiveintv1alpha1.FailureSyncSetResult, testNow.Add(-2*time.Hour))),
+		),
+		testcs.FullBuilder("ns-2", "cd-2", scheme).Build(
+			testcs.WithSyncSetStatus(syncStatus("ss-succeeding", hiveintv1alpha1.SuccessSyncSetResult, testNow.Add(-2*time.Hour))),
+		),
+	}
+	labeledCD := func(namespace, name string, opts ...testgeneric.Option) runtime.Object {
+		return testcd.FullBuilder(namespace, name, scheme).GenericOptions(opts...).Build()
+	}
+	selectorSyncSets := []runtime.Object{
+		labeledCD("ns-1", "cd-1", testgeneric.WithLabel("team", "a"), testgeneric.WithLabel("env", "prod")),
+		labeledCD("ns-1", "cd-2", testgeneric.WithLabel("team", "a")),
+		labeledCD("ns-2", "cd-3", testgeneric.WithLabel("team", "b"), testgeneric.WithLabel("env", "prod")),
+		labeledCD("ns-3", "cd-4", testgeneric.WithLabel("team", "b"), testgeneric.WithLabel("env", "prod")),
+		labeledCD("ns-4", "cd-5", append([]testgeneric.Option{testgeneric.WithLabel("env", "prod")}, deleted...)...),
+		// Matching several clusters in a single namespace.
+		testsss.FullBuilder("sss-team-a", scheme).Build(testsss.WithLabelSelector("team", "a")),
+		testsss.FullBuilder("sss-team-b", scheme).Build(testsss.WithLabelSelector("team", "b")),
+		testsss.FullBuilder("sss-prod", scheme).Build(testsss.WithLabelSelector("env", "prod")),
+		testsss.FullBuilder("sss-unmatched", scheme).Build(testsss.WithLabelSelector("team", "c")),
+	}
+
+	runCollectorTests(t, []collectorTest{{
+		name:      "failing syncset resources",
+		collector: failingSyncSetResources(0),
+		cases: []collectorCase{{
+			name:     "all failures",
+			existing: failingSyncSets,
+			expected: []string{
+				"clustersync_name = cd-1 clustersync_namespace = ns-1 syncset_name = ss-failing-long syncset_type = syncset 7200",
+				"clustersync_name = cd-1 clustersync_namespace = ns-1 syncset_name = ss-failing-recent syncset_type = syncset 60",
+				"clustersync_name = cd-1 clustersync_namespace = ns-1 syncset_name = sss-failing-long syncset_type = selectorsyncset 7200",
+			},
+		}, {
+			name:      "failures over min",
+			existing:  failingSyncSets,
+			collector: failingSyncSetResources(time.Hour),
+			expected: []string{
+				"clustersync_name = cd-1 clustersync_namespace = ns-1 syncset_name = ss-failing-long syncset_type = syncset 7200",
+				"clustersync_name = cd-1 clustersync_namespace = ns-1 syncset_name = sss-failing-long syncset_type = selectorsyncset 7200",
+			},
+		}, {
+			name:      "failures at exactly min",
+			existing:  failingSyncSets,
+			collector: failingSyncSetResources(time.Minute),
+			expected: []string{
+				"clustersync_name = cd-1 clustersync_namespace = ns-1 syncset_name = ss-failing-long syncset_type = syncset 7200",
+				"clustersync_name = cd-1 clustersync_namespace = ns-1 syncset_name = ss-failing-recent syncset_type = syncset 60",
+				"clustersync_name = cd-1 clustersync_namespace = ns-1 syncset_name = sss-failing-long syncset_type = selectorsyncset 7200",
+			},
+		}, {
+			name:      "min over all failures",
+			existing:  failingSyncSets,
+			collector: failingSyncSetResources(3 * time.Hour),
+		}},
+	}, {
+		name: "sync identity provider failing",
+		collector: func(c client.Client) prometheus.Collector {
+			collect := newSyncIdentityProviderFailingCollector(c).(syncIdentityProviderFailingCollector)
+			collect.clock = clocktesting.NewFakePassiveClock(testNow)
+			return collect
+		},
+		cases: []collectorCase{{
+			name: "identity provider and ordinary syncsets",
+			existing: []runtime.Object{
+				// Identity providers failing alongside an ordinary SyncSet.
+				testcs.FullBuilder("ns-1", "cd-1", scheme).Build(
+					testcs.WithSyncSetStatus(syncStatus("cd-1-idp", hiveintv1alpha1.FailureSyncSetResult, testNow.Add(-2*time.Hour))),
+					testcs.WithSyncSetStatus(syncStatus("ss-failing", hiveintv1alpha1.FailureSyncSetResult, testNow.Add(-3*time.Hour))),
+				),
+				// Only ordinary SyncSets and SelectorSyncSets failing.
+				testcs.FullBuilder("ns-2", "cd-2", scheme).Build(
+					testcs.WithSyncSetStatus(syncStatus("cd-2-idp", hiveintv1alpha1.SuccessSyncSetResult, testNow.Add(-2*time.Hour))),
+					testcs.WithSyncSetStatus(syncStatus("ss-failing", hiveintv1alpha1.FailureSyncSetResult, testNow.Add(-time.Hour))),
+					testcs.WithSelectorSyncSetStatus(syncStatus("sss-failing", hiveintv1alpha1.FailureSyncSetResult, testNow.Add(-time.Hour))),
+				),
+				// The identity provider SyncSet of another cluster is an ordinary SyncSet here.
+				testcs.FullBuilder("ns-3", "cd-3", scheme).Build(
+					testcs.WithSyncSetStatus(syncStatus("cd-1-idp", hiveintv1alpha1.FailureSyncSetResult, testNow.Add(-time.Hour))),
+				),
+				testcs.FullBuilder("ns-4", "cd-4", scheme).Build(
+					testcs.WithSyncSetStatus(syncStatus("cd-4-idp", hiveintv1alpha1.FailureSyncSetResult, testNow.Add(-time.Minute))),
+				),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = ns-1 7200",
+				"cluster_deployment = cd-4 namespace = ns-4 60",
+			},
+		}},
+	}, {
+		name:      "selectorsyncset namespace span",
+		collector: selectorSyncSetNamespaceSpan(1),
+		cases: []collectorCase{{
+			name:     "multiple namespaces",
+			existing: selectorSyncSets,
+			expected: []string{
+				"name = sss-prod namespace_count = 3 3",
+				"name = sss-team-b namespace_count = 2 2",
+			},
+		}, {
+			name:      "above maximum",
+			existing:  selectorSyncSets,
+			collector: selectorSyncSetNamespaceSpan(2),
+			expected: []string{
+				"name = sss-prod namespace_count = 3 3",
+			},
+		}, {
+			name:      "single namespace",
+			existing:  selectorSyncSets,
+			collector: selectorSyncSetNamespaceSpan(0),
+			expected: []string{
+				"name = sss-prod namespace_count = 3 3",
+				"name = sss-team-a namespace_count = 1 1",
+				"name = sss-team-b namespace_count = 2 2",
+			},
+		}},
+	}, {
+		name:      "syncset create only",
+		collector: newSyncSetCreateOnlyCollector,
+		cases: []collectorCase{{
+			name:     "no syncsets",
+			expected: []string{" 0"},
+		}, {
+			name: "no create only syncsets",
+			existing: []runtime.Object{
+				ssBuilder("ss-1").Build(),
+				ssBuilder("ss-2").Build(testsyncset.WithApplyBehavior(hivev1.ApplySyncSetApplyBehavior)),
+				ssBuilder("ss-3").Build(testsyncset.WithApplyBehavior(hivev1.CreateOrUpdateSyncSetApplyBehavior)),
+			},
+			expected: []string{" 0"},
+		}, {
+			name: "mix of create only and other behaviors",
+			existing: []runtime.Object{
+				ssBuilder("ss-1").Build(testsyncset.WithApplyBehavior(hivev1.CreateOnlySyncSetApplyBehavior)),
+				ssBuilder("ss-2").Build(testsyncset.WithApplyBehavior(hivev1.ApplySyncSetApplyBehavior)),
+				ssBuilder("ss-3").Build(testsyncset.WithApplyBehavior(hivev1.CreateOnlySyncSetApplyBehavior)),
+				ssBuilder("ss-4").Build(),
+				ssBuilder("ss-5").GenericOptions(deleted...).Build(testsyncset.WithApplyBehavior(hivev1.CreateOnlySyncSetApplyBehavior)),
+			},
+			expected: []string{" 2"},
+		}},
+	}, {
+		name:      "clustersync pending deletes",
+		collector: newClusterSyncPendingDeletesCollector,
+		cases: []collectorCase{{
+			name: "no clustersyncs",
+		}, {
+			name: "failing with pending deletes",
+			existing: []runtime.Object{
+				csBuilder("cs-1").Build(FailingSince(testNow), testcs.WithSyncSetStatus(failedDeletes)),
+				csBuilder("cs-2").Build(FailingSince(testNow), testcs.WithSelectorSyncSetStatus(failedDeletes)),
+			},
+			expected: []string{
+				"namespaced_name = cs-1/cs-1 1",
+				"namespaced_name = cs-2/cs-2 1",
+			},
+		}, {
+			name: "failing without pending deletes",
+			existing: []runtime.Object{
+				csBuilder("cs-1").Build(FailingSince(testNow), testcs.WithSyncSetStatus(applied)),
+				csBuilder("cs-2").Build(FailingSince(testNow), testcs.WithSelectorSyncSetStatus(hiveintv1alpha1.SyncStatus{
+					Name:   "failed",
+					Result: hiveintv1alpha1.FailureSyncSetResult,
+				})),
+			},
+		}, {
+			name: "pending deletes without failing",
+			existing: []runtime.Object{
+				csBuilder("cs-1").Build(testcs.WithSyncSetStatus(failedDeletes)),
+				csBuilder("cs-2").Build(testcs.WithSelectorSyncSetStatus(failedDeletes), testcs.WithCondition(hiveintv1alpha1.ClusterSyncCondition{
+					Type:   hiveintv1alpha1.ClusterSyncFailed,
+					Status: corev1.ConditionFalse,
+				})),
+			},
+		}},
+	}, {
+		name:      "clustersync CRD ordering",
+		collector: newClusterSyncCRDOrderingCollector,
+		cases: []collectorCase{{
+			name: "CRD ordering and other failures",
+			existing: []runtime.Object{
+				csBuilder("cs-1").Build(FailingSince(testNow), testcs.WithSyncSetStatus(
+					failed(`unable to recognize "": no matches for kind "Widget" in version "example.com/v1"`))),
+				csBuilder("cs-2").Build(FailingSince(testNow), testcs.WithSelectorSyncSetStatus(
+					failed(`resource mapping not found for name: "widget" namespace: "default": ensure CRDs are installed first`))),
+				// Failures unrelated to CRDs.
+				csBuilder("cs-3").Build(FailingSince(testNow), testcs.WithSyncSetStatus(
+					failed(`configmaps "cm" is forbidden: User "system:serviceaccount:hive:hive" cannot create resource "configmaps"`))),
+				csBuilder("cs-4").Build(FailingSince(testNow), testcs.WithSelectorSyncSetStatus(
+					failed(`Secret "pull-secret" is invalid: data[.dockerconfigjson]: Invalid value`))),
+				// A CRD ordering error that has since been resolved.
+				csBuilder("cs-5").Build(testcs.WithSyncSetStatus(hiveintv1alpha1.SyncStatus{
+					Name:           "ss",
+					Result:         hiveintv1alpha1.SuccessSyncSetResult,
+					FailureMessage: `no matches for kind "Widget" in version "example.com/v1"`,
+				})),
+			},
+			expected: []string{
+				"namespaced_name = cs-1/cs-1 1",
+				"namespaced_name = cs-2/cs-2 1",
+			},
+		}},
+	}, {
+		name:      "clustersync failing expected",
+		collector: newClusterSyncFailingExpectedCollector,
+		cases: []collectorCase{{
+			name: "failing by power state",
+			existing: []runtime.Object{
+				// Failing while hibernating.
+				cdBuilder("cd-1").Build(testcd.WithPowerState(hivev1.ClusterPowerStateHibernating)),
+				csBuilder("cd-1").Build(FailingSince(testNow)),
+				// Failing while running.
+				cdBuilder("cd-2").Build(testcd.WithPowerState(hivev1.ClusterPowerStateRunning)),
+				csBuilder("cd-2").Build(FailingSince(testNow)),
+				// Failing without a power state.
+				cdBuilder("cd-3").Build(),
+				csBuilder("cd-3").Build(FailingSince(testNow)),
+				// Hibernating but not failing.
+				cdBuilder("cd-4").Build(testcd.WithPowerState(hivev1.ClusterPowerStateHibernating)),
+				csBuilder("cd-4").Build(),
+				// Failing without a ClusterDeployment.
+				csBuilder("cd-5").Build(FailingSince(testNow)),
+			},
+			expected: []string{
+				"namespaced_name = cd-1/cd-1 1",
+			},
+		}},
+	}, {
+		name:      "clustersync resources",
+		collector: newClusterSyncResourcesCollector,
+		named:     true,
+		cases: []collectorCase{{
+			name: "synced and failed resources",
+			existing: []runtime.Object{
+				csBuilder("cd-1").Build(
+					testcs.WithSyncSetStatus(hiveintv1alpha1.SyncStatus{Name: "ss-1", Result: hiveintv1alpha1.SuccessSyncSetResult}),
+					testcs.WithSyncSetStatus(hiveintv1alpha1.SyncStatus{Name: "ss-2", Result: hiveintv1alpha1.FailureSyncSetResult}),
+					testcs.WithSyncSetStatus(hiveintv1alpha1.SyncStatus{Name: "ss-3", Result: hiveintv1alpha1.SuccessSyncSetResult}),
+					testcs.WithSelectorSyncSetStatus(hiveintv1alpha1.SyncStatus{Name: "sss-1", Result: hiveintv1alpha1.SuccessSyncSetResult}),
+					testcs.WithSelectorSyncSetStatus(hiveintv1alpha1.SyncStatus{Name: "sss-2", Result: hiveintv1alpha1.FailureSyncSetResult}),
+				),
+				csBuilder("cd-2").Build(
+					testcs.WithSelectorSyncSetStatus(hiveintv1alpha1.SyncStatus{Name: "sss-1", Result: hiveintv1alpha1.SuccessSyncSetResult}),
+				),
+				// Nothing synced yet.
+				csBuilder("cd-3").Build(),
+			},
+			expected: []string{
+				"hive_clustersync_resources_success namespaced_name = cd-1/cd-1 3",
+				"hive_clustersync_resources_failure namespaced_name = cd-1/cd-1 2",
+				"hive_clustersync_resources_success namespaced_name = cd-2/cd-2 1",
+				"hive_clustersync_resources_failure namespaced_name = cd-2/cd-2 0",
+				"hive_clustersync_resources_success namespaced_name = cd-3/cd-3 0",
+				"hive_clustersync_resources_failure namespaced_name = cd-3/cd-3 0",
+			},
+		}},
+	}, {
+		name: "installed never synced",
+		collector: func(c client.Client) prometheus.Collector {
+			collect := newInstalledNeverSyncedCollector(c, time.Hour).(installedNeverSyncedCollector)
+			collect.clock = clocktesting.NewFakePassiveClock(testNow)
+			return collect
+		},
+		cases: []collectorCase{{
+			name: "installed and synced",
+			existing: []runtime.Object{
+				installedCD("cd-1", 3*time.Hour),
+				csBuilder("cd-1").Build(testcs.WithFirstSuccessTime(testNow.Add(-2 * time.Hour))),
+			},
+		}, {
+			name: "installed and never synced past threshold",
+			existing: []runtime.Object{
+				installedCD("cd-1", 3*time.Hour),
+				csBuilder("cd-1").Build(FailingSince(testNow.Add(-2 * time.Hour))),
+				// No ClusterSync at all.
+				installedCD("cd-2", 2*time.Hour),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = cd-1 10800",
+				"cluster_deployment = cd-2 namespace = cd-2 7200",
+			},
+		}, {
+			name: "installed and never synced under threshold",
+			existing: []runtime.Object{
+				installedCD("cd-1", 30*time.Minute),
+				csBuilder("cd-1").Build(),
+			},
+		}, {
+			name: "not installed",
+			existing: []runtime.Object{
+				createdCD("cd-1", 3*time.Hour),
+			},
+		}, {
+			name: "installed without install time",
+			existing: []runtime.Object{
+				createdCD("cd-1", 3*time.Hour, testcd.Installed()),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = cd-1 10800",
+			},
+		}},
+	}})
+}
@@ -0,0 +1,287 @@
+package metrics
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/utils/clock"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	controllerutils "github.com/openshift/hive/pkg/controller/utils"
+)
+
+// deprovisioning underway metric collected through a custom prometheus collector
+type deprovisioningUnderwayCollector struct {
+	client client.Client
+
+	// clock is the source of the current time.
+	clock clock.PassiveClock
+
+	// TODO: Make metric optional and allow for a minDuration to be specified by user.
+
+	// excludedNamespaces filters out objects in namespaces that should not be reported.
+	excludedNamespaces namespaceFilter
+
+	// includedClusterTypes limits the ClusterDeployments reported to those of the given cluster types.
+	includedClusterTypes clusterTypeFilter
+
+	// clusterTypeLabel is the label whose value is reported as cluster_type.
+	clusterTypeLabel clusterTypeLabel
+
+	// includePaused reports clusters whose reconciles are paused, with the paused label, rather than skipping them.
+	includePaused bool
+
+	// metricClusterDeploymentDeprovisionUnderwaySeconds is a prometheus metric for the number of seconds
+	// between when a deprovisioning cluster DeletionTimestamp was set and now.
+	metricClusterDeploymentDeprovisionUnderwaySeconds constMetricDesc
+}
+
+// Collect collects the metrics for deprovisioningUnderwayCollector
+func (cc deprovisioningUnderwayCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "deprovisioning underway metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	// Load all ClusterDeployments so we can accumulate facts about them.
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	buffer := cc.metricClusterDeploymentDeprovisionUnderwaySeconds.newBuffer()
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			// Without finalizers, nothing is holding up the deletion.
+			if cd.DeletionTimestamp == nil || len(cd.Finalizers) == 0 {
+				continue
+			}
+			clusterType := cc.clusterTypeLabel.value(&cd)
+			if cc.excludedNamespaces.excludes(cd.Namespace) || !cc.includedClusterTypes.includes(clusterType) {
+				continue
+			}
+			paused := isReconcilePaused(&cd)
+			if paused && !cc.includePaused {
+				continue
+			}
+
+			elapsedDuration := cc.clock.Since(cd.DeletionTimestamp.Time)
+
+			buffer.labels["blocked_on"] = getBlockedOn(&cd)
+			buffer.labels["cluster_deployment"] = cd.Name
+			buffer.labels["cluster_type"] = clusterType
+			buffer.labels["namespace"] = cd.Namespace
+			if cc.includePaused {
+				buffer.labels["paused"] = strconv.FormatBool(paused)
+			}
+			// For installing clusters we report the seconds since the cluster was created.
+			ch <- buffer.mustNewConstMetric(prometheus.GaugeValue, elapsedDuration.Seconds())
+
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+
+}
+
+func (cc deprovisioningUnderwayCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentDeprovisionUnderwaySeconds.Desc
+}
+
+var (
+	metricClusterDeploymentDeprovisionUnderwaySecondsDesc = newConstMetricDesc(
+		"hive_cluster_deployment_deprovision_underway_seconds",
+		"Length of time a cluster has been deprovisioning.",
+		"blocked_on", "cluster_deployment", "cluster_type", "namespace",
+	)
+)
+
+// getBlockedOn returns the finalizers other than Hive's deprovision finalizer that hold up the deletion of cd, sorted
+// and joined by commas, or "none" when only Hive's finalizer is left.
+func getBlockedOn(cd *hivev1.ClusterDeployment) string {
+	var foreign []string
+	for _, finalizer := range cd.Finalizers {
+		if finalizer != hivev1.FinalizerDeprovision {
+			foreign = append(foreign, finalizer)
+		}
+	}
+	if len(foreign) == 0 {
+		return "none"
+	}
+	sort.Strings(foreign)
+	return strings.Join(foreign, ",")
+}
+
+// deprovisioningUnderwayOptions configures the collector returned by newDeprovisioningUnderwaySecondsCollector. Its
+// fields filter and label clusters as those of provisioningUnderwayOptions do.
+type deprovisioningUnderwayOptions struct {
+	excludedNamespaces  []string
+	includeClusterTypes []string
+	clusterTypeLabelKey string
+	includePaused       bool
+}
+
+// newDeprovisioningUnderwaySecondsCollector returns a collector reporting the clusters being deprovisioned. The
+// blocked_on label names the finalizers other than Hive's that remain, as returned by getBlockedOn.
+func newDeprovisioningUnderwaySecondsCollector(client client.Client, opts deprovisioningUnderwayOptions) prometheus.Collector {
+	desc := metricClusterDeploymentDeprovisionUnderwaySecondsDesc
+	if opts.includePaused {
+		desc = desc.withLabels("paused")
+	}
+	return deprovisioningUnderwayCollector{
+		client: client,
+		clock:  clock.RealClock{},
+		metricClusterDeploymentDeprovisionUnderwaySeconds: desc,
+		excludedNamespaces:   newNamespaceFilter(opts.excludedNamespaces),
+		includedClusterTypes: clusterTypeFilter(opts.includeClusterTypes),
+		clusterTypeLabel:     newClusterTypeLabel(opts.clusterTypeLabelKey),
+		includePaused:        opts.includePaused,
+	}
+}
+
+// oldest deprovision metric collected through a custom prometheus collector
+type deprovisionOldestCollector struct {
+	client client.Client
+
+	// clock is the source of the current time.
+	clock clock.PassiveClock
+
+	// metricClusterDeploymentDeprovisionOldestSeconds is a prometheus metric for the number of seconds the
+	// ClusterDeployment that has been waiting longest on its deprovision finalizer has been deleted.
+	metricClusterDeploymentDeprovisionOldestSeconds constMetricDesc
+}
+
+// Collect collects the metrics for deprovisionOldestCollector
+func (cc deprovisionOldestCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "oldest deprovision metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	var oldest *hivev1.ClusterDeployment
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	for pages.next() {
+		for i := range clusterDeployments.Items {
+			cd := &clusterDeployments.Items[i]
+			if cd.DeletionTimestamp == nil || !controllerutils.HasFinalizer(cd, hivev1.FinalizerDeprovision) {
+				continue
+			}
+			// Ties go to the first ClusterDeployment by namespace and name, so the label is stable between scrapes.
+			if oldest == nil || cd.DeletionTimestamp.Before(oldest.DeletionTimestamp) ||
+				(cd.DeletionTimestamp.Equal(oldest.DeletionTimestamp) &&
+					cd.Namespace+"/"+cd.Name < oldest.Namespace+"/"+oldest.Name) {
+				oldest = cd.DeepCopy()
+			}
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+	if oldest == nil {
+		return
+	}
+	ch <- cc.metricClusterDeploymentDeprovisionOldestSeconds.mustNewConstMetric(
+		prometheus.GaugeValue,
+		cc.clock.Since(oldest.DeletionTimestamp.Time).Seconds(),
+		prometheus.Labels{
+			"cluster_deployment": oldest.Name,
+			"namespace":          oldest.Namespace,
+		},
+	)
+}
+
+func (cc deprovisionOldestCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentDeprovisionOldestSeconds.Desc
+}
+
+var (
+	metricClusterDeploymentDeprovisionOldestSecondsDesc = newConstMetricDesc(
+		"hive_cluster_deployment_deprovision_oldest_seconds",
+		"Length of time the cluster waiting longest on its deprovision finalizer has been deleted.",
+		"cluster_deployment", "namespace",
+	)
+)
+
+// newDeprovisionOldestCollector returns a collector reporting the single ClusterDeployment whose deprovision
+// finalizer has been pending longest.
+func newDeprovisionOldestCollector(client client.Client) prometheus.Collector {
+	return deprovisionOldestCollector{
+		client: client,
+		clock:  clock.RealClock{},
+		metricClusterDeploymentDeprovisionOldestSeconds: metricClusterDeploymentDeprovisionOldestSecondsDesc,
+	}
+}
+
+// failingClusterDeprovisionCollector reports ClusterDeprovisions that have not completed, such as those unable to
+// remove the cluster's cloud resources.
+type failingClusterDeprovisionCollector struct {
+	client client.Client
+
+	// clock is the source of the current time.
+	clock clock.PassiveClock
+
+	// minDuration, when non-zero, is the minimum duration after which an incomplete ClusterDeprovision
+	// will start becoming part of this metric. When set to zero, all incomplete ClusterDeprovisions
+	// will be included in the metric.
+	minDuration time.Duration
+
+	// metricClusterDeprovisionUnderwaySeconds is a prometheus metric for the number of seconds since an incomplete
+	// ClusterDeprovision was created.
+	metricClusterDeprovisionUnderwaySeconds constMetricDesc
+}
+
+// Collect collects the metrics for failingClusterDeprovisionCollector
+func (cc failingClusterDeprovisionCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "deprovisioning underway metrics across all ClusterDeprovisions")
+	defer scrape.done()
+
+	clusterDeprovisions := &hivev1.ClusterDeprovisionList{}
+	pages := scrape.list(cc.client, clusterDeprovisions)
+	for pages.next() {
+		for _, deprovision := range clusterDeprovisions.Items {
+			if deprovision.Status.Completed {
+				continue
+			}
+			elapsedDuration := cc.clock.Since(deprovision.CreationTimestamp.Time)
+			if elapsedDuration < cc.minDuration {
+				continue
+			}
+			ch <- cc.metricClusterDeprovisionUnderwaySeconds.mustNewConstMetric(
+				prometheus.GaugeValue,
+				elapsedDuration.Seconds(),
+				prometheus.Labels{
+					"cluster_deprovision": deprovision.Name,
+					"namespace":           deprovision.Namespace,
+					"platform":            controllerutils.GetClusterDeprovisionPlatform(&deprovision),
+				},
+			)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+func (cc failingClusterDeprovisionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeprovisionUnderwaySeconds.Desc
+}
+
+var (
+	metricClusterDeprovisionUnderwaySecondsDesc = newConstMetricDesc(
+		"hive_cluster_deprovision_underway_seconds",
+		"Length of time an incomplete ClusterDeprovision has existed.",
+		"cluster_deprovision", "namespace", "platform",
+	)
+)
+
+// newFailingClusterDeprovisionCollector returns a collector reporting ClusterDeprovisions that have not completed
+// within minimum of being created.
+func newFailingClusterDeprovisionCollector(client client.Client, minimum time.Duration) prometheus.Collector {
+	return failingClusterDeprovisionCollector{
+		client:                                  client,
+		clock:                                   clock.RealClock{},
+		minDuration:                             minimum,
+		metricClusterDeprovisionUnderwaySeconds: metricClusterDeprovisionUnderwaySecondsDesc,
+	}
+}
@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	testcd "github.com/openshift/hive/pkg/test/clusterdeployment"
+	testclusterdeprovision "github.com/openshift/hive/pkg/test/clusterdeprovision"
+	testgeneric "github.com/openshift/hive/pkg/test/generic"
+	"github.com/openshift/hive/pkg/util/scheme"
+)
+
+func TestDeprovisioningCollectors(t *testing.T) {
+	scheme := scheme.GetScheme()
+
+	cdBuilder := func(name string) testcd.Builder {
+		return testcd.FullBuilder(name, name, scheme)
+	}
+	deletedCD := func(name string, age time.Duration, finalizer string) runtime.Object {
+		return cdBuilder(name).GenericOptions(
+			testgeneric.WithFinalizer(finalizer),
+			func(meta hivev1.MetaRuntimeObject) {
+				deleted := metav1.NewTime(testNow.Add(-age))
+				meta.SetDeletionTimestamp(&deleted)
+			},
+		).Build()
+	}
+	deprovision := func(name string, age time.Duration, opts ...testclusterdeprovision.Option) *hivev1.ClusterDeprovision {
+		return testclusterdeprovision.FullBuilder(name, name, scheme).
+			GenericOptions(testgeneric.WithCreationTimestamp(testNow.Add(-age))).
+			Build(opts...)
+	}
+	onAWS := func(d *hivev1.ClusterDeprovision) {
+		d.Spec.Platform.AWS = &hivev1.AWSClusterDeprovision{Region: "us-east-1"}
+	}
+	onGCP := func(d *hivev1.ClusterDeprovision) {
+		d.Spec.Platform.GCP = &hivev1.GCPClusterDeprovision{Region: "us-east1"}
+	}
+	failingClusterDeprovision := func(min time.Duration) func(client.Client) prometheus.Collector {
+		return func(c client.Client) prometheus.Collector {
+			collect := newFailingClusterDeprovisionCollector(c, min).(failingClusterDeprovisionCollector)
+			collect.clock = clocktesting.NewFakePassiveClock(testNow)
+			return collect
+		}
+	}
+
+	runCollectorTests(t, []collectorTest{{
+		name: "deprovision oldest",
+		collector: func(c client.Client) prometheus.Collector {
+			collect := newDeprovisionOldestCollector(c).(deprovisionOldestCollector)
+			collect.clock = clocktesting.NewFakePassiveClock(testNow)
+			return collect
+		},
+		cases: []collectorCase{{
+			name: "no cluster deployments",
+		}, {
+			name: "none deleted",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(),
+			},
+		}, {
+			name: "oldest of several",
+			existing: []runtime.Object{
+				deletedCD("cd-1", 10*time.Minute, hivev1.FinalizerDeprovision),
+				deletedCD("cd-2", 3*time.Hour, hivev1.FinalizerDeprovision),
+				deletedCD("cd-3", time.Hour, hivev1.FinalizerDeprovision),
+				cdBuilder("cd-4").Build(),
+			},
+			expected: []string{
+				"cluster_deployment = cd-2 namespace = cd-2 10800",
+			},
+		}, {
+			name: "deprovision finalizer removed",
+			existing: []runtime.Object{
+				deletedCD("cd-1", 10*time.Minute, hivev1.FinalizerDeprovision),
+				deletedCD("cd-2", 3*time.Hour, testFinalizer),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = cd-1 600",
+			},
+		}, {
+			name: "ties broken by namespace and name",
+			existing: []runtime.Object{
+				deletedCD("cd-3", time.Hour, hivev1.FinalizerDeprovision),
+				deletedCD("cd-2", time.Hour, hivev1.FinalizerDeprovision),
+				deletedCD("cd-1", time.Minute, hivev1.FinalizerDeprovision),
+			},
+			expected: []string{
+				"cluster_deployment = cd-2 namespace = cd-2 3600",
+			},
+		}},
+	}, {
+		name:      "failing cluster deprovision",
+		collector: failingClusterDeprovision(0),
+		cases: []collectorCase{{
+			name: "completed, stalled and newly created deprovisions",
+			existing: []runtime.Object{
+				deprovision("cdp-1", 3*time.Hour, onAWS, testclusterdeprovision.Completed()),
+				deprovision("cdp-2", 2*time.Hour, onAWS),
+				deprovision("cdp-3", 1*time.Hour, onGCP, testclusterdeprovision.WithAuthenticationFailure()),
+				deprovision("cdp-4", 10*time.Minute, onGCP),
+			},
+			collector: failingClusterDeprovision(time.Hour),
+			expected: []string{
+				"cluster_deprovision = cdp-2 namespace = cdp-2 platform = aws 7200",
+				"cluster_deprovision = cdp-3 namespace = cdp-3 platform = gcp 3600",
+			},
+		}, {
+			name: "no minimum",
+			existing: []runtime.Object{
+				deprovision("cdp-1", 3*time.Hour, onAWS, testclusterdeprovision.Completed()),
+				deprovision("cdp-2", 10*time.Minute),
+			},
+			expected: []string{
+				"cluster_deprovision = cdp-2 namespace = cdp-2 platform = unknown 600",
+			},
+		}},
+	}})
+}
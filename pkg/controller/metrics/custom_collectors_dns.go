@@ -0,0 +1,414 @@
+package metrics
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/openshift/hive/pkg/constants"
+	controllerutils "github.com/openshift/hive/pkg/controller/utils"
+)
+
+// dns zone record conflict metrics collected through a custom prometheus collector
+type dnsZoneRecordConflictCollector struct {
+	client client.Client
+
+	// metricDNSZoneRecordConflict is a prometheus metric reporting DNSZones whose zone is also
+	// managed by another DNSZone, in which case both will be writing to the same records.
+	metricDNSZoneRecordConflict constMetricDesc
+}
+
+// Collect collects the metrics for dnsZoneRecordConflictCollector
+func (cc dnsZoneRecordConflictCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "record conflict metrics across all DNSZones")
+	defer scrape.done()
+
+	dnsZones := &hivev1.DNSZoneList{}
+	pages := scrape.list(cc.client, dnsZones)
+	// The DNSZones managing each zone, gathered across all pages.
+	zoneOwners := map[string][]types.NamespacedName{}
+	for pages.next() {
+		for _, dnsZone := range dnsZones.Items {
+			if dnsZone.DeletionTimestamp != nil {
+				continue
+			}
+			zone := normalizeZone(dnsZone.Spec.Zone)
+			zoneOwners[zone] = append(zoneOwners[zone], types.NamespacedName{Namespace: dnsZone.Namespace, Name: dnsZone.Name})
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+	for _, owners := range zoneOwners {
+		if len(owners) < 2 {
+			continue
+		}
+		for _, owner := range owners {
+			ch <- cc.metricDNSZoneRecordConflict.mustNewConstMetric(
+				prometheus.GaugeValue,
+				1,
+				prometheus.Labels{
+					"dns_zone":  owner.Name,
+					"namespace": owner.Namespace,
+				},
+			)
+		}
+	}
+}
+
+func (cc dnsZoneRecordConflictCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricDNSZoneRecordConflict.Desc
+}
+
+var (
+	metricDNSZoneRecordConflictDesc = newConstMetricDesc(
+		"hive_dnszone_record_conflict",
+		"Whether the zone of a DNSZone is also managed by another DNSZone.",
+		"dns_zone", "namespace",
+	)
+)
+
+func newDNSZoneRecordConflictCollector(client client.Client) prometheus.Collector {
+	return dnsZoneRecordConflictCollector{
+		client:                      client,
+		metricDNSZoneRecordConflict: metricDNSZoneRecordConflictDesc,
+	}
+}
+
+// normalizeZone returns the zone in a form that can be compared with other zones.
+func normalizeZone(zone string) string {
+	return strings.ToLower(strings.TrimSuffix(zone, "."))
+}
+
+// dnsLimitFailureReasons are the provision failure reasons, as set by the install log regexes, that indicate a
+// cloud DNS limit was hit.
+var dnsLimitFailureReasons = sets.New[string](
+	"TooManyRoute53Zones",
+)
+
+// dns limit failure metrics collected through a custom prometheus collector
+type dnsLimitFailuresCollector struct {
+	client client.Client
+
+	// metricClusterDeploymentDNSLimitFailures is a prometheus metric for the number of ClusterDeployments,
+	// per platform, whose last provision failed because a cloud DNS limit was hit.
+	metricClusterDeploymentDNSLimitFailures constMetricDesc
+}
+
+// Collect collects the metrics for dnsLimitFailuresCollector
+func (cc dnsLimitFailuresCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "dns limit failure metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	counts := map[string]int{}
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			cond := controllerutils.FindCondition(cd.Status.Conditions, hivev1.ProvisionFailedCondition)
+			if cond == nil || cond.Status != corev1.ConditionTrue || !dnsLimitFailureReasons.Has(cond.Reason) {
+				continue
+			}
+			counts[cd.Labels[hivev1.HiveClusterPlatformLabel]]++
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+	for platform, count := range counts {
+		ch <- cc.metricClusterDeploymentDNSLimitFailures.mustNewConstMetric(
+			prometheus.GaugeValue,
+			float64(count),
+			prometheus.Labels{
+				"platform": platform,
+			},
+		)
+	}
+}
+
+func (cc dnsLimitFailuresCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentDNSLimitFailures.Desc
+}
+
+var (
+	metricClusterDeploymentDNSLimitFailuresDesc = newConstMetricDesc(
+		"hive_cluster_deployment_dns_limit_failures",
+		"Number of clusters whose provision failed because a cloud DNS limit was exceeded.",
+		"platform",
+	)
+)
+
+func newDNSLimitFailuresCollector(client client.Client) prometheus.Collector {
+	return dnsLimitFailuresCollector{
+		client:                                  client,
+		metricClusterDeploymentDNSLimitFailures: metricClusterDeploymentDNSLimitFailuresDesc,
+	}
+}
+
+var (
+	// dnsCleanupErrorConditions are DNSZone conditions indicating the DNS provider could not be reconciled.
+	dnsCleanupErrorConditions = [...]hivev1.DNSZoneConditionType{
+		hivev1.GenericDNSErrorsCondition,
+		hivev1.InsufficientCredentialsCondition,
+		hivev1.AuthenticationFailureCondition,
+		hivev1.APIOptInRequiredCondition,
+	}
+)
+
+// dnsCleanupFailedCollector reports deleting ClusterDeployments whose managed DNSZone could not be cleaned up.
+type dnsCleanupFailedCollector struct {
+	client client.Client
+
+	// metricClusterDeploymentDNSCleanupFailed is a prometheus metric reporting deleting ClusterDeployments
+	// whose managed DNSZone is stuck deleting with an error from the DNS provider.
+	metricClusterDeploymentDNSCleanupFailed constMetricDesc
+}
+
+// Collect collects the metrics for dnsCleanupFailedCollector
+func (cc dnsCleanupFailedCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "DNS cleanup failure metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp == nil {
+				continue
+			}
+			if !cd.Spec.ManageDNS {
+				continue
+			}
+			dnsZone := &hivev1.DNSZone{}
+			switch err := cc.client.Get(scrape.ctx, types.NamespacedName{Namespace: cd.Namespace, Name: controllerutils.DNSZoneName(cd.Name)}, dnsZone); {
+			case apierrors.IsNotFound(err):
+				continue
+			case err != nil:
+				if scrape.readFailed() {
+					return
+				}
+				scrape.log.WithError(err).WithField("clusterDeployment", cd.Name).Warn("error getting managed dnszone")
+				continue
+			}
+			if dnsZone.DeletionTimestamp == nil {
+				continue
+			}
+			for _, condType := range dnsCleanupErrorConditions {
+				cond := controllerutils.FindCondition(dnsZone.Status.Conditions, condType)
+				if cond == nil || cond.Status != corev1.ConditionTrue {
+					continue
+				}
+				ch <- cc.metricClusterDeploymentDNSCleanupFailed.mustNewConstMetric(
+					prometheus.GaugeValue,
+					1,
+					prometheus.Labels{
+						"cluster_deployment": cd.Name,
+						"namespace":          cd.Namespace,
+					},
+				)
+				break
+			}
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+func (cc dnsCleanupFailedCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentDNSCleanupFailed.Desc
+}
+
+var (
+	metricClusterDeploymentDNSCleanupFailedDesc = newConstMetricDesc(
+		"hive_cluster_deployment_dns_cleanup_failed",
+		"Whether cleanup of a deleting cluster's managed DNS is failing.",
+		"cluster_deployment", "namespace",
+	)
+)
+
+func newDNSCleanupFailedCollector(client client.Client) prometheus.Collector {
+	return dnsCleanupFailedCollector{
+		client:                                  client,
+		metricClusterDeploymentDNSCleanupFailed: metricClusterDeploymentDNSCleanupFailedDesc,
+	}
+}
+
+// cluster deployments by DNS mode metric collected through a custom prometheus collector
+type dnsModeCollector struct {
+	client client.Client
+
+	// metricClusterDeploymentsByDNSMode is a prometheus metric for the number of ClusterDeployments by whether Hive
+	// manages their DNS.
+	metricClusterDeploymentsByDNSMode constMetricDesc
+}
+
+const (
+	// dnsModeManaged is used for clusters with spec.manageDNS set, whose DNSZone is created and managed by Hive.
+	dnsModeManaged = "managed"
+	// dnsModeUser is used for clusters whose DNS is managed by the user.
+	dnsModeUser = "user"
+)
+
+// Collect collects the metrics for dnsModeCollector
+func (cc dnsModeCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "DNS mode metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	counts := map[string]int{
+		dnsModeManaged: 0,
+		dnsModeUser:    0,
+	}
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			if cd.Spec.ManageDNS {
+				counts[dnsModeManaged]++
+			} else {
+				counts[dnsModeUser]++
+			}
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+	for mode, count := range counts {
+		ch <- cc.metricClusterDeploymentsByDNSMode.mustNewConstMetric(
+			prometheus.GaugeValue,
+			float64(count),
+			prometheus.Labels{
+				"mode": mode,
+			},
+		)
+	}
+}
+
+func (cc dnsModeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentsByDNSMode.Desc
+}
+
+var (
+	metricClusterDeploymentsByDNSModeDesc = newConstMetricDesc(
+		"hive_cluster_deployments_by_dns_mode",
+		"Number of ClusterDeployments by whether their DNS is managed by Hive or by the user.",
+		"mode",
+	)
+)
+
+func newDNSModeCollector(client client.Client) prometheus.Collector {
+	return dnsModeCollector{
+		client:                            client,
+		metricClusterDeploymentsByDNSMode: metricClusterDeploymentsByDNSModeDesc,
+	}
+}
+
+// dnszone not ready metric collected through a custom prometheus collector
+type dnsZoneNotReadyCollector struct {
+	client client.Client
+
+	// clock is the source of the current time.
+	clock clock.PassiveClock
+
+	// minDuration, when non-zero, is the minimum duration after which a DNSZone that is not ready
+	// will start becoming part of this metric. When set to zero, all DNSZones that are not ready
+	// will be included in the metric.
+	minDuration time.Duration
+
+	// metricDNSZoneNotReadySeconds is a prometheus metric for the number of seconds a DNSZone has not been
+	// available.
+	metricDNSZoneNotReadySeconds constMetricDesc
+}
+
+// Collect collects the metrics for dnsZoneNotReadyCollector
+func (cc dnsZoneNotReadyCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "not ready metrics across all DNSZones")
+	defer scrape.done()
+
+	dnsZones := &hivev1.DNSZoneList{}
+	pages := scrape.list(cc.client, dnsZones)
+	for pages.next() {
+		for _, dnsZone := range dnsZones.Items {
+			if dnsZone.DeletionTimestamp != nil {
+				continue
+			}
+			// A zone that has never reported whether it is available has not been ready since it was created.
+			since := dnsZone.CreationTimestamp.Time
+			if cond := controllerutils.FindCondition(dnsZone.Status.Conditions, hivev1.ZoneAvailableDNSZoneCondition); cond != nil {
+				if cond.Status == corev1.ConditionTrue {
+					continue
+				}
+				since = cond.LastTransitionTime.Time
+			}
+			elapsedDuration := cc.clock.Since(since)
+			if elapsedDuration < cc.minDuration {
+				continue
+			}
+			ch <- cc.metricDNSZoneNotReadySeconds.mustNewConstMetric(
+				prometheus.GaugeValue,
+				elapsedDuration.Seconds(),
+				prometheus.Labels{
+					"cloud":             getDNSZoneCloud(&dnsZone),
+					"dnszone_name":      dnsZone.Name,
+					"dnszone_namespace": dnsZone.Namespace,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+// getDNSZoneCloud returns the cloud whose DNS service hosts the zone, or "unknown" if the zone names none.
+func getDNSZoneCloud(dnsZone *hivev1.DNSZone) string {
+	switch {
+	case dnsZone.Spec.AWS != nil:
+		return constants.PlatformAWS
+	case dnsZone.Spec.GCP != nil:
+		return constants.PlatformGCP
+	case dnsZone.Spec.Azure != nil:
+		return constants.PlatformAzure
+	default:
+		return constants.PlatformUnknown
+	}
+}
+
+func (cc dnsZoneNotReadyCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricDNSZoneNotReadySeconds.Desc
+}
+
+var (
+	metricDNSZoneNotReadySecondsDesc = newConstMetricDesc(
+		"hive_dnszone_not_ready_seconds",
+		"Length of time a DNSZone has not been available.",
+		"cloud", "dnszone_name", "dnszone_namespace",
+	)
+)
+
+// newDNSZoneNotReadyCollector returns a collector reporting DNSZones that have not been available for at least
+// minimum.
+func newDNSZoneNotReadyCollector(client client.Client, minimum time.Duration) prometheus.Collector {
+	return dnsZoneNotReadyCollector{
+		client:                       client,
+		clock:                        clock.RealClock{},
+		minDuration:                  minimum,
+		metricDNSZoneNotReadySeconds: metricDNSZoneNotReadySecondsDesc,
+	}
+}
@@ -0,0 +1,284 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	testcd "github.com/openshift/hive/pkg/test/clusterdeployment"
+	testdnszone "github.com/openshift/hive/pkg/test/dnszone"
+	testgeneric "github.com/openshift/hive/pkg/test/generic"
+	"github.com/openshift/hive/pkg/util/scheme"
+)
+
+func TestDNSCollectors(t *testing.T) {
+	scheme := scheme.GetScheme()
+
+	cdBuilder := func(name string) testcd.Builder {
+		return testcd.FullBuilder(name, name, scheme)
+	}
+	zoneBuilder := func(namespace, name string) testdnszone.Builder {
+		return testdnszone.FullBuilder(namespace, name, scheme)
+	}
+	deleted := testgeneric.Deleted()
+	withFinalizer := testgeneric.WithFinalizer(testFinalizer)
+	manageDNS := func(cd *hivev1.ClusterDeployment) {
+		cd.Spec.ManageDNS = true
+	}
+
+	// Zone record conflicts, which are found across pages of DNSZones.
+	recordConflictCases := []collectorCase{{
+		name: "clean zones",
+		existing: []runtime.Object{
+			zoneBuilder("ns-1", "zone-1").Build(testdnszone.WithZone("cluster-1.example.com")),
+			zoneBuilder("ns-2", "zone-2").Build(testdnszone.WithZone("cluster-2.example.com")),
+		},
+	}, {
+		name: "conflicting zones",
+		existing: []runtime.Object{
+			zoneBuilder("ns-1", "zone-1").Build(testdnszone.WithZone("cluster-1.example.com")),
+			zoneBuilder("ns-2", "zone-2").Build(testdnszone.WithZone("Cluster-1.example.com.")),
+			zoneBuilder("ns-3", "zone-3").Build(testdnszone.WithZone("cluster-3.example.com")),
+		},
+		expected: []string{
+			"dns_zone = zone-1 namespace = ns-1 1",
+			"dns_zone = zone-2 namespace = ns-2 1",
+		},
+	}, {
+		name: "conflicting zone being deleted",
+		existing: []runtime.Object{
+			zoneBuilder("ns-1", "zone-1").Build(testdnszone.WithZone("cluster-1.example.com")),
+			zoneBuilder("ns-2", "zone-2").GenericOptions(deleted, withFinalizer).Build(testdnszone.WithZone("cluster-1.example.com")),
+		},
+	}}
+	defer func(pageSize int64) { collectPageSize = pageSize }(collectPageSize)
+	collectPageSize = 1
+
+	// DNS limit failures.
+	provisionFailed := func(reason string) testcd.Option {
+		return testcd.WithCondition(hivev1.ClusterDeploymentCondition{
+			Type:   hivev1.ProvisionFailedCondition,
+			Status: corev1.ConditionTrue,
+			Reason: reason,
+		})
+	}
+	platform := func(platform string) testcd.Option {
+		return testcd.WithLabel(hivev1.HiveClusterPlatformLabel, platform)
+	}
+
+	// DNS cleanup failures.
+	deletingCD := func(name string) *hivev1.ClusterDeployment {
+		return cdBuilder(name).GenericOptions(deleted, withFinalizer).Build(manageDNS)
+	}
+	cleanupZoneBuilder := func(cdName string) testdnszone.Builder {
+		return zoneBuilder(cdName, cdName+"-zone")
+	}
+	dnsError := testdnszone.WithCondition(hivev1.DNSZoneCondition{
+		Type:   hivev1.GenericDNSErrorsCondition,
+		Status: corev1.ConditionTrue,
+		Reason: "CloudError",
+	})
+	dnsErrorCleared := testdnszone.WithCondition(hivev1.DNSZoneCondition{
+		Type:   hivev1.GenericDNSErrorsCondition,
+		Status: corev1.ConditionFalse,
+	})
+	authFailure := testdnszone.WithCondition(hivev1.DNSZoneCondition{
+		Type:   hivev1.AuthenticationFailureCondition,
+		Status: corev1.ConditionTrue,
+	})
+	zoneDeleting := testdnszone.Generic(deleted)
+	zoneFinalizer := testdnszone.Generic(testgeneric.WithFinalizer(hivev1.FinalizerDNSZone))
+
+	// Zones not ready.
+	aws := func(dnsZone *hivev1.DNSZone) {
+		dnsZone.Spec.AWS = &hivev1.AWSDNSZoneSpec{}
+	}
+	azure := func(dnsZone *hivev1.DNSZone) {
+		dnsZone.Spec.Azure = &hivev1.AzureDNSZoneSpec{}
+	}
+	available := func(status corev1.ConditionStatus, since time.Duration) testdnszone.Option {
+		return testdnszone.WithCondition(hivev1.DNSZoneCondition{
+			Type:               hivev1.ZoneAvailableDNSZoneCondition,
+			Status:             status,
+			LastTransitionTime: metav1.NewTime(testNow.Add(-since)),
+		})
+	}
+	agedZoneBuilder := func(name string) testdnszone.Builder {
+		return zoneBuilder(name, name).GenericOptions(testgeneric.WithCreationTimestamp(testNow.Add(-3 * time.Hour)))
+	}
+	zones := []runtime.Object{
+		agedZoneBuilder("ready").Build(aws, available(corev1.ConditionTrue, 2*time.Hour)),
+		agedZoneBuilder("recently-failing").Build(testdnszone.WithGCPPlatform("zone"), available(corev1.ConditionFalse, 5*time.Minute)),
+		agedZoneBuilder("long-failing-aws").Build(aws, available(corev1.ConditionFalse, 2*time.Hour)),
+		agedZoneBuilder("long-failing-gcp").Build(testdnszone.WithGCPPlatform("zone"), available(corev1.ConditionUnknown, time.Hour)),
+		agedZoneBuilder("long-failing-azure").Build(azure, available(corev1.ConditionFalse, time.Hour)),
+		// Without the condition, the zone has not been ready since it was created.
+		agedZoneBuilder("never-reported").Build(azure),
+		agedZoneBuilder("no-platform").Build(available(corev1.ConditionFalse, time.Hour)),
+		agedZoneBuilder("deleted").GenericOptions(deleted, withFinalizer).Build(aws, available(corev1.ConditionFalse, 2*time.Hour)),
+	}
+	zoneNotReady := func(min time.Duration) func(client.Client) prometheus.Collector {
+		return func(c client.Client) prometheus.Collector {
+			collect := newDNSZoneNotReadyCollector(c, min).(dnsZoneNotReadyCollector)
+			collect.clock = clocktesting.NewFakePassiveClock(testNow)
+			return collect
+		}
+	}
+
+	runCollectorTests(t, []collectorTest{{
+		name:      "zone record conflict",
+		collector: newDNSZoneRecordConflictCollector,
+		cases:     recordConflictCases,
+	}, {
+		name: "zone record conflict when paging",
+		collector: func(c client.Client) prometheus.Collector {
+			return newDNSZoneRecordConflictCollector(&pagingClient{Client: c})
+		},
+		cases: recordConflictCases,
+	}, {
+		name:      "DNS limit failures",
+		collector: newDNSLimitFailuresCollector,
+		cases: []collectorCase{{
+			name: "no dns limit failures",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(platform("aws"), provisionFailed("AWSVPCLimitExceeded")),
+				cdBuilder("cd-2").Build(platform("gcp")),
+			},
+		}, {
+			name: "dns limit failures across platforms",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(platform("aws"), provisionFailed("TooManyRoute53Zones")),
+				cdBuilder("cd-2").Build(platform("aws"), provisionFailed("TooManyRoute53Zones")),
+				cdBuilder("cd-3").Build(platform("aws"), provisionFailed("AWSVPCLimitExceeded")),
+				cdBuilder("cd-4").Build(platform("gcp"), provisionFailed("TooManyRoute53Zones")),
+				cdBuilder("cd-5").Build(platform("azure"), provisionFailed("AzureQuotaExceeded")),
+			},
+			expected: []string{
+				"platform = aws 2",
+				"platform = gcp 1",
+			},
+		}, {
+			name: "provision no longer failing",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(platform("aws"), testcd.WithCondition(hivev1.ClusterDeploymentCondition{
+					Type:   hivev1.ProvisionFailedCondition,
+					Status: corev1.ConditionFalse,
+					Reason: "TooManyRoute53Zones",
+				})),
+			},
+		}},
+	}, {
+		name:      "DNS cleanup failed",
+		collector: newDNSCleanupFailedCollector,
+		cases: []collectorCase{{
+			name: "clean DNS cleanup",
+			existing: []runtime.Object{
+				deletingCD("cd-1"),
+				deletingCD("cd-2"),
+				cleanupZoneBuilder("cd-2").Build(zoneDeleting, zoneFinalizer),
+				deletingCD("cd-3"),
+				cleanupZoneBuilder("cd-3").Build(zoneDeleting, zoneFinalizer, dnsErrorCleared),
+			},
+		}, {
+			name: "failed DNS cleanup",
+			existing: []runtime.Object{
+				deletingCD("cd-1"),
+				cleanupZoneBuilder("cd-1").Build(zoneDeleting, zoneFinalizer, dnsError),
+				deletingCD("cd-2"),
+				cleanupZoneBuilder("cd-2").Build(zoneDeleting, zoneFinalizer, authFailure, dnsError),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = cd-1 1",
+				"cluster_deployment = cd-2 namespace = cd-2 1",
+			},
+		}, {
+			name: "DNS errors on clusters that are not being deleted",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(manageDNS),
+				cleanupZoneBuilder("cd-1").Build(zoneFinalizer, dnsError),
+			},
+		}, {
+			name: "unmanaged DNS",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").GenericOptions(deleted, withFinalizer).Build(),
+				cleanupZoneBuilder("cd-1").Build(zoneDeleting, zoneFinalizer, dnsError),
+			},
+		}},
+	}, {
+		name:      "DNS mode",
+		collector: newDNSModeCollector,
+		cases: []collectorCase{{
+			name: "no clusters",
+			expected: []string{
+				"mode = managed 0",
+				"mode = user 0",
+			},
+		}, {
+			name: "managed and user DNS",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(manageDNS),
+				cdBuilder("cd-2").Build(manageDNS, testcd.Installed()),
+				cdBuilder("cd-3").Build(),
+				cdBuilder("cd-4").GenericOptions(deleted, withFinalizer).Build(manageDNS),
+			},
+			expected: []string{
+				"mode = managed 2",
+				"mode = user 1",
+			},
+		}, {
+			name: "only user DNS",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(),
+				cdBuilder("cd-2").Build(),
+			},
+			expected: []string{
+				"mode = managed 0",
+				"mode = user 2",
+			},
+		}},
+	}, {
+		name:      "zone not ready",
+		collector: zoneNotReady(0),
+		cases: []collectorCase{{
+			name:     "all not ready zones",
+			existing: zones,
+			expected: []string{
+				"cloud = gcp dnszone_name = recently-failing dnszone_namespace = recently-failing 300",
+				"cloud = aws dnszone_name = long-failing-aws dnszone_namespace = long-failing-aws 7200",
+				"cloud = gcp dnszone_name = long-failing-gcp dnszone_namespace = long-failing-gcp 3600",
+				"cloud = azure dnszone_name = long-failing-azure dnszone_namespace = long-failing-azure 3600",
+				"cloud = azure dnszone_name = never-reported dnszone_namespace = never-reported 10800",
+				"cloud = unknown dnszone_name = no-platform dnszone_namespace = no-platform 3600",
+			},
+		}, {
+			name:      "threshold",
+			existing:  zones,
+			collector: zoneNotReady(30 * time.Minute),
+			expected: []string{
+				"cloud = aws dnszone_name = long-failing-aws dnszone_namespace = long-failing-aws 7200",
+				"cloud = gcp dnszone_name = long-failing-gcp dnszone_namespace = long-failing-gcp 3600",
+				"cloud = azure dnszone_name = long-failing-azure dnszone_namespace = long-failing-azure 3600",
+				"cloud = azure dnszone_name = never-reported dnszone_namespace = never-reported 10800",
+				"cloud = unknown dnszone_name = no-platform dnszone_namespace = no-platform 3600",
+			},
+		}, {
+			name:      "threshold equal to zone age",
+			existing:  zones,
+			collector: zoneNotReady(3 * time.Hour),
+			expected: []string{
+				"cloud = azure dnszone_name = never-reported dnszone_namespace = never-reported 10800",
+			},
+		}, {
+			name:      "threshold above all zones",
+			existing:  zones,
+			collector: zoneNotReady(4 * time.Hour),
+		}},
+	}})
+}
@@ -0,0 +1,705 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ghodss/yaml"
+	"github.com/prometheus/client_golang/prometheus"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/openshift/hive/pkg/constants"
+	controllerutils "github.com/openshift/hive/pkg/controller/utils"
+	installertypes "github.com/openshift/installer/pkg/types"
+)
+
+// installConfigMutatedCollector reports provisioning ClusterDeployments whose install-config secret changed after
+// the current provision started.
+type installConfigMutatedCollector struct {
+	client client.Client
+
+	// metricClusterDeploymentInstallConfigMutated is a prometheus metric reporting provisioning ClusterDeployments
+	// whose install-config no longer matches the checksum recorded on their ClusterProvision.
+	metricClusterDeploymentInstallConfigMutated constMetricDesc
+}
+
+// Collect collects the metrics for installConfigMutatedCollector
+func (cc installConfigMutatedCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "install config mutation metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			if cd.Spec.Installed {
+				continue
+			}
+			if cd.Spec.Provisioning == nil || cd.Spec.Provisioning.InstallConfigSecretRef == nil || cd.Status.ProvisionRef == nil {
+				continue
+			}
+			cdLog := scrape.log.WithField("clusterDeployment", cd.Namespace+"/"+cd.Name)
+			provision := &hivev1.ClusterProvision{}
+			if err := cc.client.Get(scrape.ctx, types.NamespacedName{Namespace: cd.Namespace, Name: cd.Status.ProvisionRef.Name}, provision); err != nil {
+				if scrape.readFailed() {
+					return
+				}
+				cdLog.WithError(err).Warn("error getting cluster provision")
+				continue
+			}
+			// Provisions created before we started recording the checksum can't be checked.
+			startChecksum, ok := provision.Annotations[constants.InstallConfigChecksumAnnotation]
+			if !ok {
+				continue
+			}
+			icSecret := &corev1.Secret{}
+			if err := cc.client.Get(scrape.ctx, types.NamespacedName{Namespace: cd.Namespace, Name: cd.Spec.Provisioning.InstallConfigSecretRef.Name}, icSecret); err != nil {
+				if scrape.readFailed() {
+					return
+				}
+				cdLog.WithError(err).Warn("error getting install config secret")
+				continue
+			}
+			checksum, err := controllerutils.GetInstallConfigChecksum(icSecret)
+			if err != nil {
+				cdLog.WithError(err).Warn("error computing install config checksum")
+				continue
+			}
+			if checksum == startChecksum {
+				continue
+			}
+			ch <- cc.metricClusterDeploymentInstallConfigMutated.mustNewConstMetric(
+				prometheus.GaugeValue,
+				1,
+				prometheus.Labels{
+					"cluster_deployment": cd.Name,
+					"namespace":          cd.Namespace,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+func (cc installConfigMutatedCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentInstallConfigMutated.Desc
+}
+
+var (
+	metricClusterDeploymentInstallConfigMutatedDesc = newConstMetricDesc(
+		"hive_cluster_deployment_installconfig_mutated",
+		"Whether a provisioning cluster's install-config secret changed after the provision started.",
+		"cluster_deployment", "namespace",
+	)
+)
+
+func newInstallConfigMutatedCollector(client client.Client) prometheus.Collector {
+	return installConfigMutatedCollector{
+		client: client,
+		metricClusterDeploymentInstallConfigMutated: metricClusterDeploymentInstallConfigMutatedDesc,
+	}
+}
+
+// mirror install metric collected through a custom prometheus collector
+type mirrorInstallCollector struct {
+	client client.Client
+
+	// installConfigs holds the summaries of the install-configs read by the collector.
+	installConfigs *installConfigCache
+
+	// metricClusterDeploymentMirrorInstall is a prometheus metric reporting ClusterDeployments whose install-config
+	// pulls the release image content from a mirror.
+	metricClusterDeploymentMirrorInstall constMetricDesc
+}
+
+// Collect collects the metrics for mirrorInstallCollector
+func (cc mirrorInstallCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "mirror install metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			if cd.Spec.Provisioning == nil || cd.Spec.Provisioning.InstallConfigSecretRef == nil {
+				continue
+			}
+			cdLog := scrape.log.WithField("clusterDeployment", cd.Namespace+"/"+cd.Name)
+			ic, err := cc.installConfigs.get(scrape.ctx, cc.client, &cd)
+			if err != nil {
+				if scrape.readFailed() {
+					return
+				}
+				cdLog.WithError(err).Warn("error getting install config")
+				continue
+			}
+			if !ic.mirror {
+				continue
+			}
+			ch <- cc.metricClusterDeploymentMirrorInstall.mustNewConstMetric(
+				prometheus.GaugeValue,
+				1,
+				prometheus.Labels{
+					"cluster_deployment": cd.Name,
+					"namespace":          cd.Namespace,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+	cc.installConfigs.sweep()
+}
+
+func (cc mirrorInstallCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentMirrorInstall.Desc
+}
+
+var (
+	metricClusterDeploymentMirrorInstallDesc = newConstMetricDesc(
+		"hive_cluster_deployment_mirror_install",
+		"Whether a cluster's install-config pulls the release image content from a mirror.",
+		"cluster_deployment", "namespace",
+	)
+)
+
+// newMirrorInstallCollector returns a collector reporting clusters whose install-config configures image digest or
+// image content sources, as used by disconnected installs.
+func newMirrorInstallCollector(client client.Client) prometheus.Collector {
+	return mirrorInstallCollector{
+		client:                               client,
+		installConfigs:                       newInstallConfigCache(),
+		metricClusterDeploymentMirrorInstall: metricClusterDeploymentMirrorInstallDesc,
+	}
+}
+
+// proxy metric collected through a custom prometheus collector
+type proxyCollector struct {
+	client client.Client
+
+	// installConfigs holds the summaries of the install-configs read by the collector.
+	installConfigs *installConfigCache
+
+	// metricClusterDeploymentsWithProxy is a prometheus metric reporting ClusterDeployments whose install-config
+	// configures an HTTP or HTTPS proxy.
+	metricClusterDeploymentsWithProxy constMetricDesc
+}
+
+// Collect collects the metrics for proxyCollector
+func (cc proxyCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "proxy metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			if cd.Spec.Provisioning == nil || cd.Spec.Provisioning.InstallConfigSecretRef == nil {
+				continue
+			}
+			cdLog := scrape.log.WithField("clusterDeployment", cd.Namespace+"/"+cd.Name)
+			ic, err := cc.installConfigs.get(scrape.ctx, cc.client, &cd)
+			if err != nil {
+				if scrape.readFailed() {
+					return
+				}
+				cdLog.WithError(err).Warn("error getting install config")
+				continue
+			}
+			if !ic.proxy {
+				continue
+			}
+			ch <- cc.metricClusterDeploymentsWithProxy.mustNewConstMetric(
+				prometheus.GaugeValue,
+				1,
+				prometheus.Labels{
+					"cluster_deployment": cd.Name,
+					"namespace":          cd.Namespace,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+	cc.installConfigs.sweep()
+}
+
+func (cc proxyCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentsWithProxy.Desc
+}
+
+var (
+	metricClusterDeploymentsWithProxyDesc = newConstMetricDesc(
+		"hive_cluster_deployments_with_proxy",
+		"Whether a cluster's install-config configures an HTTP or HTTPS proxy.",
+		"cluster_deployment", "namespace",
+	)
+)
+
+// newProxyCollector returns a collector reporting clusters whose install-config sets an HTTP or HTTPS proxy.
+func newProxyCollector(client client.Client) prometheus.Collector {
+	return proxyCollector{
+		client:                            client,
+		installConfigs:                    newInstallConfigCache(),
+		metricClusterDeploymentsWithProxy: metricClusterDeploymentsWithProxyDesc,
+	}
+}
+
+// installConfigSummary holds the settings of an install-config that collectors report on.
+type installConfigSummary struct {
+	// fips is whether the install-config enables FIPS mode.
+	fips bool
+	// networkType is the cluster network type the install-config selects, or empty if it leaves it to the
+	// installer's default.
+	networkType string
+	// proxy is whether the install-config sets an HTTP or HTTPS proxy. A proxy with only noProxy set sends nothing
+	// through a proxy.
+	proxy bool
+	// mirror is whether the install-config pulls the release image content from a mirror, configuring image digest
+	// or image content sources.
+	mirror bool
+	// instanceTypes are the instance types the install-config configures for the control plane and compute pools.
+	instanceTypes []string
+}
+
+// summarizeInstallConfig returns the settings of ic that collectors report on.
+func summarizeInstallConfig(ic *installertypes.InstallConfig) installConfigSummary {
+	summary := installConfigSummary{
+		fips:          ic.FIPS,
+		proxy:         ic.Proxy != nil && (ic.Proxy.HTTPProxy != "" || ic.Proxy.HTTPSProxy != ""),
+		mirror:        len(ic.ImageDigestSources) > 0 || len(ic.DeprecatedImageContentSources) > 0,
+		instanceTypes: installConfigInstanceTypes(ic),
+	}
+	if ic.Networking != nil {
+		summary.networkType = ic.Networking.NetworkType
+	}
+	return summary
+}
+
+// installConfigCache holds the summaries of the install-configs a collector has read, by the secret holding each, so
+// that an install-config is only parsed again once its secret has changed. The secrets are read from the informer
+// cache.
+type installConfigCache struct {
+	mu      sync.Mutex
+	entries map[types.NamespacedName]*installConfigCacheEntry
+}
+
+// installConfigCacheEntry is the summary of the install-config held by a secret at resourceVersion.
+type installConfigCacheEntry struct {
+	resourceVersion string
+	summary         installConfigSummary
+	// used is whether the entry has been read since the last sweep.
+	used bool
+}
+
+func newInstallConfigCache() *installConfigCache {
+	return &installConfigCache{
+		entries: map[types.NamespacedName]*installConfigCacheEntry{},
+	}
+}
+
+// get returns the summary of the install-config of cd, which must have an InstallConfigSecretRef, parsing it only if
+// its secret has changed since it was last read.
+func (c *installConfigCache) get(ctx context.Context, reader client.Client, cd *hivev1.ClusterDeployment) (installConfigSummary, error) {
+	key := types.NamespacedName{Namespace: cd.Namespace, Name: cd.Spec.Provisioning.InstallConfigSecretRef.Name}
+	icSecret := &corev1.Secret{}
+	if err := reader.Get(ctx, key, icSecret); err != nil {
+		return installConfigSummary{}, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || entry.resourceVersion != icSecret.ResourceVersion {
+		ic := &installertypes.InstallConfig{}
+		if err := yaml.Unmarshal(icSecret.Data["install-config.yaml"], ic); err != nil {
+			return installConfigSummary{}, fmt.Errorf("could not unmarshal install config: %w", err)
+		}
+		entry = &installConfigCacheEntry{
+			resourceVersion: icSecret.ResourceVersion,
+			summary:         summarizeInstallConfig(ic),
+		}
+		c.entries[key] = entry
+	}
+	entry.used = true
+	return entry.summary, nil
+}
+
+// sweep drops the entries that have not been read since the last sweep, such as those of deleted ClusterDeployments.
+// Collectors sweep once they have read every ClusterDeployment.
+func (c *installConfigCache) sweep() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.entries {
+		if !entry.used {
+			delete(c.entries, key)
+			continue
+		}
+		entry.used = false
+	}
+}
+
+// FIPS metrics collected through a custom prometheus collector
+type fipsCollector struct {
+	client client.Client
+
+	// installConfigs holds the summaries of the install-configs read by the collector.
+	installConfigs *installConfigCache
+
+	// metricClusterDeploymentsByFIPS is a prometheus metric for the number of ClusterDeployments by whether their
+	// install-config enables FIPS mode.
+	metricClusterDeploymentsByFIPS constMetricDesc
+}
+
+// fipsUnknown is used for clusters with no install-config to read FIPS mode from, such as adopted clusters.
+const fipsUnknown = "unknown"
+
+// Collect collects the metrics for fipsCollector
+func (cc fipsCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "FIPS metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	counts := map[string]int{
+		"true":  0,
+		"false": 0,
+	}
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			if cd.Spec.Provisioning == nil || cd.Spec.Provisioning.InstallConfigSecretRef == nil {
+				counts[fipsUnknown]++
+				continue
+			}
+			ic, err := cc.installConfigs.get(scrape.ctx, cc.client, &cd)
+			if err != nil {
+				if scrape.readFailed() {
+					return
+				}
+				scrape.log.WithField("clusterDeployment", cd.Namespace+"/"+cd.Name).WithError(err).Warn("error getting install config")
+				continue
+			}
+			counts[strconv.FormatBool(ic.fips)]++
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+	cc.installConfigs.sweep()
+	for enabled, count := range counts {
+		ch <- cc.metricClusterDeploymentsByFIPS.mustNewConstMetric(
+			prometheus.GaugeValue,
+			float64(count),
+			prometheus.Labels{
+				"enabled": enabled,
+			},
+		)
+	}
+}
+
+func (cc fipsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentsByFIPS.Desc
+}
+
+var (
+	metricClusterDeploymentsByFIPSDesc = newConstMetricDesc(
+		"hive_cluster_deployments_by_fips",
+		"Number of ClusterDeployments by whether FIPS mode is enabled in their install-config.",
+		"enabled",
+	)
+)
+
+func newFIPSCollector(client client.Client) prometheus.Collector {
+	return fipsCollector{
+		client:                         client,
+		installConfigs:                 newInstallConfigCache(),
+		metricClusterDeploymentsByFIPS: metricClusterDeploymentsByFIPSDesc,
+	}
+}
+
+// network type metrics collected through a custom prometheus collector
+type networkTypeCollector struct {
+	client client.Client
+
+	// installConfigs holds the summaries of the install-configs read by the collector.
+	installConfigs *installConfigCache
+
+	// metricClusterDeploymentsByNetworkType is a prometheus metric for the number of ClusterDeployments by the cluster
+	// network provider their install-config selects.
+	metricClusterDeploymentsByNetworkType constMetricDesc
+}
+
+const (
+	// networkTypeUnknown is used for clusters with no install-config to read the network type from, such as adopted
+	// clusters.
+	networkTypeUnknown = "unknown"
+	// networkTypeUnspecified is used for clusters whose install-config leaves the network type to the installer's
+	// default.
+	networkTypeUnspecified = "unspecified"
+)
+
+// Collect collects the metrics for networkTypeCollector
+func (cc networkTypeCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "network type metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	// Seed the network types tracked by migrations, so both are reported before any cluster uses them.
+	counts := map[string]int{
+		"OVNKubernetes": 0,
+		"OpenShiftSDN":  0,
+	}
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			if cd.Spec.Provisioning == nil || cd.Spec.Provisioning.InstallConfigSecretRef == nil {
+				counts[networkTypeUnknown]++
+				continue
+			}
+			ic, err := cc.installConfigs.get(scrape.ctx, cc.client, &cd)
+			if err != nil {
+				if scrape.readFailed() {
+					return
+				}
+				scrape.log.WithField("clusterDeployment", cd.Namespace+"/"+cd.Name).WithError(err).Warn("error getting install config")
+				continue
+			}
+			networkType := networkTypeUnspecified
+			if ic.networkType != "" {
+				networkType = ic.networkType
+			}
+			counts[networkType]++
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+	cc.installConfigs.sweep()
+	for networkType, count := range counts {
+		ch <- cc.metricClusterDeploymentsByNetworkType.mustNewConstMetric(
+			prometheus.GaugeValue,
+			float64(count),
+			prometheus.Labels{
+				"type": networkType,
+			},
+		)
+	}
+}
+
+func (cc networkTypeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentsByNetworkType.Desc
+}
+
+var (
+	metricClusterDeploymentsByNetworkTypeDesc = newConstMetricDesc(
+		"hive_cluster_deployments_by_network_type",
+		"Number of ClusterDeployments by the cluster network type in their install-config.",
+		"type",
+	)
+)
+
+func newNetworkTypeCollector(client client.Client) prometheus.Collector {
+	return networkTypeCollector{
+		client:                                client,
+		installConfigs:                        newInstallConfigCache(),
+		metricClusterDeploymentsByNetworkType: metricClusterDeploymentsByNetworkTypeDesc,
+	}
+}
+
+// cluster deployments by instance family metric collected through a custom prometheus collector
+type instanceFamilyCollector struct {
+	client client.Client
+
+	// installConfigs holds the summaries of the install-configs read by the collector.
+	installConfigs *installConfigCache
+
+	// metricClusterDeploymentInstanceFamily is a prometheus metric for the number of ClusterDeployments running
+	// instances of each instance type family.
+	metricClusterDeploymentInstanceFamily constMetricDesc
+}
+
+// instanceFamilyUnknown is used for clusters with no instance type we can read, such as adopted clusters without
+// MachinePools, and for instance types whose family we can't tell.
+const instanceFamilyUnknown = "unknown"
+
+// Collect collects the metrics for instanceFamilyCollector
+func (cc instanceFamilyCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "instance family metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	machinePools := &hivev1.MachinePoolList{}
+	mpPages := scrape.list(cc.client, machinePools)
+	familiesByCD := map[types.NamespacedName]sets.Set[string]{}
+	for mpPages.next() {
+		for _, mp := range machinePools.Items {
+			if mp.DeletionTimestamp != nil {
+				continue
+			}
+			instanceType := machinePoolInstanceType(&mp)
+			if instanceType == "" {
+				continue
+			}
+			key := types.NamespacedName{Namespace: mp.Namespace, Name: mp.Spec.ClusterDeploymentRef.Name}
+			if familiesByCD[key] == nil {
+				familiesByCD[key] = sets.New[string]()
+			}
+			familiesByCD[key].Insert(instanceTypeFamily(instanceType))
+		}
+	}
+	if scrape.listFailed(mpPages) {
+		return
+	}
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	cdPages := scrape.list(cc.client, clusterDeployments)
+	counts := map[string]int{}
+	for cdPages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			families := familiesByCD[types.NamespacedName{Namespace: cd.Namespace, Name: cd.Name}]
+			if families == nil {
+				families = sets.New[string]()
+			}
+			// Control plane instances are only described by the install-config.
+			if cd.Spec.Provisioning != nil && cd.Spec.Provisioning.InstallConfigSecretRef != nil {
+				ic, err := cc.installConfigs.get(scrape.ctx, cc.client, &cd)
+				if err != nil {
+					if scrape.readFailed() {
+						return
+					}
+					scrape.log.WithField("clusterDeployment", cd.Namespace+"/"+cd.Name).WithError(err).Warn("error getting install config")
+				} else {
+					for _, instanceType := range ic.instanceTypes {
+						families.Insert(instanceTypeFamily(instanceType))
+					}
+				}
+			}
+			if families.Len() == 0 {
+				families.Insert(instanceFamilyUnknown)
+			}
+			for family := range families {
+				counts[family]++
+			}
+		}
+	}
+	if scrape.listFailed(cdPages) {
+		return
+	}
+	cc.installConfigs.sweep()
+	for family, count := range counts {
+		ch <- cc.metricClusterDeploymentInstanceFamily.mustNewConstMetric(
+			prometheus.GaugeValue,
+			float64(count),
+			prometheus.Labels{
+				"family": family,
+			},
+		)
+	}
+}
+
+// machinePoolInstanceType returns the instance type configured in the MachinePool's platform spec, if any.
+func machinePoolInstanceType(mp *hivev1.MachinePool) string {
+	switch p := mp.Spec.Platform; {
+	case p.AWS != nil:
+		return p.AWS.InstanceType
+	case p.Azure != nil:
+		return p.Azure.InstanceType
+	case p.GCP != nil:
+		return p.GCP.InstanceType
+	}
+	return ""
+}
+
+// installConfigInstanceTypes returns the instance types configured for the control plane and compute pools of the
+// install-config.
+func installConfigInstanceTypes(ic *installertypes.InstallConfig) []string {
+	pools := ic.Compute
+	if ic.ControlPlane != nil {
+		pools = append([]installertypes.MachinePool{*ic.ControlPlane}, pools...)
+	}
+	var instanceTypes []string
+	for _, pool := range pools {
+		var instanceType string
+		switch p := pool.Platform; {
+		case p.AWS != nil:
+			instanceType = p.AWS.InstanceType
+		case p.Azure != nil:
+			instanceType = p.Azure.InstanceType
+		case p.GCP != nil:
+			instanceType = p.GCP.InstanceType
+		}
+		if instanceType != "" {
+			instanceTypes = append(instanceTypes, instanceType)
+		}
+	}
+	return instanceTypes
+}
+
+// azureInstanceTypeRegex matches Azure VM sizes such as Standard_D4s_v3, capturing the series, the additive features
+// and the version.
+var azureInstanceTypeRegex = regexp.MustCompile(`^standard_([a-z]+)[0-9]+(?:-[0-9]+)?([a-z]*)(?:_(v[0-9]+))?`)
+
+// instanceTypeFamily returns the family of an AWS, GCP or Azure instance type, such as m5 for m5.xlarge, n2 for
+// n2-standard-4 and dsv3 for Standard_D4s_v3, or instanceFamilyUnknown if the instance type has none of those forms.
+func instanceTypeFamily(instanceType string) string {
+	instanceType = strings.ToLower(instanceType)
+	if m := azureInstanceTypeRegex.FindStringSubmatch(instanceType); m != nil {
+		return m[1] + m[2] + m[3]
+	}
+	for _, sep := range []string{".", "-"} {
+		if family, _, ok := strings.Cut(instanceType, sep); ok && family != "" {
+			return family
+		}
+	}
+	return instanceFamilyUnknown
+}
+
+func (cc instanceFamilyCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentInstanceFamily.Desc
+}
+
+var (
+	metricClusterDeploymentInstanceFamilyDesc = newConstMetricDesc(
+		"hive_cluster_deployment_instance_family",
+		"Number of ClusterDeployments running control plane or worker instances of each instance type family.",
+		"family",
+	)
+)
+
+func newInstanceFamilyCollector(client client.Client) prometheus.Collector {
+	return instanceFamilyCollector{
+		client:                                client,
+		installConfigs:                        newInstallConfigCache(),
+		metricClusterDeploymentInstanceFamily: metricClusterDeploymentInstanceFamilyDesc,
+	}
+}
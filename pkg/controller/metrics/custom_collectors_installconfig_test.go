@@ -0,0 +1,394 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hivev1aws "github.com/openshift/hive/apis/hive/v1/aws"
+	hivev1azure "github.com/openshift/hive/apis/hive/v1/azure"
+	hivev1gcp "github.com/openshift/hive/apis/hive/v1/gcp"
+	"github.com/openshift/hive/pkg/constants"
+	controllerutils "github.com/openshift/hive/pkg/controller/utils"
+	testcd "github.com/openshift/hive/pkg/test/clusterdeployment"
+	testcp "github.com/openshift/hive/pkg/test/clusterprovision"
+	testfake "github.com/openshift/hive/pkg/test/fake"
+	testgeneric "github.com/openshift/hive/pkg/test/generic"
+	testmp "github.com/openshift/hive/pkg/test/machinepool"
+	testsecret "github.com/openshift/hive/pkg/test/secret"
+	"github.com/openshift/hive/pkg/util/scheme"
+)
+
+func TestInstallConfigCollectors(t *testing.T) {
+	scheme := scheme.GetScheme()
+
+	installConfig := func(namespace, contents string) *corev1.Secret {
+		return testsecret.FullBuilder(namespace, "install-config", scheme).Build(
+			testsecret.WithDataKeyValue("install-config.yaml", []byte(contents)),
+		)
+	}
+	cdBuilder := func(name string) testcd.Builder {
+		return testcd.FullBuilder(name, name, scheme)
+	}
+	deleted := []testgeneric.Option{testgeneric.Deleted(), testgeneric.WithFinalizer(testFinalizer)}
+	provisioning := func(cd *hivev1.ClusterDeployment) {
+		cd.Spec.Provisioning = &hivev1.Provisioning{
+			InstallConfigSecretRef: &corev1.LocalObjectReference{Name: "install-config"},
+		}
+	}
+	provisioned := func(cd *hivev1.ClusterDeployment) {
+		provisioning(cd)
+		cd.Status.ProvisionRef = &corev1.LocalObjectReference{Name: "provision"}
+	}
+	checksum := func(contents string) string {
+		sum, err := controllerutils.GetInstallConfigChecksum(installConfig("", contents))
+		require.NoError(t, err)
+		return sum
+	}
+	provision := func(namespace string, opts ...testcp.Option) *hivev1.ClusterProvision {
+		return testcp.FullBuilder(namespace, "provision").Build(
+			append([]testcp.Option{testcp.WithClusterDeploymentRef(namespace)}, opts...)...,
+		)
+	}
+	startedWith := func(contents string) testcp.Option {
+		return testcp.Generic(testgeneric.WithAnnotation(constants.InstallConfigChecksumAnnotation, checksum(contents)))
+	}
+	mpBuilder := func(cdName, name string) testmp.Builder {
+		return testmp.FullBuilder(cdName, name, cdName, scheme)
+	}
+	awsType := func(instanceType string) testmp.Option {
+		return func(mp *hivev1.MachinePool) {
+			mp.Spec.Platform.AWS = &hivev1aws.MachinePoolPlatform{InstanceType: instanceType}
+		}
+	}
+	gcpType := func(instanceType string) testmp.Option {
+		return func(mp *hivev1.MachinePool) {
+			mp.Spec.Platform.GCP = &hivev1gcp.MachinePool{InstanceType: instanceType}
+		}
+	}
+	azureType := func(instanceType string) testmp.Option {
+		return func(mp *hivev1.MachinePool) {
+			mp.Spec.Platform.Azure = &hivev1azure.MachinePool{InstanceType: instanceType}
+		}
+	}
+	const (
+		directInstall = "baseDomain: example.com"
+		digestMirror  = `baseDomain: example.com
+imageDigestSources:
+- source: quay.io/openshift-release-dev/ocp-release
+  mirrors:
+  - mirror.example.com/ocp-release
+`
+		contentMirror = `baseDomain: example.com
+imageContentSources:
+- source: quay.io/openshift-release-dev/ocp-v4.0-art-dev
+  mirrors:
+  - mirror.example.com/ocp-v4.0-art-dev
+`
+		httpProxy = `baseDomain: example.com
+proxy:
+  httpProxy: http://proxy.example.com:3128
+`
+		httpsProxy = `baseDomain: example.com
+proxy:
+  httpsProxy: https://proxy.example.com:3129
+  noProxy: .example.com
+`
+		noProxyOnly = `baseDomain: example.com
+proxy:
+  noProxy: .example.com
+`
+	)
+
+	runCollectorTests(t, []collectorTest{{
+		name:      "mirror install",
+		collector: newMirrorInstallCollector,
+		cases: []collectorCase{{
+			name: "direct installs",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(provisioning),
+				installConfig("cd-1", directInstall),
+				cdBuilder("cd-2").Build(),
+			},
+		}, {
+			name: "mirrored and direct installs",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(provisioning),
+				installConfig("cd-1", digestMirror),
+				cdBuilder("cd-2").Build(testcd.Installed(), provisioning),
+				installConfig("cd-2", contentMirror),
+				cdBuilder("cd-3").Build(provisioning),
+				installConfig("cd-3", directInstall),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = cd-1 1",
+				"cluster_deployment = cd-2 namespace = cd-2 1",
+			},
+		}, {
+			name: "missing install config and deleted clusters",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(provisioning),
+				cdBuilder("cd-2").GenericOptions(deleted...).Build(provisioning),
+				installConfig("cd-2", digestMirror),
+			},
+		}},
+	}, {
+		name:      "proxy",
+		collector: newProxyCollector,
+		cases: []collectorCase{{
+			name: "direct installs",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(provisioning),
+				installConfig("cd-1", directInstall),
+				cdBuilder("cd-2").Build(provisioning),
+				installConfig("cd-2", noProxyOnly),
+				cdBuilder("cd-3").Build(),
+			},
+		}, {
+			name: "proxied and direct installs",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(provisioning),
+				installConfig("cd-1", httpProxy),
+				cdBuilder("cd-2").Build(testcd.Installed(), provisioning),
+				installConfig("cd-2", httpsProxy),
+				cdBuilder("cd-3").Build(provisioning),
+				installConfig("cd-3", directInstall),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = cd-1 1",
+				"cluster_deployment = cd-2 namespace = cd-2 1",
+			},
+		}, {
+			name: "missing install config and deleted clusters",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(provisioning),
+				cdBuilder("cd-2").GenericOptions(deleted...).Build(provisioning),
+				installConfig("cd-2", httpProxy),
+			},
+		}},
+	}, {
+		name:      "FIPS",
+		collector: newFIPSCollector,
+		cases: []collectorCase{{
+			name: "no clusters",
+			expected: []string{
+				"enabled = false 0",
+				"enabled = true 0",
+			},
+		}, {
+			name: "FIPS on and off",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(provisioning),
+				installConfig("cd-1", "fips: true"),
+				cdBuilder("cd-2").Build(testcd.Installed(), provisioning),
+				installConfig("cd-2", "fips: true"),
+				cdBuilder("cd-3").Build(provisioning),
+				installConfig("cd-3", "fips: false"),
+				cdBuilder("cd-4").Build(provisioning),
+				installConfig("cd-4", directInstall),
+			},
+			expected: []string{
+				"enabled = false 2",
+				"enabled = true 2",
+			},
+		}, {
+			name: "clusters without install config",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(),
+				cdBuilder("cd-2").Build(provisioning),
+				cdBuilder("cd-3").GenericOptions(deleted...).Build(provisioning),
+				installConfig("cd-3", "fips: true"),
+			},
+			expected: []string{
+				"enabled = false 0",
+				"enabled = true 0",
+				"enabled = unknown 1",
+			},
+		}},
+	}, {
+		name:      "network type",
+		collector: newNetworkTypeCollector,
+		cases: []collectorCase{{
+			name: "no clusters",
+			expected: []string{
+				"type = OVNKubernetes 0",
+				"type = OpenShiftSDN 0",
+			},
+		}, {
+			name: "OVN and SDN clusters",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(provisioning),
+				installConfig("cd-1", "networking:\n  networkType: OVNKubernetes"),
+				cdBuilder("cd-2").Build(testcd.Installed(), provisioning),
+				installConfig("cd-2", "networking:\n  networkType: OVNKubernetes"),
+				cdBuilder("cd-3").Build(testcd.Installed(), provisioning),
+				installConfig("cd-3", "networking:\n  networkType: OpenShiftSDN"),
+				cdBuilder("cd-4").Build(provisioning),
+				installConfig("cd-4", directInstall),
+				cdBuilder("cd-5").Build(provisioning),
+				installConfig("cd-5", "networking:\n  clusterNetwork:\n  - cidr: 10.128.0.0/14"),
+			},
+			expected: []string{
+				"type = OVNKubernetes 2",
+				"type = OpenShiftSDN 1",
+				"type = unspecified 2",
+			},
+		}, {
+			name: "clusters without install config",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(),
+				cdBuilder("cd-2").Build(provisioning),
+				cdBuilder("cd-3").GenericOptions(deleted...).Build(provisioning),
+				installConfig("cd-3", "networking:\n  networkType: OpenShiftSDN"),
+			},
+			expected: []string{
+				"type = OVNKubernetes 0",
+				"type = OpenShiftSDN 0",
+				"type = unknown 1",
+			},
+		}},
+	}, {
+		name:      "instance family",
+		collector: newInstanceFamilyCollector,
+		cases: []collectorCase{{
+			name: "no clusters",
+		}, {
+			name: "worker families across clouds",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(),
+				mpBuilder("cd-1", "worker").Build(awsType("m5.xlarge")),
+				mpBuilder("cd-1", "infra").Build(awsType("m5.2xlarge")),
+				cdBuilder("cd-2").Build(),
+				mpBuilder("cd-2", "worker").Build(awsType("m5.large")),
+				mpBuilder("cd-2", "gpu").Build(awsType("g4dn.xlarge")),
+				cdBuilder("cd-3").Build(),
+				mpBuilder("cd-3", "worker").Build(gcpType("n2-standard-4")),
+				cdBuilder("cd-4").Build(),
+				mpBuilder("cd-4", "worker").Build(azureType("Standard_D4s_v3")),
+				cdBuilder("cd-5").Build(),
+				mpBuilder("cd-5", "worker").Build(azureType("Standard_D8s_v3")),
+			},
+			expected: []string{
+				"family = dsv3 2",
+				"family = g4dn 1",
+				"family = m5 2",
+				"family = n2 1",
+			},
+		}, {
+			name: "control plane families",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(provisioning),
+				installConfig("cd-1", "controlPlane:\n  platform:\n    aws:\n      type: m6i.2xlarge\n"),
+				mpBuilder("cd-1", "worker").Build(awsType("m5.xlarge")),
+				cdBuilder("cd-2").Build(provisioning),
+				installConfig("cd-2", "controlPlane:\n  platform:\n    gcp:\n      type: e2-standard-8\ncompute:\n- platform:\n    gcp:\n      type: e2-standard-4\n"),
+			},
+			expected: []string{
+				"family = e2 1",
+				"family = m5 1",
+				"family = m6i 1",
+			},
+		}, {
+			name: "unknown families",
+			existing: []runtime.Object{
+				// No MachinePools or install-config.
+				cdBuilder("cd-1").Build(),
+				// No instance type in the install-config.
+				cdBuilder("cd-2").Build(provisioning),
+				installConfig("cd-2", directInstall),
+				cdBuilder("cd-3").Build(),
+				mpBuilder("cd-3", "worker").Build(awsType("custom")),
+				cdBuilder("cd-4").GenericOptions(deleted...).Build(),
+				mpBuilder("cd-4", "worker").Build(awsType("m5.xlarge")),
+			},
+			expected: []string{
+				"family = unknown 3",
+			},
+		}},
+	}, {
+		name:      "install config mutated",
+		collector: newInstallConfigMutatedCollector,
+		cases: []collectorCase{{
+			name: "stable install configs",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(provisioned),
+				installConfig("cd-1", directInstall),
+				provision("cd-1", startedWith(directInstall)),
+			},
+		}, {
+			name: "mutated and stable install configs",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(provisioned),
+				installConfig("cd-1", directInstall),
+				provision("cd-1", startedWith(directInstall)),
+				cdBuilder("cd-2").Build(provisioned),
+				installConfig("cd-2", "baseDomain: changed.example.com"),
+				provision("cd-2", startedWith(directInstall)),
+			},
+			expected: []string{
+				"cluster_deployment = cd-2 namespace = cd-2 1",
+			},
+		}, {
+			name: "provision without recorded checksum",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(provisioned),
+				installConfig("cd-1", "baseDomain: changed.example.com"),
+				provision("cd-1"),
+			},
+		}, {
+			name: "installed and deleted clusters",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(testcd.Installed(), provisioned),
+				installConfig("cd-1", "baseDomain: changed.example.com"),
+				provision("cd-1", startedWith(directInstall)),
+				cdBuilder("cd-2").GenericOptions(deleted...).Build(provisioned),
+				installConfig("cd-2", "baseDomain: changed.example.com"),
+				provision("cd-2", startedWith(directInstall)),
+			},
+		}},
+	}})
+}
+
+func TestInstallConfigCache(t *testing.T) {
+	scheme := scheme.GetScheme()
+	cd := testcd.FullBuilder("cd-1", "cd-1", scheme).Build(func(cd *hivev1.ClusterDeployment) {
+		cd.Spec.Provisioning = &hivev1.Provisioning{
+			InstallConfigSecretRef: &corev1.LocalObjectReference{Name: "install-config"},
+		}
+	})
+	icSecret := testsecret.FullBuilder("cd-1", "install-config", scheme).Build(
+		testsecret.WithDataKeyValue("install-config.yaml", []byte("fips: true")),
+	)
+	c := testfake.NewFakeClientBuilder().WithRuntimeObjects(cd, icSecret).Build()
+	installConfigs := newInstallConfigCache()
+
+	summary, err := installConfigs.get(context.Background(), c, cd)
+	require.NoError(t, err)
+	assert.True(t, summary.fips)
+
+	// The install-config is only parsed again once its secret changes.
+	key := types.NamespacedName{Namespace: "cd-1", Name: "install-config"}
+	installConfigs.entries[key].summary.fips = false
+	summary, err = installConfigs.get(context.Background(), c, cd)
+	require.NoError(t, err)
+	assert.False(t, summary.fips, "expected the cached summary")
+
+	require.NoError(t, c.Get(context.Background(), key, icSecret))
+	icSecret.Data["install-config.yaml"] = []byte("fips: true\nbaseDomain: example.com")
+	require.NoError(t, c.Update(context.Background(), icSecret))
+	summary, err = installConfigs.get(context.Background(), c, cd)
+	require.NoError(t, err)
+	assert.True(t, summary.fips, "expected the changed secret to be parsed")
+
+	// Entries not read between two sweeps are dropped.
+	installConfigs.sweep()
+	assert.Len(t, installConfigs.entries, 1)
+	installConfigs.sweep()
+	assert.Empty(t, installConfigs.entries)
+}
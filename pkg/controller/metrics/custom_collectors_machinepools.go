@@ -0,0 +1,413 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+)
+
+// machinePoolSpotInstancesCollector reports MachinePools configured to run on spot/preemptible instances.
+type machinePoolSpotInstancesCollector struct {
+	client client.Client
+
+	// metricMachinePoolSpotInstances is a prometheus metric reporting MachinePools using spot/preemptible instances.
+	metricMachinePoolSpotInstances constMetricDesc
+}
+
+// Collect collects the metrics for machinePoolSpotInstancesCollector
+func (cc machinePoolSpotInstancesCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "spot instance metrics across all MachinePools")
+	defer scrape.done()
+
+	machinePools := &hivev1.MachinePoolList{}
+	pages := scrape.list(cc.client, machinePools)
+	for pages.next() {
+		for _, mp := range machinePools.Items {
+			if mp.DeletionTimestamp != nil {
+				continue
+			}
+			if !usesSpotInstances(&mp) {
+				continue
+			}
+			ch <- cc.metricMachinePoolSpotInstances.mustNewConstMetric(
+				prometheus.GaugeValue,
+				1,
+				prometheus.Labels{
+					"cluster_deployment": mp.Spec.ClusterDeploymentRef.Name,
+					"machine_pool":       mp.Spec.Name,
+					"namespace":          mp.Namespace,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+// usesSpotInstances returns true if the MachinePool's platform spec requests spot/preemptible instances.
+// Only AWS MachinePools can currently be configured this way.
+func usesSpotInstances(mp *hivev1.MachinePool) bool {
+	return mp.Spec.Platform.AWS != nil && mp.Spec.Platform.AWS.SpotMarketOptions != nil
+}
+
+func (cc machinePoolSpotInstancesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricMachinePoolSpotInstances.Desc
+}
+
+var (
+	metricMachinePoolSpotInstancesDesc = newConstMetricDesc(
+		"hive_machine_pool_spot_instances",
+		"Whether a machine pool is configured to use spot/preemptible instances.",
+		"cluster_deployment", "machine_pool", "namespace",
+	)
+)
+
+func newMachinePoolSpotInstancesCollector(client client.Client) prometheus.Collector {
+	return machinePoolSpotInstancesCollector{
+		client:                         client,
+		metricMachinePoolSpotInstances: metricMachinePoolSpotInstancesDesc,
+	}
+}
+
+// availabilityZoneCountCollector reports how many availability zones each ClusterDeployment's MachinePools span.
+type availabilityZoneCountCollector struct {
+	client client.Client
+
+	// metricClusterDeploymentAZCount is a prometheus metric for the number of distinct availability zones
+	// configured across a ClusterDeployment's MachinePools.
+	metricClusterDeploymentAZCount constMetricDesc
+}
+
+// Collect collects the metrics for availabilityZoneCountCollector
+func (cc availabilityZoneCountCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "availability zone metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	machinePools := &hivev1.MachinePoolList{}
+	mpPages := scrape.list(cc.client, machinePools)
+	zonesByCD := map[types.NamespacedName]sets.Set[string]{}
+	for mpPages.next() {
+		for _, mp := range machinePools.Items {
+			if mp.DeletionTimestamp != nil {
+				continue
+			}
+			zones := machinePoolZones(&mp)
+			if len(zones) == 0 {
+				continue
+			}
+			key := types.NamespacedName{Namespace: mp.Namespace, Name: mp.Spec.ClusterDeploymentRef.Name}
+			if zonesByCD[key] == nil {
+				zonesByCD[key] = sets.New[string]()
+			}
+			zonesByCD[key].Insert(zones...)
+		}
+	}
+	if scrape.listFailed(mpPages) {
+		return
+	}
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	cdPages := scrape.list(cc.client, clusterDeployments)
+	for cdPages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			// Clusters with no zones configured on any MachinePool leave the choice to the installer, so we
+			// can't say how many zones they span.
+			zones, ok := zonesByCD[types.NamespacedName{Namespace: cd.Namespace, Name: cd.Name}]
+			if !ok {
+				continue
+			}
+			ch <- cc.metricClusterDeploymentAZCount.mustNewConstMetric(
+				prometheus.GaugeValue,
+				float64(zones.Len()),
+				prometheus.Labels{
+					"cluster_deployment": cd.Name,
+					"namespace":          cd.Namespace,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(cdPages) {
+		return
+	}
+}
+
+// machinePoolZones returns the availability zones configured in the MachinePool's platform spec.
+func machinePoolZones(mp *hivev1.MachinePool) []string {
+	switch p := mp.Spec.Platform; {
+	case p.AWS != nil:
+		return p.AWS.Zones
+	case p.Azure != nil:
+		return p.Azure.Zones
+	case p.GCP != nil:
+		return p.GCP.Zones
+	default:
+		return nil
+	}
+}
+
+func (cc availabilityZoneCountCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentAZCount.Desc
+}
+
+var (
+	metricClusterDeploymentAZCountDesc = newConstMetricDesc(
+		"hive_cluster_deployment_az_count",
+		"Number of availability zones a cluster's machine pools span.",
+		"cluster_deployment", "namespace",
+	)
+)
+
+func newAvailabilityZoneCountCollector(client client.Client) prometheus.Collector {
+	return availabilityZoneCountCollector{
+		client:                         client,
+		metricClusterDeploymentAZCount: metricClusterDeploymentAZCountDesc,
+	}
+}
+
+// nodes below minimum metric collected through a custom prometheus collector
+type nodesBelowMinCollector struct {
+	client client.Client
+
+	// metricClusterDeploymentNodesBelowMin is a prometheus metric reporting MachinePools with fewer ready nodes than
+	// their configured minimum.
+	metricClusterDeploymentNodesBelowMin constMetricDesc
+}
+
+// Collect collects the metrics for nodesBelowMinCollector
+func (cc nodesBelowMinCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "nodes below minimum metrics across all MachinePools")
+	defer scrape.done()
+
+	// Nodes of clusters that aren't installed or are hibernating are expected to be missing.
+	running := sets.New[types.NamespacedName]()
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	cdPages := scrape.list(cc.client, clusterDeployments)
+	for cdPages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil || !cd.Spec.Installed || cd.Spec.PowerState == hivev1.ClusterPowerStateHibernating {
+				continue
+			}
+			running.Insert(types.NamespacedName{Namespace: cd.Namespace, Name: cd.Name})
+		}
+	}
+	if scrape.listFailed(cdPages) {
+		return
+	}
+
+	machinePools := &hivev1.MachinePoolList{}
+	mpPages := scrape.list(cc.client, machinePools)
+	for mpPages.next() {
+		for _, mp := range machinePools.Items {
+			if mp.DeletionTimestamp != nil {
+				continue
+			}
+			if !running.Has(types.NamespacedName{Namespace: mp.Namespace, Name: mp.Spec.ClusterDeploymentRef.Name}) {
+				continue
+			}
+			// MachinePools that haven't reported their MachineSets yet have no observed node count.
+			if len(mp.Status.MachineSets) == 0 {
+				continue
+			}
+			var minimum int32
+			switch {
+			case mp.Spec.Autoscaling != nil:
+				minimum = mp.Spec.Autoscaling.MinReplicas
+			case mp.Spec.Replicas != nil:
+				minimum = int32(*mp.Spec.Replicas)
+			default:
+				continue
+			}
+			var ready int32
+			for _, ms := range mp.Status.MachineSets {
+				ready += ms.ReadyReplicas
+			}
+			if ready >= minimum {
+				continue
+			}
+			ch <- cc.metricClusterDeploymentNodesBelowMin.mustNewConstMetric(
+				prometheus.GaugeValue,
+				1,
+				prometheus.Labels{
+					"cluster_deployment": mp.Spec.ClusterDeploymentRef.Name,
+					"machine_pool":       mp.Spec.Name,
+					"namespace":          mp.Namespace,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(mpPages) {
+		return
+	}
+}
+
+func (cc nodesBelowMinCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentNodesBelowMin.Desc
+}
+
+var (
+	metricClusterDeploymentNodesBelowMinDesc = newConstMetricDesc(
+		"hive_cluster_deployment_nodes_below_min",
+		"Whether a running cluster's MachinePool has fewer ready nodes than its configured minimum.",
+		"cluster_deployment", "machine_pool", "namespace",
+	)
+)
+
+// newNodesBelowMinCollector returns a collector reporting MachinePools of running clusters whose MachineSets have
+// fewer ready replicas than the pool's replicas or autoscaling minimum.
+func newNodesBelowMinCollector(client client.Client) prometheus.Collector {
+	return nodesBelowMinCollector{
+		client:                               client,
+		metricClusterDeploymentNodesBelowMin: metricClusterDeploymentNodesBelowMinDesc,
+	}
+}
+
+// machine pool replicas mismatch metric collected through a custom prometheus collector
+type machinePoolUnderwayCollector struct {
+	client client.Client
+
+	// clock is the source of the current time.
+	clock clock.PassiveClock
+
+	// minDuration is how long a MachinePool's replicas must have differed from its desired replicas before it is
+	// reported.
+	minDuration time.Duration
+
+	// mismatchedSince records when each MachinePool was first seen away from its desired replicas.
+	mismatchedSince *firstSeenTracker
+
+	// metricMachinePoolReplicasMismatch is a prometheus metric for the number of replicas a MachinePool is short of
+	// (or, when negative, over) its desired replicas.
+	metricMachinePoolReplicasMismatch constMetricDesc
+}
+
+// Collect collects the metrics for machinePoolUnderwayCollector
+func (cc machinePoolUnderwayCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "replicas mismatch metrics across all MachinePools")
+	defer scrape.done()
+
+	type mismatch struct {
+		delta  int32
+		labels prometheus.Labels
+	}
+	mismatches := map[types.NamespacedName]mismatch{}
+	machinePools := &hivev1.MachinePoolList{}
+	pages := scrape.list(cc.client, machinePools)
+	for pages.next() {
+		for _, mp := range machinePools.Items {
+			if mp.DeletionTimestamp != nil {
+				continue
+			}
+			delta := getMachinePoolReplicasDelta(&mp)
+			if delta == 0 {
+				continue
+			}
+			mismatches[types.NamespacedName{Namespace: mp.Namespace, Name: mp.Name}] = mismatch{
+				delta: delta,
+				labels: prometheus.Labels{
+					"cluster_deployment":    mp.Spec.ClusterDeploymentRef.Name,
+					"machinepool_name":      mp.Name,
+					"machinepool_namespace": mp.Namespace,
+					"pool":                  mp.Spec.Name,
+				},
+			}
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+
+	now := cc.clock.Now()
+	since := cc.mismatchedSince.observe(sets.KeySet(mismatches), now)
+	for key, m := range mismatches {
+		if now.Sub(since[key]) < cc.minDuration {
+			continue
+		}
+		ch <- cc.metricMachinePoolReplicasMismatch.mustNewConstMetric(prometheus.GaugeValue, float64(m.delta), m.labels)
+	}
+}
+
+func (cc machinePoolUnderwayCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricMachinePoolReplicasMismatch.Desc
+}
+
+var (
+	metricMachinePoolReplicasMismatchDesc = newConstMetricDesc(
+		"hive_machinepool_replicas_mismatch",
+		"Desired replicas minus current replicas of a MachinePool that has not reached its desired replicas.",
+		"cluster_deployment", "machinepool_name", "machinepool_namespace", "pool",
+	)
+)
+
+// newMachinePoolUnderwayCollector returns a collector reporting MachinePools whose replicas have differed from their
+// desired replicas for at least minimum. As MachinePools do not record when their replicas last matched, the duration
+// is measured from the first collection that saw the mismatch.
+func newMachinePoolUnderwayCollector(c client.Client, minimum time.Duration) prometheus.Collector {
+	return machinePoolUnderwayCollector{
+		client:                            c,
+		clock:                             clock.RealClock{},
+		minDuration:                       minimum,
+		mismatchedSince:                   newFirstSeenTracker(),
+		metricMachinePoolReplicasMismatch: metricMachinePoolReplicasMismatchDesc,
+	}
+}
+
+// getMachinePoolReplicasDelta returns the desired replicas of mp minus its current replicas. The desired replicas
+// are spec.replicas or, when autoscaling, the current replicas clamped to the autoscaling bounds. MachinePools with
+// neither have no desired replicas, and report no difference.
+func getMachinePoolReplicasDelta(mp *hivev1.MachinePool) int32 {
+	current := mp.Status.Replicas
+	desired := current
+	switch {
+	case mp.Spec.Autoscaling != nil:
+		if current < mp.Spec.Autoscaling.MinReplicas {
+			desired = mp.Spec.Autoscaling.MinReplicas
+		} else if current > mp.Spec.Autoscaling.MaxReplicas {
+			desired = mp.Spec.Autoscaling.MaxReplicas
+		}
+	case mp.Spec.Replicas != nil:
+		desired = int32(*mp.Spec.Replicas)
+	}
+	return desired - current
+}
+
+// firstSeenTracker remembers when each object was first seen in a condition across collections, forgetting objects
+// as soon as they are seen out of it.
+type firstSeenTracker struct {
+	mu        sync.Mutex
+	firstSeen map[types.NamespacedName]time.Time
+}
+
+func newFirstSeenTracker() *firstSeenTracker {
+	return &firstSeenTracker{firstSeen: map[types.NamespacedName]time.Time{}}
+}
+
+// observe records that exactly the objects in seen are in the condition as of now, and returns when each of them
+// was first seen in it.
+func (t *firstSeenTracker) observe(seen sets.Set[types.NamespacedName], now time.Time) map[types.NamespacedName]time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	firstSeen := make(map[types.NamespacedName]time.Time, len(seen))
+	for key := range seen {
+		since, ok := t.firstSeen[key]
+		if !ok {
+			since = now
+		}
+		firstSeen[key] = since
+	}
+	// The map is replaced rather than updated in place, so the one returned is never written to again.
+	t.firstSeen = firstSeen
+	return firstSeen
+}
@@ -0,0 +1,254 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hivev1aws "github.com/openshift/hive/apis/hive/v1/aws"
+	hivev1gcp "github.com/openshift/hive/apis/hive/v1/gcp"
+	testcd "github.com/openshift/hive/pkg/test/clusterdeployment"
+	testfake "github.com/openshift/hive/pkg/test/fake"
+	testgeneric "github.com/openshift/hive/pkg/test/generic"
+	testmp "github.com/openshift/hive/pkg/test/machinepool"
+	"github.com/openshift/hive/pkg/util/scheme"
+)
+
+func TestMachinePoolCollectors(t *testing.T) {
+	scheme := scheme.GetScheme()
+
+	cdBuilder := func(name string) testcd.Builder {
+		return testcd.FullBuilder(name, name, scheme)
+	}
+	mpBuilder := func(cdName, name string) testmp.Builder {
+		return testmp.FullBuilder(cdName, name, cdName, scheme)
+	}
+	deleted := []testgeneric.Option{testgeneric.Deleted(), testgeneric.WithFinalizer(testFinalizer)}
+	aws := func(zones ...string) testmp.Option {
+		return func(mp *hivev1.MachinePool) {
+			mp.Spec.Platform.AWS = &hivev1aws.MachinePoolPlatform{InstanceType: "m5.xlarge", Zones: zones}
+		}
+	}
+	spot := func(mp *hivev1.MachinePool) {
+		mp.Spec.Platform.AWS.SpotMarketOptions = &hivev1aws.SpotMarketOptions{}
+	}
+	gcp := func(zones ...string) testmp.Option {
+		return func(mp *hivev1.MachinePool) {
+			mp.Spec.Platform.GCP = &hivev1gcp.MachinePool{InstanceType: "n1-standard-4", Zones: zones}
+		}
+	}
+	replicas := func(desired int64, current int32) testmp.Option {
+		return func(mp *hivev1.MachinePool) {
+			mp.Spec.Replicas = &desired
+			mp.Status.Replicas = current
+		}
+	}
+	autoscaling := func(minReplicas, maxReplicas, current int32) testmp.Option {
+		return func(mp *hivev1.MachinePool) {
+			mp.Spec.Autoscaling = &hivev1.MachinePoolAutoscaling{MinReplicas: minReplicas, MaxReplicas: maxReplicas}
+			mp.Status.Replicas = current
+		}
+	}
+	readyNodes := func(ready ...int32) testmp.Option {
+		return func(mp *hivev1.MachinePool) {
+			for i, r := range ready {
+				mp.Status.MachineSets = append(mp.Status.MachineSets, hivev1.MachineSetStatus{
+					Name:          fmt.Sprintf("worker-%d", i),
+					Replicas:      r,
+					ReadyReplicas: r,
+				})
+			}
+		}
+	}
+
+	runCollectorTests(t, []collectorTest{{
+		name:      "spot instances",
+		collector: newMachinePoolSpotInstancesCollector,
+		cases: []collectorCase{{
+			name: "on-demand pools",
+			existing: []runtime.Object{
+				testmp.FullBuilder("ns-1", "worker", "cd-1", scheme).Build(aws()),
+				testmp.FullBuilder("ns-2", "worker", "cd-2", scheme).Build(),
+			},
+		}, {
+			name: "mix of spot and on-demand pools",
+			existing: []runtime.Object{
+				testmp.FullBuilder("ns-1", "worker", "cd-1", scheme).Build(aws()),
+				testmp.FullBuilder("ns-1", "spot", "cd-1", scheme).Build(aws(), spot),
+				testmp.FullBuilder("ns-2", "worker", "cd-2", scheme).Build(aws(), spot),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 machine_pool = spot namespace = ns-1 1",
+				"cluster_deployment = cd-2 machine_pool = worker namespace = ns-2 1",
+			},
+		}, {
+			name: "deleted spot pool",
+			existing: []runtime.Object{
+				testmp.FullBuilder("ns-1", "spot", "cd-1", scheme).GenericOptions(deleted...).Build(aws(), spot),
+			},
+		}},
+	}, {
+		name:      "availability zone count",
+		collector: newAvailabilityZoneCountCollector,
+		cases: []collectorCase{{
+			name: "no zones configured",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(),
+				mpBuilder("cd-1", "worker").Build(aws()),
+			},
+		}, {
+			name: "single- and multi-AZ clusters",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(),
+				mpBuilder("cd-1", "worker").Build(aws("us-east-1a")),
+				cdBuilder("cd-2").Build(),
+				mpBuilder("cd-2", "worker").Build(aws("us-east-1a", "us-east-1b", "us-east-1c")),
+				cdBuilder("cd-3").Build(),
+				mpBuilder("cd-3", "worker").Build(gcp("us-east1-b", "us-east1-c")),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = cd-1 1",
+				"cluster_deployment = cd-2 namespace = cd-2 3",
+				"cluster_deployment = cd-3 namespace = cd-3 2",
+			},
+		}, {
+			name: "zones combined across machine pools",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(),
+				mpBuilder("cd-1", "worker").Build(aws("us-east-1a", "us-east-1b")),
+				mpBuilder("cd-1", "infra").Build(aws("us-east-1b", "us-east-1c")),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = cd-1 3",
+			},
+		}, {
+			name: "deleted cluster",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").GenericOptions(deleted...).Build(),
+				mpBuilder("cd-1", "worker").Build(aws("us-east-1a")),
+			},
+		}},
+	}, {
+		name:      "nodes below min",
+		collector: newNodesBelowMinCollector,
+		cases: []collectorCase{{
+			name: "healthy node counts",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(testcd.Installed()),
+				mpBuilder("cd-1", "worker").Build(replicas(3, 3), readyNodes(1, 1, 1)),
+				cdBuilder("cd-2").Build(testcd.Installed()),
+				mpBuilder("cd-2", "worker").Build(autoscaling(2, 6, 4), readyNodes(2, 2)),
+			},
+		}, {
+			name: "degraded node counts",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(testcd.Installed()),
+				mpBuilder("cd-1", "worker").Build(replicas(3, 3), readyNodes(1, 0, 1)),
+				cdBuilder("cd-2").Build(testcd.Installed()),
+				mpBuilder("cd-2", "worker").Build(autoscaling(3, 6, 3), readyNodes(1, 1)),
+				cdBuilder("cd-3").Build(testcd.Installed()),
+				mpBuilder("cd-3", "worker").Build(replicas(3, 3), readyNodes(1, 1, 1)),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 machine_pool = worker namespace = cd-1 1",
+				"cluster_deployment = cd-2 machine_pool = worker namespace = cd-2 1",
+			},
+		}, {
+			name: "clusters not expected to have nodes",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(),
+				mpBuilder("cd-1", "worker").Build(replicas(3, 3), readyNodes(0)),
+				cdBuilder("cd-2").Build(testcd.Installed(), testcd.WithPowerState(hivev1.ClusterPowerStateHibernating)),
+				mpBuilder("cd-2", "worker").Build(replicas(3, 3), readyNodes(0)),
+				cdBuilder("cd-3").GenericOptions(deleted...).Build(testcd.Installed()),
+				mpBuilder("cd-3", "worker").Build(replicas(3, 3), readyNodes(0)),
+				// MachineSets not yet reported.
+				cdBuilder("cd-4").Build(testcd.Installed()),
+				mpBuilder("cd-4", "worker").Build(replicas(3, 3)),
+			},
+		}},
+	}, {
+		name: "machine pool underway",
+		collector: func(c client.Client) prometheus.Collector {
+			return newMachinePoolUnderwayCollector(c, 0)
+		},
+		cases: []collectorCase{{
+			name: "at target",
+			existing: []runtime.Object{
+				mpBuilder("cd-1", "worker").Build(replicas(3, 3)),
+				mpBuilder("cd-2", "worker").Build(replicas(0, 0)),
+			},
+		}, {
+			name: "over target",
+			existing: []runtime.Object{
+				mpBuilder("cd-1", "worker").Build(replicas(3, 5)),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 machinepool_name = cd-1-worker machinepool_namespace = cd-1 pool = worker -2",
+			},
+		}, {
+			name: "under target",
+			existing: []runtime.Object{
+				mpBuilder("cd-1", "worker").Build(replicas(3, 1)),
+				mpBuilder("cd-2", "worker").GenericOptions(deleted...).Build(replicas(3, 0)),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 machinepool_name = cd-1-worker machinepool_namespace = cd-1 pool = worker 2",
+			},
+		}, {
+			name: "autoscaling",
+			existing: []runtime.Object{
+				mpBuilder("cd-1", "worker").Build(autoscaling(2, 6, 4)),
+				mpBuilder("cd-2", "worker").Build(autoscaling(2, 6, 1)),
+				mpBuilder("cd-3", "worker").Build(autoscaling(2, 6, 8)),
+				mpBuilder("cd-4", "worker").Build(autoscaling(2, 6, 6)),
+			},
+			expected: []string{
+				"cluster_deployment = cd-2 machinepool_name = cd-2-worker machinepool_namespace = cd-2 pool = worker 1",
+				"cluster_deployment = cd-3 machinepool_name = cd-3-worker machinepool_namespace = cd-3 pool = worker -2",
+			},
+		}},
+	}})
+}
+
+func TestMachinePoolUnderwayCollectorMin(t *testing.T) {
+	scheme := scheme.GetScheme()
+
+	mp := testmp.FullBuilder("cd-1", "worker", "cd-1", scheme).Build(func(mp *hivev1.MachinePool) {
+		replicas := int64(3)
+		mp.Spec.Replicas = &replicas
+		mp.Status.Replicas = 1
+	})
+	c := testfake.NewFakeClientBuilder().WithRuntimeObjects(mp).Build()
+	collect := newMachinePoolUnderwayCollector(c, time.Hour).(machinePoolUnderwayCollector)
+	clock := clocktesting.NewFakePassiveClock(testNow)
+	collect.clock = clock
+
+	// A mismatch is only reported once it has been seen for the minimum duration.
+	assert.Empty(t, collectMetrics(t, collect, metricPrettyWithValue))
+	key := types.NamespacedName{Namespace: "cd-1", Name: "cd-1-worker"}
+	clock.SetTime(testNow.Add(time.Hour - time.Second))
+	assert.Empty(t, collectMetrics(t, collect, metricPrettyWithValue))
+	clock.SetTime(testNow.Add(time.Hour))
+	assert.Equal(t, []string{
+		"cluster_deployment = cd-1 machinepool_name = cd-1-worker machinepool_namespace = cd-1 pool = worker 2",
+	}, collectMetrics(t, collect, metricPrettyWithValue))
+	tracker := collect.mismatchedSince
+
+	// Once the pool reaches its target, the mismatch is forgotten.
+	require.NoError(t, c.Get(context.Background(), key, mp))
+	mp.Status.Replicas = 3
+	require.NoError(t, c.Status().Update(context.Background(), mp))
+	assert.Empty(t, collectMetrics(t, collect, metricPrettyWithValue))
+	assert.NotContains(t, tracker.firstSeen, key)
+}
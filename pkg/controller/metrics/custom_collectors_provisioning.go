@@ -0,0 +1,1671 @@
+package metrics
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/ghodss/yaml"
+	"github.com/prometheus/client_golang/prometheus"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/openshift/hive/apis/hive/v1/metricsconfig"
+	"github.com/openshift/hive/pkg/constants"
+	controllerutils "github.com/openshift/hive/pkg/controller/utils"
+)
+
+// provisioning underway metrics collected through a custom prometheus collector
+type provisioningUnderwayCollector struct {
+	client client.Client
+
+	// clock is the source of the current time.
+	clock clock.PassiveClock
+
+	// minDuration, when non-zero, is the minimum duration after which clusters provisioning
+	// will start becoming part of this metric. Clusters provisioning for exactly minDuration
+	// are included. When set to zero, all clusters provisioning will be included in the metric. It can be changed
+	// with SetMinDuration while the collector is registered.
+	minDuration *liveSetting[time.Duration]
+
+	// minDurationByCondition overrides minDuration for clusters whose reported condition is in the map.
+	minDurationByCondition map[hivev1.ClusterDeploymentConditionType]time.Duration
+
+	// excludedNamespaces filters out objects in namespaces that should not be reported.
+	excludedNamespaces namespaceFilter
+
+	// includedClusterTypes limits the ClusterDeployments reported to those of the given cluster types.
+	includedClusterTypes clusterTypeFilter
+
+	// clusterTypeLabel is the label whose value is reported as cluster_type.
+	clusterTypeLabel clusterTypeLabel
+
+	// reasons collapses unknown condition reasons in the reason label.
+	reasons reasonFilter
+
+	// ownedBy adds the owned_by label, naming the owner of each cluster.
+	ownedBy bool
+
+	// version adds the version label, naming the version each cluster is being installed with.
+	version bool
+
+	// provisionKind adds the provision_kind label, telling fresh installs from reprovisions and adopted clusters.
+	provisionKind bool
+
+	// quotaDetail adds the quota_detail label, naming the quota exhausted by clusters failing on a quota.
+	quotaDetail bool
+
+	// includePaused reports clusters whose reconciles are paused, with the paused label, rather than skipping them.
+	includePaused bool
+
+	// metricClusterDeploymentProvisionUnderwaySeconds is a prometheus metric for the number of seconds
+	// between when a still provisioning cluster was created and now.
+	metricClusterDeploymentProvisionUnderwaySeconds constMetricDesc
+
+	// postInstallDegraded, when true, reports installed clusters with a condition in postInstallDegradedCondition in
+	// an undesired state through metricClusterDeploymentPostInstallDegradedSeconds.
+	postInstallDegraded bool
+
+	// metricClusterDeploymentPostInstallDegradedSeconds is a prometheus metric for the number of seconds an installed
+	// cluster has had a post-install condition in an undesired state.
+	metricClusterDeploymentPostInstallDegradedSeconds constMetricDesc
+}
+
+// Collect collects the metrics for provisioningUnderwayCollector
+func (cc provisioningUnderwayCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "provisioning underway metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	// Load all ClusterDeployments so we can accumulate facts about them.
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	buffer := cc.metricClusterDeploymentProvisionUnderwaySeconds.newBuffer()
+	degradedBuffer := cc.metricClusterDeploymentPostInstallDegradedSeconds.newBuffer()
+	defaultMinDuration := cc.minDuration.get()
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			clusterType := cc.clusterTypeLabel.value(&cd)
+			if cc.excludedNamespaces.excludes(cd.Namespace) || !cc.includedClusterTypes.includes(clusterType) {
+				continue
+			}
+			paused := isReconcilePaused(&cd)
+			if paused && !cc.includePaused {
+				continue
+			}
+			if cd.Spec.Installed {
+				if cc.postInstallDegraded {
+					cc.collectPostInstallDegraded(ch, degradedBuffer, &cd, paused)
+				}
+				continue
+			}
+
+			platform := cd.Labels[hivev1.HiveClusterPlatformLabel]
+			imageSet := "none"
+			if cd.Spec.Provisioning != nil && cd.Spec.Provisioning.ImageSetRef != nil {
+				imageSet = cd.Spec.Provisioning.ImageSetRef.Name
+			}
+			// Add install failure details for stuck provision
+			condition, reason, skip := getConditionAndReason(cd.Status.Conditions)
+			if skip {
+				continue
+			}
+
+			elapsedDuration := cc.clock.Since(cd.CreationTimestamp.Time)
+			minDuration := defaultMinDuration
+			if override, ok := cc.minDurationByCondition[hivev1.ClusterDeploymentConditionType(condition)]; ok {
+				minDuration = override
+			}
+			if minDuration.Seconds() > 0 && elapsedDuration < minDuration {
+				continue // skip reporting the metric for clusterdeployment until the elapsed time is at least minDuration
+			}
+
+			buffer.labels["cluster_deployment"] = cd.Name
+			buffer.labels["cluster_type"] = clusterType
+			buffer.labels["condition"] = condition
+			buffer.labels["image_set"] = imageSet
+			buffer.labels["namespace"] = cd.Namespace
+			buffer.labels["platform"] = platform
+			buffer.labels["reason"] = cc.reasons.labelValue(reason)
+			if cc.quotaDetail {
+				buffer.labels["quota_detail"] = getQuotaDetail(cd.Status.Conditions, condition, reason)
+			}
+			if cc.provisionKind {
+				buffer.labels["provision_kind"] = getProvisionKind(&cd)
+			}
+			if cc.version {
+				version := ""
+				if cd.Status.InstallVersion != nil {
+					version = *cd.Status.InstallVersion
+				}
+				buffer.labels["version"] = version
+			}
+			if cc.ownedBy {
+				buffer.labels["owned_by"] = getOwnedBy(&cd)
+			}
+			if cc.includePaused {
+				buffer.labels["paused"] = strconv.FormatBool(paused)
+			}
+			// For installing clusters we report the seconds since the cluster was created.
+			ch <- buffer.mustNewConstMetric(prometheus.GaugeValue, elapsedDuration.Seconds())
+
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+
+}
+
+// collectPostInstallDegraded reports the installed cluster cd if one of its post-install conditions is in an
+// undesired state, with the seconds since that condition last changed. paused is whether cd's reconciles are paused.
+func (cc provisioningUnderwayCollector) collectPostInstallDegraded(ch chan<- prometheus.Metric, buffer *constMetricBuffer, cd *hivev1.ClusterDeployment, paused bool) {
+	for _, degradedCondition := range postInstallDegradedCondition {
+		cdCondition := controllerutils.FindCondition(cd.Status.Conditions, degradedCondition)
+		if cdCondition == nil || cdCondition.Status == corev1.ConditionUnknown ||
+			controllerutils.IsConditionInDesiredState(*cdCondition) {
+			continue
+		}
+		reason := cdCondition.Reason
+		if reason == "" {
+			reason = "Unknown"
+		}
+		buffer.labels["cluster_deployment"] = cd.Name
+		buffer.labels["cluster_type"] = cc.clusterTypeLabel.value(cd)
+		buffer.labels["condition"] = string(degradedCondition)
+		buffer.labels["namespace"] = cd.Namespace
+		buffer.labels["reason"] = cc.reasons.labelValue(reason)
+		if cc.includePaused {
+			buffer.labels["paused"] = strconv.FormatBool(paused)
+		}
+		ch <- buffer.mustNewConstMetric(prometheus.GaugeValue, cc.clock.Since(cdCondition.LastTransitionTime.Time).Seconds())
+		return
+	}
+}
+
+func (cc provisioningUnderwayCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentProvisionUnderwaySeconds.Desc
+	ch <- cc.metricClusterDeploymentPostInstallDegradedSeconds.Desc
+}
+
+// MinDuration returns how long a cluster must have been provisioning to be reported, unless its condition has an
+// override.
+func (cc provisioningUnderwayCollector) MinDuration() time.Duration {
+	return cc.minDuration.get()
+}
+
+// SetMinDuration changes how long a cluster must have been provisioning to be reported, unless its condition has an
+// override, from the next scrape on. It is safe to call while the collector is being scraped.
+func (cc provisioningUnderwayCollector) SetMinDuration(d time.Duration) {
+	cc.minDuration.set(d)
+}
+
+var (
+	metricClusterDeploymentProvisionUnderwaySecondsDesc = newConstMetricDesc(
+		"hive_cluster_deployment_provision_underway_seconds",
+		"Length of time a cluster has been provisioning.",
+		"cluster_deployment", "cluster_type", "condition", "image_set", "namespace", "platform", "reason",
+	)
+	metricClusterDeploymentPostInstallDegradedSecondsDesc = newConstMetricDesc(
+		"hive_cluster_deployment_post_install_degraded_seconds",
+		"Length of time an installed cluster has had a post-install condition in an undesired state.",
+		"cluster_deployment", "cluster_type", "condition", "namespace", "reason",
+	)
+)
+
+// provisioningUnderwayOptions configures the collector returned by newProvisioningUnderwaySecondsCollector.
+type provisioningUnderwayOptions struct {
+	// minimum is how long a cluster must have been provisioning, that is, its age, to be reported.
+	minimum time.Duration
+	// minimumByCondition overrides minimum for clusters whose reported condition is of the given type.
+	minimumByCondition map[hivev1.ClusterDeploymentConditionType]time.Duration
+	// excludedNamespaces are namespace patterns whose ClusterDeployments are not reported.
+	excludedNamespaces []string
+	// includeClusterTypes, when not empty, limits the clusters reported to those whose cluster_type is one of these.
+	includeClusterTypes []string
+	// clusterTypeLabelKey is the label whose value is reported as cluster_type, hive.openshift.io/cluster-type if empty.
+	clusterTypeLabelKey string
+	// additionalReasons are the condition reasons reported, along with knownConditionReasons. Others are reported as
+	// Other.
+	additionalReasons []string
+	// ownedBy adds an owned_by label, as returned by getOwnedBy.
+	ownedBy bool
+	// version adds a version label, the install version of each cluster, or empty until it is known.
+	version bool
+	// provisionKind adds a provision_kind label, as returned by getProvisionKind.
+	provisionKind bool
+	// quotaDetail adds a quota_detail label, as returned by getQuotaDetail.
+	quotaDetail bool
+	// postInstallDegraded also reports installed clusters with a condition of postInstallDegradedCondition in an
+	// undesired state, through hive_cluster_deployment_post_install_degraded_seconds.
+	postInstallDegraded bool
+	// includePaused reports clusters whose reconciles are paused rather than skipping them, with a paused label on
+	// both metrics telling them apart.
+	includePaused bool
+}
+
+// newProvisioningUnderwaySecondsCollector returns a collector reporting the clusters provisioning for at least the
+// minimum of opts.
+func newProvisioningUnderwaySecondsCollector(client client.Client, opts provisioningUnderwayOptions) prometheus.Collector {
+	desc := metricClusterDeploymentProvisionUnderwaySecondsDesc
+	if opts.ownedBy {
+		desc = desc.withLabels("owned_by")
+	}
+	if opts.version {
+		desc = desc.withLabels("version")
+	}
+	if opts.provisionKind {
+		desc = desc.withLabels("provision_kind")
+	}
+	if opts.quotaDetail {
+		desc = desc.withLabels("quota_detail")
+	}
+	degradedDesc := metricClusterDeploymentPostInstallDegradedSecondsDesc
+	if opts.includePaused {
+		desc = desc.withLabels("paused")
+		degradedDesc = degradedDesc.withLabels("paused")
+	}
+	return provisioningUnderwayCollector{
+		client: client,
+		clock:  clock.RealClock{},
+		metricClusterDeploymentProvisionUnderwaySeconds: desc,
+		minDuration:            newLiveSetting(opts.minimum),
+		minDurationByCondition: opts.minimumByCondition,
+		excludedNamespaces:     newNamespaceFilter(opts.excludedNamespaces),
+		includedClusterTypes:   clusterTypeFilter(opts.includeClusterTypes),
+		clusterTypeLabel:       newClusterTypeLabel(opts.clusterTypeLabelKey),
+		reasons:                newReasonFilter(opts.additionalReasons),
+		ownedBy:                opts.ownedBy,
+		version:                opts.version,
+		provisionKind:          opts.provisionKind,
+		quotaDetail:            opts.quotaDetail,
+		includePaused:          opts.includePaused,
+		postInstallDegraded:    opts.postInstallDegraded,
+		metricClusterDeploymentPostInstallDegradedSeconds: degradedDesc,
+	}
+}
+
+const (
+	// provisionKindInitial is the provision_kind of clusters being installed for the first time.
+	provisionKindInitial = "initial"
+	// provisionKindReprovision is the provision_kind of clusters whose install has been restarted after failing.
+	provisionKindReprovision = "reprovision"
+	// provisionKindAdopted is the provision_kind of clusters whose metadata was supplied rather than produced by
+	// an install.
+	provisionKindAdopted = "adopted"
+)
+
+// getProvisionKind returns the provision_kind of the uninstalled cluster cd, derived from cd alone:
+//   - adopted, if cd carries cluster metadata but has neither a ClusterProvision nor a cluster install reference,
+//     so the metadata came from whoever created it rather than from an install;
+//   - reprovision, if the install has been restarted at least once;
+//   - initial, otherwise.
+func getProvisionKind(cd *hivev1.ClusterDeployment) string {
+	switch {
+	case cd.Spec.ClusterMetadata != nil && cd.Status.ProvisionRef == nil && cd.Spec.ClusterInstallRef == nil:
+		return provisionKindAdopted
+	case cd.Status.InstallRestarts > 0:
+		return provisionKindReprovision
+	default:
+		return provisionKindInitial
+	}
+}
+
+// quotaDetailUnknown is the quota_detail of clusters failing on a quota whose condition message doesn't say which.
+const quotaDetailUnknown = "unknown"
+
+var (
+	// quotaCheckRegex matches the installer's quota check failures, on AWS and GCP alike, capturing the quota and
+	// the region, as in "ec2/L-1216C47A is not available in us-east-1 because ...".
+	quotaCheckRegex = regexp.MustCompile(`([\w.-]+(?:/[\w.-]+)+) is not available in ([\w-]+) because`)
+	// gcpQuotaExceededRegex matches GCP quota errors, capturing the metric and the region, as in
+	// "Quota 'CPUS' exceeded.  Limit: 24.0 in region us-central1.".
+	gcpQuotaExceededRegex = regexp.MustCompile(`Quota '(\w+)' exceeded\.\s+Limit: [\d.]+ (?:in region ([\w-]+)|globally)`)
+	// awsLimitExceededRegex matches AWS API errors for exceeded account limits, capturing the error code, as in
+	// "VcpuLimitExceeded: You have requested more vCPU capacity than your current vCPU limit".
+	awsLimitExceededRegex = regexp.MustCompile(`\b(\w+LimitExceeded)\b`)
+)
+
+// getQuotaDetail returns the quota, and the region when known, that the condition of type condition in conditions
+// reports as exhausted, as quota/region. It returns "" when reason is not about quotas, to keep the series of other
+// failures unchanged, and quotaDetailUnknown when the condition message does not match a known quota error.
+func getQuotaDetail(conditions []hivev1.ClusterDeploymentCondition, condition, reason string) string {
+	if !strings.Contains(reason, "Quota") {
+		return ""
+	}
+	cond := controllerutils.FindCondition(conditions, hivev1.ClusterDeploymentConditionType(condition))
+	if cond == nil {
+		return quotaDetailUnknown
+	}
+	if match := quotaCheckRegex.FindStringSubmatch(cond.Message); match != nil {
+		return match[1] + "/" + match[2]
+	}
+	if match := gcpQuotaExceededRegex.FindStringSubmatch(cond.Message); match != nil {
+		region := match[2]
+		if region == "" {
+			region = "global"
+		}
+		return match[1] + "/" + region
+	}
+	if match := awsLimitExceededRegex.FindStringSubmatch(cond.Message); match != nil {
+		return match[1]
+	}
+	return quotaDetailUnknown
+}
+
+// getOwnedBy returns the owner of cd as kind/name, preferring its controller owner over its first owner reference,
+// or "none" when cd has no owners. Only the owner references on cd are consulted.
+func getOwnedBy(cd *hivev1.ClusterDeployment) string {
+	if len(cd.OwnerReferences) == 0 {
+		return "none"
+	}
+	owner := metav1.GetControllerOfNoCopy(cd)
+	if owner == nil {
+		owner = &cd.OwnerReferences[0]
+	}
+	return owner.Kind + "/" + owner.Name
+}
+
+// provisioning underway install restarts metrics collected through a custom prometheus collector
+type provisioningUnderwayInstallRestartsCollector struct {
+	client client.Client
+
+	// minRestarts, when non-zero, is the minimum restarts after which clusters provisioning
+	// will start becoming part of the metric. Clusters with exactly minRestarts restarts are
+	// included. When set to zero, all clusters provisioning that have restarted at least once
+	// will be included in the metric. It can be changed with SetMinRestarts while the collector is registered.
+	minRestarts *liveSetting[int]
+
+	// excludedNamespaces filters out objects in namespaces that should not be reported.
+	excludedNamespaces namespaceFilter
+
+	// includedClusterTypes limits the ClusterDeployments reported to those of the given cluster types.
+	includedClusterTypes clusterTypeFilter
+
+	// clusterTypeLabel is the label whose value is reported as cluster_type.
+	clusterTypeLabel clusterTypeLabel
+
+	// reasons collapses unknown condition reasons in the reason label.
+	reasons reasonFilter
+
+	// metricClusterDeploymentProvisionUnderwayInstallRestarts is a prometheus metric for the number of install
+	// restarts for a still provisioning cluster.
+	metricClusterDeploymentProvisionUnderwayInstallRestarts constMetricDesc
+
+	// emitHistogram, when true, additionally reports the install restarts of every provisioning cluster, regardless
+	// of minRestarts, through metricClusterDeploymentInstallRestarts.
+	emitHistogram bool
+
+	// metricClusterDeploymentInstallRestarts is a prometheus histogram of the number of install restarts across
+	// all still provisioning clusters.
+	metricClusterDeploymentInstallRestarts constMetricDesc
+}
+
+// Collect collects the metrics for provisioningUnderwayInstallRestartsCollector
+func (cc provisioningUnderwayInstallRestartsCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "provisioning underway install restarts metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	// Load all ClusterDeployments so we can accumulate facts about them.
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	var histogram *installRestartsHistogram
+	if cc.emitHistogram {
+		histogram = newInstallRestartsHistogram()
+	}
+	buffer := cc.metricClusterDeploymentProvisionUnderwayInstallRestarts.newBuffer()
+	minRestarts := cc.minRestarts.get()
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			if cd.Spec.Installed {
+				continue
+			}
+			clusterType := cc.clusterTypeLabel.value(&cd)
+			if cc.excludedNamespaces.excludes(cd.Namespace) || !cc.includedClusterTypes.includes(clusterType) {
+				continue
+			}
+
+			platform := cd.Labels[hivev1.HiveClusterPlatformLabel]
+			imageSet := "none"
+			if cd.Spec.Provisioning != nil && cd.Spec.Provisioning.ImageSetRef != nil {
+				imageSet = cd.Spec.Provisioning.ImageSetRef.Name
+			}
+
+			restarts := cd.Status.InstallRestarts
+			if histogram != nil {
+				histogram.observe(restarts)
+			}
+			if restarts == 0 {
+				continue // skip reporting the metric for clusterdeployment that hasn't restarted at all
+			}
+			if minRestarts > 0 && restarts < minRestarts {
+				continue // skip reporting the metric for clusterdeployment until the InstallRestarts is at least minRestarts
+			}
+
+			// Add install failure details for stuck provision
+			condition, reason, skip := getConditionAndReason(cd.Status.Conditions)
+			if skip {
+				continue
+			}
+
+			buffer.labels["cluster_deployment"] = cd.Name
+			buffer.labels["cluster_type"] = clusterType
+			buffer.labels["condition"] = condition
+			buffer.labels["image_set"] = imageSet
+			buffer.labels["namespace"] = cd.Namespace
+			buffer.labels["platform"] = platform
+			buffer.labels["reason"] = cc.reasons.labelValue(reason)
+			// For installing clusters we report the seconds since the cluster was created.
+			ch <- buffer.mustNewConstMetric(prometheus.GaugeValue, float64(restarts))
+
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+
+	if histogram != nil {
+		ch <- prometheus.MustNewConstHistogram(
+			cc.metricClusterDeploymentInstallRestarts.Desc,
+			histogram.count,
+			histogram.sum,
+			histogram.buckets,
+		)
+	}
+}
+
+func (cc provisioningUnderwayInstallRestartsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentProvisionUnderwayInstallRestarts.Desc
+	ch <- cc.metricClusterDeploymentInstallRestarts.Desc
+}
+
+// MinRestarts returns the install restarts a provisioning cluster must have reached to be reported.
+func (cc provisioningUnderwayInstallRestartsCollector) MinRestarts() int {
+	return cc.minRestarts.get()
+}
+
+// SetMinRestarts changes the install restarts a provisioning cluster must have reached to be reported, from the next
+// scrape on. It is safe to call while the collector is being scraped.
+func (cc provisioningUnderwayInstallRestartsCollector) SetMinRestarts(n int) {
+	cc.minRestarts.set(n)
+}
+
+// installRestartsBuckets are the upper bounds of the buckets of the install restarts histogram.
+var installRestartsBuckets = []float64{0, 1, 2, 4, 8, 16}
+
+// installRestartsHistogram accumulates the install restarts observed across clusters for a constant histogram.
+type installRestartsHistogram struct {
+	count   uint64
+	sum     float64
+	buckets map[float64]uint64
+}
+
+func newInstallRestartsHistogram() *installRestartsHistogram {
+	h := &installRestartsHistogram{buckets: make(map[float64]uint64, len(installRestartsBuckets))}
+	for _, upperBound := range installRestartsBuckets {
+		h.buckets[upperBound] = 0
+	}
+	return h
+}
+
+// observe adds restarts to the histogram, counting it in every bucket whose upper bound it doesn't exceed.
+func (h *installRestartsHistogram) observe(restarts int) {
+	h.count++
+	h.sum += float64(restarts)
+	for _, upperBound := range installRestartsBuckets {
+		if float64(restarts) <= upperBound {
+			h.buckets[upperBound]++
+		}
+	}
+}
+
+var (
+	provisioningUnderwayInstallRestartsCollectorDesc = newConstMetricDesc(
+		"hive_cluster_deployment_provision_underway_install_restarts",
+		"Number install restarts for a cluster that has been provisioning.",
+		"cluster_deployment", "cluster_type", "condition", "image_set", "namespace", "platform", "reason",
+	)
+	metricClusterDeploymentInstallRestartsDesc = newConstMetricDesc(
+		"hive_cluster_deployment_install_restarts",
+		"Distribution of install restarts across clusters that are provisioning.",
+	)
+)
+
+// newProvisioningUnderwayInstallRestartsCollector returns a collector reporting clusters provisioning with at least
+// minimum install restarts, that is, those whose restarts are >= minimum, as newProvisioningUnderwaySecondsCollector
+// compares ages. Clusters that have not restarted are never reported. When emitHistogram is true, it also reports the
+// distribution of install restarts across all provisioning clusters. ClusterDeployments are filtered and condition
+// reasons collapsed as for newProvisioningUnderwaySecondsCollector.
+func newProvisioningUnderwayInstallRestartsCollector(client client.Client, minimum int, excludedNamespaces []string, includeClusterTypes []string, clusterTypeLabelKey string, emitHistogram bool, additionalReasons []string) prometheus.Collector {
+	return provisioningUnderwayInstallRestartsCollector{
+		client: client,
+		metricClusterDeploymentProvisionUnderwayInstallRestarts: provisioningUnderwayInstallRestartsCollectorDesc,
+		minRestarts:                            newLiveSetting(minimum),
+		excludedNamespaces:                     newNamespaceFilter(excludedNamespaces),
+		includedClusterTypes:                   clusterTypeFilter(includeClusterTypes),
+		clusterTypeLabel:                       newClusterTypeLabel(clusterTypeLabelKey),
+		reasons:                                newReasonFilter(additionalReasons),
+		emitHistogram:                          emitHistogram,
+		metricClusterDeploymentInstallRestarts: metricClusterDeploymentInstallRestartsDesc,
+	}
+}
+
+// getConditionAndReason fetches a condition and reason if some condition is found in undesired state.
+// All other conditions apart from provisionDelayConditions are tagged as unknown for metric reporting purposes
+// Reporting of the metric is to be skipped when all conditions are in their desired state
+func getConditionAndReason(conditions []hivev1.ClusterDeploymentCondition) (condition, reason string, skip bool) {
+	condition, reason = "Unknown", "Unknown"
+	for _, delayCondition := range provisioningDelayCondition {
+		if cdCondition := controllerutils.FindCondition(conditions,
+			delayCondition); cdCondition != nil {
+			if cdCondition.Status != corev1.ConditionUnknown &&
+				!controllerutils.IsConditionInDesiredState(*cdCondition) {
+				condition = string(delayCondition)
+				if cdCondition.Reason != "" {
+					reason = cdCondition.Reason
+				}
+				break
+			}
+		}
+	}
+	// skip reporting if all conditions are in their desired state
+	if condition == "Unknown" && len(conditions) > 0 {
+		if controllerutils.AreAllConditionsInDesiredState(conditions) {
+			skip = true
+		}
+	}
+	return condition, reason, skip
+}
+
+// installer version mismatch metric collected through a custom prometheus collector
+type installerVersionMismatchCollector struct {
+	client client.Client
+
+	// metricClusterDeploymentInstallerVersionMismatch is a prometheus metric reporting still provisioning
+	// ClusterDeployments whose installer image is of a different version than their release image.
+	metricClusterDeploymentInstallerVersionMismatch constMetricDesc
+}
+
+// Collect collects the metrics for installerVersionMismatchCollector
+func (cc installerVersionMismatchCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "installer version mismatch metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			if cd.Spec.Installed {
+				continue
+			}
+			if !hasInstallerVersionMismatch(&cd) {
+				continue
+			}
+			ch <- cc.metricClusterDeploymentInstallerVersionMismatch.mustNewConstMetric(
+				prometheus.GaugeValue,
+				1,
+				prometheus.Labels{
+					"cluster_deployment": cd.Name,
+					"namespace":          cd.Namespace,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+func (cc installerVersionMismatchCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentInstallerVersionMismatch.Desc
+}
+
+var (
+	metricClusterDeploymentInstallerVersionMismatchDesc = newConstMetricDesc(
+		"hive_cluster_deployment_installer_version_mismatch",
+		"Whether a provisioning cluster uses an overridden installer image whose version does not match its release image.",
+		"cluster_deployment", "namespace",
+	)
+)
+
+func newInstallerVersionMismatchCollector(client client.Client) prometheus.Collector {
+	return installerVersionMismatchCollector{
+		client: client,
+		metricClusterDeploymentInstallerVersionMismatch: metricClusterDeploymentInstallerVersionMismatchDesc,
+	}
+}
+
+// hasInstallerVersionMismatch returns true if the ClusterDeployment is provisioned with an overridden installer image
+// whose version differs from that of its release image. Hive otherwise always resolves the installer image from the
+// release payload, so the two can only diverge when the installer image is overridden. The version of an overridden
+// installer image is only known from its tag, so overrides referenced by digest or by a tag that is not a version are
+// not reported.
+func hasInstallerVersionMismatch(cd *hivev1.ClusterDeployment) bool {
+	if cd.Spec.Provisioning == nil || cd.Spec.Provisioning.InstallerImageOverride == "" {
+		return false
+	}
+	// The installer image has not been resolved yet, so the override has not been used.
+	if cd.Status.InstallerImage == nil || *cd.Status.InstallerImage != cd.Spec.Provisioning.InstallerImageOverride {
+		return false
+	}
+	if cd.Status.InstallVersion == nil {
+		return false
+	}
+	releaseVersion, err := semver.ParseTolerant(*cd.Status.InstallVersion)
+	if err != nil {
+		return false
+	}
+	installerVersion, ok := imageTagVersion(cd.Spec.Provisioning.InstallerImageOverride)
+	if !ok {
+		return false
+	}
+	return installerVersion.Major != releaseVersion.Major ||
+		installerVersion.Minor != releaseVersion.Minor ||
+		installerVersion.Patch != releaseVersion.Patch
+}
+
+// imageTagVersion returns the version in the tag of image, such as 4.14.0 for
+// quay.io/openshift-release-dev/ocp-release:4.14.0-x86_64, or false if image is referenced by digest or its tag is not
+// a version.
+func imageTagVersion(image string) (semver.Version, bool) {
+	if strings.Contains(image, "@") {
+		return semver.Version{}, false
+	}
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return semver.Version{}, false
+	}
+	version, err := semver.ParseTolerant(image[i+1:])
+	if err != nil {
+		return semver.Version{}, false
+	}
+	return version, true
+}
+
+// additional manifest count metrics collected through a custom prometheus collector
+type additionalManifestCountCollector struct {
+	client client.Client
+
+	// minManifests, when non-zero, is the number of additional manifests a cluster provisioning must
+	// exceed before it becomes part of the metric. When set to zero, all clusters provisioning with
+	// additional manifests will be included in the metric.
+	minManifests int
+
+	// metricClusterDeploymentAdditionalManifestCount is a prometheus metric for the number of additional
+	// manifests supplied for a still provisioning cluster.
+	metricClusterDeploymentAdditionalManifestCount constMetricDesc
+}
+
+// Collect collects the metrics for additionalManifestCountCollector
+func (cc additionalManifestCountCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "additional manifest count metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			// Additional manifests are only consumed by the installer
+			if cd.Spec.Installed {
+				continue
+			}
+			if cd.Spec.Provisioning == nil || cd.Spec.Provisioning.ManifestsConfigMapRef == nil {
+				continue
+			}
+
+			cm := &corev1.ConfigMap{}
+			cmName := types.NamespacedName{Namespace: cd.Namespace, Name: cd.Spec.Provisioning.ManifestsConfigMapRef.Name}
+			if err := cc.client.Get(scrape.ctx, cmName, cm); err != nil {
+				if scrape.readFailed() {
+					return
+				}
+				scrape.log.WithError(err).WithField("configMap", cmName).Warn("error getting additional manifests configmap")
+				continue
+			}
+
+			count := len(cm.Data) + len(cm.BinaryData)
+			if count <= cc.minManifests {
+				continue // skip reporting the metric for clusterdeployment until it has more than minManifests manifests
+			}
+
+			ch <- cc.metricClusterDeploymentAdditionalManifestCount.mustNewConstMetric(
+				prometheus.GaugeValue,
+				float64(count),
+				prometheus.Labels{
+					"cluster_deployment": cd.Name,
+					"namespace":          cd.Namespace,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+func (cc additionalManifestCountCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentAdditionalManifestCount.Desc
+}
+
+var (
+	metricClusterDeploymentAdditionalManifestCountDesc = newConstMetricDesc(
+		"hive_cluster_deployment_additional_manifest_count",
+		"Number of additional manifests supplied for a cluster that is provisioning.",
+		"cluster_deployment", "namespace",
+	)
+)
+
+func newAdditionalManifestCountCollector(client client.Client, minimum int) prometheus.Collector {
+	return additionalManifestCountCollector{
+		client:       client,
+		minManifests: minimum,
+		metricClusterDeploymentAdditionalManifestCount: metricClusterDeploymentAdditionalManifestCountDesc,
+	}
+}
+
+const (
+	// installTypeIPI is used for clusters provisioned by hive running the installer.
+	installTypeIPI = "ipi"
+	// installTypeAssisted is used for clusters installed through an AgentClusterInstall.
+	installTypeAssisted = "assisted"
+	// installTypeUPI is used for clusters installed through any other ClusterInstall implementation.
+	installTypeUPI = "upi"
+	// installTypeAdopted is used for clusters that were installed outside of hive and adopted.
+	installTypeAdopted = "adopted"
+)
+
+// cluster deployments by install type metrics collected through a custom prometheus collector
+type installTypeCollector struct {
+	client client.Client
+
+	// metricClusterDeploymentsByInstallType is a prometheus metric for the number of ClusterDeployments
+	// for each install type.
+	metricClusterDeploymentsByInstallType constMetricDesc
+}
+
+// Collect collects the metrics for installTypeCollector
+func (cc installTypeCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "install type metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	counts := map[string]int{}
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			counts[getInstallType(&cd)]++
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+	for _, installType := range []string{installTypeIPI, installTypeUPI, installTypeAssisted, installTypeAdopted} {
+		ch <- cc.metricClusterDeploymentsByInstallType.mustNewConstMetric(
+			prometheus.GaugeValue,
+			float64(counts[installType]),
+			prometheus.Labels{
+				"type": installType,
+			},
+		)
+	}
+}
+
+func (cc installTypeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentsByInstallType.Desc
+}
+
+var (
+	metricClusterDeploymentsByInstallTypeDesc = newConstMetricDesc(
+		"hive_cluster_deployments_by_install_type",
+		"Total number of cluster deployments by install type.",
+		"type",
+	)
+)
+
+func newInstallTypeCollector(client client.Client) prometheus.Collector {
+	return installTypeCollector{
+		client:                                client,
+		metricClusterDeploymentsByInstallType: metricClusterDeploymentsByInstallTypeDesc,
+	}
+}
+
+// getInstallType determines how the ClusterDeployment is (or was) installed from the shape of its spec.
+func getInstallType(cd *hivev1.ClusterDeployment) string {
+	switch {
+	case cd.Spec.Provisioning != nil:
+		return installTypeIPI
+	case cd.Spec.ClusterInstallRef != nil && cd.Spec.ClusterInstallRef.Kind == "AgentClusterInstall":
+		return installTypeAssisted
+	case cd.Spec.ClusterInstallRef != nil:
+		return installTypeUPI
+	default:
+		return installTypeAdopted
+	}
+}
+
+// provisioning SLO breach metrics collected through a custom prometheus collector
+type provisioningSLOBreachedCollector struct {
+	client client.Client
+
+	// clock is the source of the current time.
+	clock clock.PassiveClock
+
+	// slos are the named provisioning limits ClusterDeployments are checked against.
+	slos []metricsconfig.ProvisioningSLO
+
+	// clusterTypeLabel is the label whose value is matched against the cluster type of each SLO.
+	clusterTypeLabel clusterTypeLabel
+
+	// metricClusterDeploymentSLOBreached is a prometheus metric reporting still provisioning ClusterDeployments
+	// that have been provisioning for longer than a configured SLO allows.
+	metricClusterDeploymentSLOBreached constMetricDesc
+}
+
+// Collect collects the metrics for provisioningSLOBreachedCollector
+func (cc provisioningSLOBreachedCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "provisioning SLO breach metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			if cd.Spec.Installed {
+				continue
+			}
+			clusterType := cc.clusterTypeLabel.value(&cd)
+			elapsedDuration := cc.clock.Since(cd.CreationTimestamp.Time)
+			for _, slo := range cc.slos {
+				if slo.ClusterType != "" && slo.ClusterType != clusterType {
+					continue
+				}
+				if elapsedDuration < slo.Duration.Duration {
+					continue
+				}
+				ch <- cc.metricClusterDeploymentSLOBreached.mustNewConstMetric(
+					prometheus.GaugeValue,
+					1,
+					prometheus.Labels{
+						"cluster_deployment": cd.Name,
+						"namespace":          cd.Namespace,
+						"slo":                slo.Name,
+					},
+				)
+			}
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+func (cc provisioningSLOBreachedCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentSLOBreached.Desc
+}
+
+var (
+	metricClusterDeploymentSLOBreachedDesc = newConstMetricDesc(
+		"hive_cluster_deployment_slo_breached",
+		"Whether a cluster has been provisioning for longer than the named SLO allows.",
+		"cluster_deployment", "namespace", "slo",
+	)
+)
+
+func newProvisioningSLOBreachedCollector(client client.Client, slos []metricsconfig.ProvisioningSLO, clusterTypeLabelKey string) prometheus.Collector {
+	return provisioningSLOBreachedCollector{
+		client:                             client,
+		clock:                              clock.RealClock{},
+		slos:                               slos,
+		clusterTypeLabel:                   newClusterTypeLabel(clusterTypeLabelKey),
+		metricClusterDeploymentSLOBreached: metricClusterDeploymentSLOBreachedDesc,
+	}
+}
+
+// knownInstallErrorCollector reports ClusterDeployments whose latest install log matches one of the
+// known error signatures configured in the install log regex ConfigMaps.
+type knownInstallErrorCollector struct {
+	client client.Client
+
+	// metricClusterDeploymentKnownInstallError is a prometheus metric reporting the known error
+	// signatures found in the install log of still provisioning ClusterDeployments.
+	metricClusterDeploymentKnownInstallError constMetricDesc
+}
+
+// installLogSignature is the subset of an install log regex entry needed to match known errors.
+type installLogSignature struct {
+	Name               string   `json:"name"`
+	SearchRegexStrings []string `json:"searchRegexStrings"`
+}
+
+// Collect collects the metrics for knownInstallErrorCollector
+func (cc knownInstallErrorCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "known install error metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	signatures := cc.loadSignatures(scrape)
+	if len(signatures) == 0 {
+		return
+	}
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			if cd.Spec.Installed {
+				continue
+			}
+			if cd.Status.ProvisionRef == nil {
+				continue
+			}
+			provision := &hivev1.ClusterProvision{}
+			if err := cc.client.Get(scrape.ctx, types.NamespacedName{Namespace: cd.Namespace, Name: cd.Status.ProvisionRef.Name}, provision); err != nil {
+				if scrape.readFailed() {
+					return
+				}
+				scrape.log.WithError(err).WithField("clusterProvision", cd.Status.ProvisionRef.Name).Warn("error getting cluster provision")
+				continue
+			}
+			if provision.Spec.InstallLog == nil {
+				continue
+			}
+			installLog := []byte(*provision.Spec.InstallLog)
+			for _, signature := range signatures {
+				for _, re := range signature.regexes {
+					if !re.Match(installLog) {
+						continue
+					}
+					ch <- cc.metricClusterDeploymentKnownInstallError.mustNewConstMetric(
+						prometheus.GaugeValue,
+						1,
+						prometheus.Labels{
+							"cluster_deployment": cd.Name,
+							"namespace":          cd.Namespace,
+							"signature":          signature.name,
+						},
+					)
+					break
+				}
+			}
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+type compiledInstallLogSignature struct {
+	name    string
+	regexes []*regexp.Regexp
+}
+
+// loadSignatures reads and compiles the known error signatures from the install log regex ConfigMaps
+// in the hive namespace. Entries that cannot be read or compiled are logged and skipped.
+func (cc knownInstallErrorCollector) loadSignatures(scrape *collectScrape) []compiledInstallLogSignature {
+	var compiled []compiledInstallLogSignature
+	for _, cmName := range []string{installLogRegexConfigMapName, additionalInstallLogRegexConfigMapName} {
+		cm := &corev1.ConfigMap{}
+		switch err := cc.client.Get(scrape.ctx, types.NamespacedName{Namespace: controllerutils.GetHiveNamespace(), Name: cmName}, cm); {
+		case apierrors.IsNotFound(err):
+			scrape.log.WithField("configMap", cmName).Debug("install log regex configmap not found")
+			continue
+		case err != nil:
+			if scrape.readFailed() {
+				return nil
+			}
+			scrape.log.WithError(err).WithField("configMap", cmName).Warn("error getting install log regex configmap")
+			continue
+		}
+		raw := cm.Data[installLogRegexDataEntryName]
+		if raw == "" {
+			continue
+		}
+		signatures := []installLogSignature{}
+		if err := yaml.Unmarshal([]byte(raw), &signatures); err != nil {
+			scrape.log.WithError(err).WithField("configMap", cmName).Error("cannot unmarshal install log regexes")
+			continue
+		}
+		for _, signature := range signatures {
+			c := compiledInstallLogSignature{name: signature.Name}
+			for _, ss := range signature.SearchRegexStrings {
+				// Match case insensitively, as the clusterprovision controller does.
+				re, err := regexp.Compile("(?i)" + ss)
+				if err != nil {
+					scrape.log.WithError(err).WithField("regexName", signature.Name).Error("unable to compile regex")
+					continue
+				}
+				c.regexes = append(c.regexes, re)
+			}
+			compiled = append(compiled, c)
+		}
+	}
+	return compiled
+}
+
+func (cc knownInstallErrorCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentKnownInstallError.Desc
+}
+
+const (
+	installLogRegexConfigMapName           = "install-log-regexes"
+	additionalInstallLogRegexConfigMapName = "additional-install-log-regexes"
+	installLogRegexDataEntryName           = "regexes"
+)
+
+var (
+	metricClusterDeploymentKnownInstallErrorDesc = newConstMetricDesc(
+		"hive_cluster_deployment_known_install_error",
+		"Known error signatures found in the install log of a provisioning cluster.",
+		"cluster_deployment", "namespace", "signature",
+	)
+)
+
+func newKnownInstallErrorCollector(client client.Client) prometheus.Collector {
+	return knownInstallErrorCollector{
+		client:                                   client,
+		metricClusterDeploymentKnownInstallError: metricClusterDeploymentKnownInstallErrorDesc,
+	}
+}
+
+// installResourceTimeoutCollector reports ClusterDeployments whose install was restarted after the installer timed out
+// waiting on a single cloud resource.
+type installResourceTimeoutCollector struct {
+	client client.Client
+
+	// metricClusterDeploymentInstallResourceTimeout is a prometheus metric reporting the resources the failed install
+	// of a still provisioning ClusterDeployment timed out waiting for.
+	metricClusterDeploymentInstallResourceTimeout constMetricDesc
+}
+
+// installResourceTimeoutRegex matches a terraform timeout waiting on a resource, capturing the type of the resource
+// from the location terraform reports a few lines after the error. The installer logs terraform output through
+// logrus, so the quotes around the resource type may be escaped.
+var installResourceTimeoutRegex = regexp.MustCompile(`(?i)timeout while waiting for state[^\n]*\n(?:[^\n]*\n){0,5}?[^\n]*in resource \\?"([\w-]+)\\?"`)
+
+// Collect collects the metrics for installResourceTimeoutCollector
+func (cc installResourceTimeoutCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "install resource timeout metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			if cd.Spec.Installed {
+				continue
+			}
+			if cd.Status.InstallRestarts == 0 || cd.Status.ProvisionRef == nil {
+				continue
+			}
+			provision := &hivev1.ClusterProvision{}
+			if err := cc.client.Get(scrape.ctx, types.NamespacedName{Namespace: cd.Namespace, Name: cd.Status.ProvisionRef.Name}, provision); err != nil {
+				if scrape.readFailed() {
+					return
+				}
+				scrape.log.WithError(err).WithField("clusterProvision", cd.Status.ProvisionRef.Name).Warn("error getting cluster provision")
+				continue
+			}
+			// Once the install has been restarted, the failed attempt is the previous provision.
+			if provision.Spec.Stage != hivev1.ClusterProvisionStageFailed {
+				if provision.Spec.PrevProvisionName == nil {
+					continue
+				}
+				prevName := *provision.Spec.PrevProvisionName
+				provision = &hivev1.ClusterProvision{}
+				if err := cc.client.Get(scrape.ctx, types.NamespacedName{Namespace: cd.Namespace, Name: prevName}, provision); err != nil {
+					if scrape.readFailed() {
+						return
+					}
+					scrape.log.WithError(err).WithField("clusterProvision", prevName).Warn("error getting previous cluster provision")
+					continue
+				}
+			}
+			if provision.Spec.InstallLog == nil {
+				continue
+			}
+			for _, resource := range installTimeoutResources(*provision.Spec.InstallLog) {
+				ch <- cc.metricClusterDeploymentInstallResourceTimeout.mustNewConstMetric(
+					prometheus.GaugeValue,
+					1,
+					prometheus.Labels{
+						"cluster_deployment": cd.Name,
+						"namespace":          cd.Namespace,
+						"resource":           resource,
+					},
+				)
+			}
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+// installTimeoutResources returns the distinct types of the resources installLog reports timing out, in the order
+// they are first reported.
+func installTimeoutResources(installLog string) []string {
+	var resources []string
+	seen := sets.New[string]()
+	for _, match := range installResourceTimeoutRegex.FindAllStringSubmatch(installLog, -1) {
+		if seen.Has(match[1]) {
+			continue
+		}
+		seen.Insert(match[1])
+		resources = append(resources, match[1])
+	}
+	return resources
+}
+
+func (cc installResourceTimeoutCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentInstallResourceTimeout.Desc
+}
+
+var (
+	metricClusterDeploymentInstallResourceTimeoutDesc = newConstMetricDesc(
+		"hive_cluster_deployment_install_resource_timeout",
+		"Resources the failed install of a restarted, still provisioning cluster timed out waiting for.",
+		"cluster_deployment", "namespace", "resource",
+	)
+)
+
+func newInstallResourceTimeoutCollector(client client.Client) prometheus.Collector {
+	return installResourceTimeoutCollector{
+		client: client,
+		metricClusterDeploymentInstallResourceTimeout: metricClusterDeploymentInstallResourceTimeoutDesc,
+	}
+}
+
+// excessiveProvisionsCollector reports ClusterDeployments that have had more ClusterProvisions than the configured
+// maximum, identifying clusters that keep failing to install.
+type excessiveProvisionsCollector struct {
+	client client.Client
+
+	// maximum is how many ClusterProvisions a ClusterDeployment may have before it is reported.
+	maximum int
+
+	// metricClusterDeploymentExcessiveProvisions is a prometheus metric reporting the number of ClusterProvisions of
+	// each ClusterDeployment with more than maximum.
+	metricClusterDeploymentExcessiveProvisions constMetricDesc
+}
+
+// Collect collects the metrics for excessiveProvisionsCollector
+func (cc excessiveProvisionsCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "excessive provisions metrics across all ClusterProvisions")
+	defer scrape.done()
+
+	counts := map[types.NamespacedName]int{}
+	provisions := &hivev1.ClusterProvisionList{}
+	pages := scrape.list(cc.client, provisions)
+	for pages.next() {
+		for _, provision := range provisions.Items {
+			counts[types.NamespacedName{Namespace: provision.Namespace, Name: provision.Spec.ClusterDeploymentRef.Name}]++
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+	for cd, count := range counts {
+		if count <= cc.maximum {
+			continue
+		}
+		ch <- cc.metricClusterDeploymentExcessiveProvisions.mustNewConstMetric(
+			prometheus.GaugeValue,
+			float64(count),
+			prometheus.Labels{
+				"cluster_deployment": cd.Name,
+				"namespace":          cd.Namespace,
+				"count":              strconv.Itoa(count),
+			},
+		)
+	}
+}
+
+func (cc excessiveProvisionsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentExcessiveProvisions.Desc
+}
+
+var (
+	metricClusterDeploymentExcessiveProvisionsDesc = newConstMetricDesc(
+		"hive_cluster_deployment_excessive_provisions",
+		"Number of ClusterProvisions of clusters with more than the configured maximum.",
+		"cluster_deployment", "namespace", "count",
+	)
+)
+
+// newExcessiveProvisionsCollector returns a collector reporting ClusterDeployments with more than maximum
+// ClusterProvisions.
+func newExcessiveProvisionsCollector(client client.Client, maximum int) prometheus.Collector {
+	return excessiveProvisionsCollector{
+		client:  client,
+		maximum: maximum,
+		metricClusterDeploymentExcessiveProvisions: metricClusterDeploymentExcessiveProvisionsDesc,
+	}
+}
+
+// registryAuthFailuresCollector reports ClusterDeployments whose provisions failed because the cluster could not
+// authenticate to an image registry, which usually means the pull secret is wrong or has expired.
+type registryAuthFailuresCollector struct {
+	client client.Client
+
+	// metricClusterDeploymentRegistryAuthFailures is a prometheus metric for the number of failed ClusterProvisions of
+	// each ClusterDeployment that failed authenticating to an image registry.
+	metricClusterDeploymentRegistryAuthFailures constMetricDesc
+}
+
+// registryAuthFailureRegex matches the errors image registries and the tools pulling from them report when the
+// credentials presented are missing or rejected.
+var registryAuthFailureRegex = regexp.MustCompile(`(?i)unauthorized: (authentication required|access to the requested resource is not authorized)|pull access denied|invalid username/password|401 unauthorized`)
+
+// Collect collects the metrics for registryAuthFailuresCollector
+func (cc registryAuthFailuresCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "registry authentication failure metrics across all ClusterProvisions")
+	defer scrape.done()
+
+	counts := map[types.NamespacedName]int{}
+	provisions := &hivev1.ClusterProvisionList{}
+	pages := scrape.list(cc.client, provisions)
+	for pages.next() {
+		for i := range provisions.Items {
+			if isRegistryAuthFailure(&provisions.Items[i]) {
+				counts[types.NamespacedName{Namespace: provisions.Items[i].Namespace, Name: provisions.Items[i].Spec.ClusterDeploymentRef.Name}]++
+			}
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+	for cd, count := range counts {
+		ch <- cc.metricClusterDeploymentRegistryAuthFailures.mustNewConstMetric(
+			prometheus.GaugeValue,
+			float64(count),
+			prometheus.Labels{
+				"cluster_deployment": cd.Name,
+				"namespace":          cd.Namespace,
+			},
+		)
+	}
+}
+
+// isRegistryAuthFailure returns whether provision failed authenticating to an image registry, according to its failure
+// condition or its install log.
+func isRegistryAuthFailure(provision *hivev1.ClusterProvision) bool {
+	if provision.Spec.Stage != hivev1.ClusterProvisionStageFailed {
+		return false
+	}
+	if cond := controllerutils.FindCondition(provision.Status.Conditions, hivev1.ClusterProvisionFailedCondition); cond != nil &&
+		registryAuthFailureRegex.MatchString(cond.Message) {
+		return true
+	}
+	return provision.Spec.InstallLog != nil && registryAuthFailureRegex.MatchString(*provision.Spec.InstallLog)
+}
+
+func (cc registryAuthFailuresCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentRegistryAuthFailures.Desc
+}
+
+var (
+	metricClusterDeploymentRegistryAuthFailuresDesc = newConstMetricDesc(
+		"hive_cluster_deployment_registry_auth_failures",
+		"Number of provisions of a cluster that failed authenticating to an image registry.",
+		"cluster_deployment", "namespace",
+	)
+)
+
+func newRegistryAuthFailuresCollector(client client.Client) prometheus.Collector {
+	return registryAuthFailuresCollector{
+		client: client,
+		metricClusterDeploymentRegistryAuthFailures: metricClusterDeploymentRegistryAuthFailuresDesc,
+	}
+}
+
+const (
+	// stagePending is used for clusters that have not started provisioning yet.
+	stagePending = "pending"
+	// stageProvisioning is used for clusters that are provisioning.
+	stageProvisioning = "provisioning"
+	// stageProvisionFailed is used for clusters whose provisioning has failed.
+	stageProvisionFailed = "provision_failed"
+	// stageInstalled is used for installed clusters.
+	stageInstalled = "installed"
+	// stageDeprovisioning is used for clusters that are being deleted.
+	stageDeprovisioning = "deprovisioning"
+)
+
+// cluster deployments by stage metrics collected through a custom prometheus collector
+type stageCollector struct {
+	client client.Client
+
+	// metricClusterDeploymentsByStage is a prometheus metric for the number of ClusterDeployments
+	// in each provisioning stage.
+	metricClusterDeploymentsByStage constMetricDesc
+}
+
+// Collect collects the metrics for stageCollector
+func (cc stageCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "stage metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	counts := map[string]int{}
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			counts[getStage(&cd)]++
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+	for _, stage := range []string{stagePending, stageProvisioning, stageProvisionFailed, stageInstalled, stageDeprovisioning} {
+		ch <- cc.metricClusterDeploymentsByStage.mustNewConstMetric(
+			prometheus.GaugeValue,
+			float64(counts[stage]),
+			prometheus.Labels{
+				"stage": stage,
+			},
+		)
+	}
+}
+
+func (cc stageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentsByStage.Desc
+}
+
+var (
+	metricClusterDeploymentsByStageDesc = newConstMetricDesc(
+		"hive_cluster_deployments_by_stage",
+		"Total number of cluster deployments by provisioning stage.",
+		"stage",
+	)
+)
+
+func newStageCollector(client client.Client) prometheus.Collector {
+	return stageCollector{
+		client:                          client,
+		metricClusterDeploymentsByStage: metricClusterDeploymentsByStageDesc,
+	}
+}
+
+// getStage classifies the ClusterDeployment into a provisioning stage. Provision failures are detected the same way
+// the provisioning underway collector picks the condition it reports.
+func getStage(cd *hivev1.ClusterDeployment) string {
+	switch {
+	case cd.DeletionTimestamp != nil:
+		return stageDeprovisioning
+	case cd.Spec.Installed:
+		return stageInstalled
+	}
+	if condition, _, _ := getConditionAndReason(cd.Status.Conditions); condition == string(hivev1.ProvisionFailedCondition) {
+		return stageProvisionFailed
+	}
+	if cond := controllerutils.FindCondition(cd.Status.Conditions, hivev1.ProvisionedCondition); cond != nil && cond.Reason == hivev1.ProvisionedReasonProvisioning {
+		return stageProvisioning
+	}
+	return stagePending
+}
+
+// never reconciled metric collected through a custom prometheus collector
+type neverReconciledCollector struct {
+	client client.Client
+
+	// metricClusterDeploymentNeverReconciled is a prometheus metric reporting ClusterDeployments the clusterdeployment
+	// controller has never reconciled.
+	metricClusterDeploymentNeverReconciled constMetricDesc
+}
+
+// Collect collects the metrics for neverReconciledCollector
+func (cc neverReconciledCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "never reconciled metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil {
+				continue
+			}
+			// The clusterdeployment controller initializes the conditions on its first successful reconcile, so a
+			// ClusterDeployment without any has never got that far.
+			if len(cd.Status.Conditions) > 0 {
+				continue
+			}
+			ch <- cc.metricClusterDeploymentNeverReconciled.mustNewConstMetric(
+				prometheus.GaugeValue,
+				1,
+				prometheus.Labels{
+					"cluster_deployment": cd.Name,
+					"namespace":          cd.Namespace,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+func (cc neverReconciledCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentNeverReconciled.Desc
+}
+
+var (
+	metricClusterDeploymentNeverReconciledDesc = newConstMetricDesc(
+		"hive_cluster_deployment_never_reconciled",
+		"Whether a ClusterDeployment has never been successfully reconciled.",
+		"cluster_deployment", "namespace",
+	)
+)
+
+// newNeverReconciledCollector returns a collector reporting ClusterDeployments whose status has never been initialized
+// by the clusterdeployment controller.
+func newNeverReconciledCollector(client client.Client) prometheus.Collector {
+	return neverReconciledCollector{
+		client:                                 client,
+		metricClusterDeploymentNeverReconciled: metricClusterDeploymentNeverReconciledDesc,
+	}
+}
+
+// ready condition missing metric collected through a custom prometheus collector
+type readyConditionMissingCollector struct {
+	client client.Client
+
+	// metricClusterDeploymentReadyConditionMissing is a prometheus metric reporting installed ClusterDeployments that
+	// have never reported whether they are ready.
+	metricClusterDeploymentReadyConditionMissing constMetricDesc
+}
+
+// Collect collects the metrics for readyConditionMissingCollector
+func (cc readyConditionMissingCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "ready condition missing metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil || !cd.Spec.Installed {
+				continue
+			}
+			// The clusterdeployment controller initializes the Ready condition as Unknown, so one still Unknown has
+			// never been set.
+			if cond := controllerutils.FindCondition(cd.Status.Conditions, hivev1.ClusterReadyCondition); cond != nil && cond.Status != corev1.ConditionUnknown {
+				continue
+			}
+			ch <- cc.metricClusterDeploymentReadyConditionMissing.mustNewConstMetric(
+				prometheus.GaugeValue,
+				1,
+				prometheus.Labels{
+					"cluster_deployment": cd.Name,
+					"namespace":          cd.Namespace,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+func (cc readyConditionMissingCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentReadyConditionMissing.Desc
+}
+
+var (
+	metricClusterDeploymentReadyConditionMissingDesc = newConstMetricDesc(
+		"hive_cluster_deployment_ready_condition_missing",
+		"Whether an installed ClusterDeployment has never had its Ready condition set.",
+		"cluster_deployment", "namespace",
+	)
+)
+
+// newReadyConditionMissingCollector returns a collector reporting installed ClusterDeployments whose Ready condition
+// is missing or still Unknown.
+func newReadyConditionMissingCollector(client client.Client) prometheus.Collector {
+	return readyConditionMissingCollector{
+		client: client,
+		metricClusterDeploymentReadyConditionMissing: metricClusterDeploymentReadyConditionMissingDesc,
+	}
+}
+
+// credentials request pending metric collected through a custom prometheus collector
+type credentialsRequestPendingCollector struct {
+	client client.Client
+
+	// metricClusterDeploymentCredentialsRequestPending is a prometheus metric reporting provisioning
+	// ClusterDeployments using manual (STS) credentials whose credentials have not been provided yet.
+	metricClusterDeploymentCredentialsRequestPending constMetricDesc
+}
+
+// Collect collects the metrics for credentialsRequestPendingCollector
+func (cc credentialsRequestPendingCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := newCollectScrape(cc, "pending credentials request metrics across all ClusterDeployments")
+	defer scrape.done()
+
+	clusterDeployments := &hivev1.ClusterDeploymentList{}
+	pages := scrape.list(cc.client, clusterDeployments)
+	for pages.next() {
+		for _, cd := range clusterDeployments.Items {
+			if cd.DeletionTimestamp != nil || cd.Spec.Installed {
+				continue
+			}
+			// Clusters are installed with manual (STS) credentials when they bring their own service account
+			// signing key.
+			if cd.Spec.BoundServiceAccountSignkingKeySecretRef == nil {
+				continue
+			}
+			pending, err := cc.credentialsPending(scrape.ctx, &cd)
+			if err != nil {
+				if scrape.readFailed() {
+					return
+				}
+				scrape.log.WithError(err).WithField("clusterDeployment", cd.Namespace+"/"+cd.Name).Warn("error getting credentials")
+				continue
+			}
+			if !pending {
+				continue
+			}
+			ch <- cc.metricClusterDeploymentCredentialsRequestPending.mustNewConstMetric(
+				prometheus.GaugeValue,
+				1,
+				prometheus.Labels{
+					"cluster_deployment": cd.Name,
+					"namespace":          cd.Namespace,
+				},
+			)
+		}
+	}
+	if scrape.listFailed(pages) {
+		return
+	}
+}
+
+// credentialsPending returns whether the install of a cluster using manual credentials is waiting for the service
+// account signing key Secret, or for the manifests holding the credentials created for its CredentialsRequests, to be
+// created.
+func (cc credentialsRequestPendingCollector) credentialsPending(ctx context.Context, cd *hivev1.ClusterDeployment) (bool, error) {
+	signingKey := &corev1.Secret{}
+	switch err := cc.client.Get(ctx, types.NamespacedName{Namespace: cd.Namespace, Name: cd.Spec.BoundServiceAccountSignkingKeySecretRef.Name}, signingKey); {
+	case apierrors.IsNotFound(err):
+		return true, nil
+	case err != nil:
+		return false, err
+	}
+	if len(signingKey.Data[constants.BoundServiceAccountSigningKeyFile]) == 0 {
+		return true, nil
+	}
+	if cd.Spec.Provisioning == nil {
+		return false, nil
+	}
+	var manifests client.Object
+	var name string
+	switch p := cd.Spec.Provisioning; {
+	case p.ManifestsConfigMapRef != nil:
+		manifests, name = &corev1.ConfigMap{}, p.ManifestsConfigMapRef.Name
+	case p.ManifestsSecretRef != nil:
+		manifests, name = &corev1.Secret{}, p.ManifestsSecretRef.Name
+	default:
+		return false, nil
+	}
+	switch err := cc.client.Get(ctx, types.NamespacedName{Namespace: cd.Namespace, Name: name}, manifests); {
+	case apierrors.IsNotFound(err):
+		return true, nil
+	case err != nil:
+		return false, err
+	}
+	return false, nil
+}
+
+func (cc credentialsRequestPendingCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.metricClusterDeploymentCredentialsRequestPending.Desc
+}
+
+var (
+	metricClusterDeploymentCredentialsRequestPendingDesc = newConstMetricDesc(
+		"hive_cluster_deployment_credentials_request_pending",
+		"Whether a cluster installing with manual (STS) credentials is waiting for its credentials to be provided.",
+		"cluster_deployment", "namespace",
+	)
+)
+
+// newCredentialsRequestPendingCollector returns a collector reporting provisioning clusters using manual (STS)
+// credentials whose service account signing key Secret, or manifests ConfigMap or Secret, does not exist yet.
+func newCredentialsRequestPendingCollector(client client.Client) prometheus.Collector {
+	return credentialsRequestPendingCollector{
+		client: client,
+		metricClusterDeploymentCredentialsRequestPending: metricClusterDeploymentCredentialsRequestPendingDesc,
+	}
+}
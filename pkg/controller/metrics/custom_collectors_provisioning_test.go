@@ -0,0 +1,1051 @@
+package metrics
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/openshift/hive/apis/hive/v1/metricsconfig"
+	"github.com/openshift/hive/pkg/constants"
+	testcd "github.com/openshift/hive/pkg/test/clusterdeployment"
+	testcp "github.com/openshift/hive/pkg/test/clusterprovision"
+	testcm "github.com/openshift/hive/pkg/test/configmap"
+	testfake "github.com/openshift/hive/pkg/test/fake"
+	testgeneric "github.com/openshift/hive/pkg/test/generic"
+	testsecret "github.com/openshift/hive/pkg/test/secret"
+	"github.com/openshift/hive/pkg/util/scheme"
+)
+
+func TestProvisioningCollectors(t *testing.T) {
+	scheme := scheme.GetScheme()
+
+	cdBuilder := func(name string) testcd.Builder {
+		return testcd.FullBuilder(name, name, scheme)
+	}
+	deleted := []testgeneric.Option{testgeneric.Deleted(), testgeneric.WithFinalizer(testFinalizer)}
+	condition := func(conditionType hivev1.ClusterDeploymentConditionType, status corev1.ConditionStatus, reason string) testcd.Option {
+		return testcd.WithCondition(hivev1.ClusterDeploymentCondition{
+			Type:   conditionType,
+			Status: status,
+			Reason: reason,
+		})
+	}
+	withProvision := func(name string) testcd.Option {
+		return func(cd *hivev1.ClusterDeployment) {
+			cd.Status.ProvisionRef = &corev1.LocalObjectReference{Name: name}
+		}
+	}
+	provision := func(namespace, name string, opts ...testcp.Option) *hivev1.ClusterProvision {
+		return testcp.FullBuilder(namespace, name).Build(
+			append([]testcp.Option{testcp.WithClusterDeploymentRef(namespace)}, opts...)...,
+		)
+	}
+
+	withInstallerImages := func(override, resolved string) testcd.Option {
+		return func(cd *hivev1.ClusterDeployment) {
+			cd.Spec.Provisioning = &hivev1.Provisioning{
+				ReleaseImage:           "quay.io/openshift-release-dev/ocp-release:4.14.0-x86_64",
+				InstallerImageOverride: override,
+			}
+			if resolved != "" {
+				cd.Status.InstallerImage = &resolved
+			}
+			cd.Status.InstallVersion = pointer.String("4.14.0")
+		}
+	}
+
+	manifestsConfigMap := func(cd *hivev1.ClusterDeployment) {
+		cd.Spec.Provisioning = &hivev1.Provisioning{
+			ManifestsConfigMapRef: &corev1.LocalObjectReference{Name: "manifests"},
+		}
+	}
+	manifestsSecret := func(cd *hivev1.ClusterDeployment) {
+		cd.Spec.Provisioning = &hivev1.Provisioning{
+			ManifestsSecretRef: &corev1.LocalObjectReference{Name: "manifests"},
+		}
+	}
+	manifests := func(namespace string, count int) *corev1.ConfigMap {
+		opts := make([]testcm.Option, count)
+		for i := range opts {
+			opts[i] = testcm.WithDataKeyValue(fmt.Sprintf("manifest-%d.yaml", i), "kind: ConfigMap")
+		}
+		return testcm.FullBuilder(namespace, "manifests", scheme).Build(opts...)
+	}
+	additionalManifestCount := func(min int) func(client.Client) prometheus.Collector {
+		return func(c client.Client) prometheus.Collector {
+			return newAdditionalManifestCountCollector(c, min)
+		}
+	}
+
+	withReleaseImage := func(cd *hivev1.ClusterDeployment) {
+		cd.Spec.Provisioning = &hivev1.Provisioning{
+			ReleaseImage: "quay.io/openshift-release-dev/ocp-release:4.14.0-x86_64",
+		}
+	}
+	withClusterInstallRef := func(kind string) testcd.Option {
+		return func(cd *hivev1.ClusterDeployment) {
+			cd.Spec.ClusterInstallRef = &hivev1.ClusterInstallLocalReference{
+				Group:   "extensions.hive.openshift.io",
+				Version: "v1beta1",
+				Kind:    kind,
+				Name:    cd.Name,
+			}
+		}
+	}
+
+	typedCD := func(name, clusterType string, age time.Duration) testcd.Builder {
+		return cdBuilder(name).GenericOptions(
+			testgeneric.WithCreationTimestamp(testNow.Add(-age)),
+			testgeneric.WithLabel(hivev1.HiveClusterTypeLabel, clusterType),
+		)
+	}
+	slos := []metricsconfig.ProvisioningSLO{{
+		Name:     "provision-2h",
+		Duration: metav1.Duration{Duration: 2 * time.Hour},
+	}, {
+		Name:        "managed-provision-1h",
+		ClusterType: "managed",
+		Duration:    metav1.Duration{Duration: time.Hour},
+	}}
+
+	regexesConfigMap := func(name, regexes string) *corev1.ConfigMap {
+		return testcm.FullBuilder(constants.DefaultHiveNamespace, name, scheme).Build(
+			testcm.WithDataKeyValue("regexes", regexes),
+		)
+	}
+	regexes := regexesConfigMap("install-log-regexes", `
+- name: AWSVPCLimitExceeded
+  searchRegexStrings:
+  - "VpcLimitExceeded"
+  installFailingReason: AWSVPCLimitExceeded
+- name: DNSAlreadyExists
+  searchRegexStrings:
+  - "aws_route53_record.*Error building changeset:.*Tried to create resource record set.*but it already exists"
+  - "route53 record already exists"
+  installFailingReason: DNSAlreadyExists
+`)
+	failedWithLog := func(namespace, installLog string) *hivev1.ClusterProvision {
+		return provision(namespace, "provision", testcp.WithInstallLog(installLog))
+	}
+
+	provisioning := condition(hivev1.ProvisionedCondition, corev1.ConditionFalse, hivev1.ProvisionedReasonProvisioning)
+	provisionFailed := condition(hivev1.ProvisionFailedCondition, corev1.ConditionTrue, "FailedDueToQuotas")
+
+	sts := func(cd *hivev1.ClusterDeployment) {
+		cd.Spec.BoundServiceAccountSignkingKeySecretRef = &corev1.LocalObjectReference{Name: "signing-key"}
+	}
+	signingKey := func(namespace string) *corev1.Secret {
+		return testsecret.FullBuilder(namespace, "signing-key", scheme).Build(
+			testsecret.WithDataKeyValue(constants.BoundServiceAccountSigningKeyFile, []byte("key")),
+		)
+	}
+
+	const (
+		lbTimeout = `level=error msg="Error: waiting for ELBv2 Load Balancer (api-int) create: timeout while waiting for state to become 'active' (last state: 'provisioning', timeout: 10m0s)"
+level=error
+level=error msg="  with module.vpc.aws_lb.api_internal,"
+level=error msg="  on vpc/master-elb.tf line 1, in resource \"aws_lb\" \"api_internal\":"
+`
+		natTimeout = `Error: error waiting for NAT Gateway (nat-0123) to become available: timeout while waiting for state to become 'available' (last state: 'pending', timeout: 10m0s)
+
+  with module.vpc.aws_nat_gateway.nat_gw[2],
+  on vpc/vpc-public.tf line 64, in resource "aws_nat_gateway" "nat_gw":
+`
+		authLog = `level=error msg="failed to fetch release image: unauthorized: authentication required"`
+	)
+	restartedCD := func(name string, restarts int, provisionName string) testcd.Builder {
+		return cdBuilder(name).Options(testcd.InstallRestarts(restarts), withProvision(provisionName))
+	}
+	withPrev := func(name string) testcp.Option {
+		return func(p *hivev1.ClusterProvision) {
+			p.Spec.PrevProvisionName = &name
+		}
+	}
+
+	// attempts returns the ClusterProvisions of cd-1, cd-2, ..., making counts[i] attempts for cd-<i+1>.
+	attempts := func(counts ...int) []runtime.Object {
+		var objs []runtime.Object
+		for i, count := range counts {
+			cdName := fmt.Sprintf("cd-%d", i+1)
+			for j := 0; j < count; j++ {
+				objs = append(objs, provision(cdName, fmt.Sprintf("%s-%d", cdName, j), testcp.Attempt(j)))
+			}
+		}
+		return objs
+	}
+	excessiveProvisions := func(maximum int) func(client.Client) prometheus.Collector {
+		return func(c client.Client) prometheus.Collector {
+			return newExcessiveProvisionsCollector(c, maximum)
+		}
+	}
+
+	attempt := func(cdName string, attempt int, opts ...testcp.Option) runtime.Object {
+		return provision(cdName, cdName, append([]testcp.Option{testcp.Attempt(attempt)}, opts...)...)
+	}
+	withFailureMessage := func(message string) testcp.Option {
+		return func(provision *hivev1.ClusterProvision) {
+			testcp.WithFailureReason("InstallFailed")(provision)
+			provision.Status.Conditions[0].Message = message
+		}
+	}
+
+	runCollectorTests(t, []collectorTest{{
+		name:      "installer version mismatch",
+		collector: newInstallerVersionMismatchCollector,
+		cases: []collectorCase{{
+			name: "installer matches release",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(withInstallerImages("", "quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:1234")),
+				cdBuilder("cd-2").Build(withInstallerImages("", "")),
+			},
+		}, {
+			name: "installer mismatched",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(withInstallerImages("", "quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:1234")),
+				cdBuilder("cd-2").Build(withInstallerImages("quay.io/example/installer:4.13", "quay.io/example/installer:4.13")),
+			},
+			expected: []string{
+				"cluster_deployment = cd-2 namespace = cd-2 1",
+			},
+		}, {
+			name: "installer override matches release version",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(withInstallerImages("quay.io/example/installer:4.14.0", "quay.io/example/installer:4.14.0")),
+				cdBuilder("cd-2").Build(withInstallerImages("quay.io/example/installer:4.14.0-x86_64", "quay.io/example/installer:4.14.0-x86_64")),
+			},
+		}, {
+			name: "installer override version unknown",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(withInstallerImages("quay.io/example/installer@sha256:1234", "quay.io/example/installer@sha256:1234")),
+				cdBuilder("cd-2").Build(withInstallerImages("quay.io/example/installer:latest", "quay.io/example/installer:latest")),
+				cdBuilder("cd-3").Build(withInstallerImages("registry.example.com:5000/installer", "registry.example.com:5000/installer")),
+			},
+		}, {
+			name: "installer override not yet resolved",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(withInstallerImages("quay.io/example/installer:4.13", "")),
+			},
+		}, {
+			name: "installed with mismatched installer",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(testcd.Installed(), withInstallerImages("quay.io/example/installer:4.13", "quay.io/example/installer:4.13")),
+			},
+		}},
+	}, {
+		name:      "additional manifest count",
+		collector: additionalManifestCount(0),
+		cases: []collectorCase{{
+			name: "no additional manifests",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(),
+			},
+		}, {
+			name: "all clusters with additional manifests",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(manifestsConfigMap),
+				manifests("cd-1", 3),
+				cdBuilder("cd-2").Build(),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = cd-1 3",
+			},
+		}, {
+			name: "below, at and above the minimum",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(manifestsConfigMap),
+				manifests("cd-1", 9),
+				cdBuilder("cd-2").Build(manifestsConfigMap),
+				manifests("cd-2", 10),
+				cdBuilder("cd-3").Build(manifestsConfigMap),
+				manifests("cd-3", 11),
+			},
+			collector: additionalManifestCount(10),
+			expected: []string{
+				"cluster_deployment = cd-3 namespace = cd-3 11",
+			},
+		}, {
+			name: "installed cluster above the minimum",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(testcd.Installed(), manifestsConfigMap),
+				manifests("cd-1", 11),
+			},
+			collector: additionalManifestCount(10),
+		}, {
+			name: "missing manifests configmap",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(manifestsConfigMap),
+			},
+		}},
+	}, {
+		name:      "install type",
+		collector: newInstallTypeCollector,
+		cases: []collectorCase{{
+			name: "no clusters",
+			expected: []string{
+				"type = ipi 0",
+				"type = upi 0",
+				"type = assisted 0",
+				"type = adopted 0",
+			},
+		}, {
+			name: "mixed install types",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(withReleaseImage),
+				cdBuilder("cd-2").Build(withReleaseImage, testcd.Installed()),
+				cdBuilder("cd-3").Build(withClusterInstallRef("AgentClusterInstall")),
+				cdBuilder("cd-4").Build(withClusterInstallRef("ImageClusterInstall")),
+				cdBuilder("cd-5").Build(testcd.Installed()),
+			},
+			expected: []string{
+				"type = ipi 2",
+				"type = upi 1",
+				"type = assisted 1",
+				"type = adopted 1",
+			},
+		}, {
+			name: "deleted clusters are skipped",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").GenericOptions(deleted...).Build(withReleaseImage),
+				cdBuilder("cd-2").Build(withClusterInstallRef("AgentClusterInstall")),
+			},
+			expected: []string{
+				"type = ipi 0",
+				"type = upi 0",
+				"type = assisted 1",
+				"type = adopted 0",
+			},
+		}},
+	}, {
+		name: "provisioning SLO breached",
+		collector: func(c client.Client) prometheus.Collector {
+			collect := newProvisioningSLOBreachedCollector(c, slos, "").(provisioningSLOBreachedCollector)
+			collect.clock = clocktesting.NewFakePassiveClock(testNow)
+			return collect
+		},
+		cases: []collectorCase{{
+			name: "within SLO",
+			existing: []runtime.Object{
+				typedCD("cd-1", "managed", 30*time.Minute).Build(),
+				typedCD("cd-2", "test", 90*time.Minute).Build(),
+			},
+		}, {
+			name: "breached SLO for the cluster type",
+			existing: []runtime.Object{
+				typedCD("cd-1", "managed", 90*time.Minute).Build(),
+				typedCD("cd-2", "test", 90*time.Minute).Build(),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = cd-1 slo = managed-provision-1h 1",
+			},
+		}, {
+			name: "breached multiple SLOs",
+			existing: []runtime.Object{
+				typedCD("cd-1", "managed", 3*time.Hour).Build(),
+				typedCD("cd-2", "test", 3*time.Hour).Build(),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = cd-1 slo = provision-2h 1",
+				"cluster_deployment = cd-1 namespace = cd-1 slo = managed-provision-1h 1",
+				"cluster_deployment = cd-2 namespace = cd-2 slo = provision-2h 1",
+			},
+		}, {
+			name: "exactly at SLO",
+			existing: []runtime.Object{
+				typedCD("cd-1", "managed", time.Hour).Build(),
+				typedCD("cd-2", "managed", time.Hour-time.Second).Build(),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = cd-1 slo = managed-provision-1h 1",
+			},
+		}, {
+			name: "installed after breaching SLO",
+			existing: []runtime.Object{
+				typedCD("cd-1", "managed", 3*time.Hour).Build(testcd.Installed()),
+			},
+		}},
+	}, {
+		name:      "known install error",
+		collector: newKnownInstallErrorCollector,
+		cases: []collectorCase{{
+			name: "no regexes configmap",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(withProvision("provision")),
+				failedWithLog("cd-1", "level=error msg=VpcLimitExceeded"),
+			},
+		}, {
+			name: "matching and non-matching logs",
+			existing: []runtime.Object{
+				regexes,
+				cdBuilder("cd-1").Build(withProvision("provision")),
+				failedWithLog("cd-1", "level=error msg=vpclimitexceeded: The maximum number of VPCs has been reached."),
+				cdBuilder("cd-2").Build(withProvision("provision")),
+				failedWithLog("cd-2", "level=error msg=something we have never seen before"),
+				cdBuilder("cd-3").Build(withProvision("provision")),
+				failedWithLog("cd-3", "level=error msg=VpcLimitExceeded\nlevel=error msg=Route53 record already exists"),
+				cdBuilder("cd-4").Build(),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = cd-1 signature = AWSVPCLimitExceeded 1",
+				"cluster_deployment = cd-3 namespace = cd-3 signature = AWSVPCLimitExceeded 1",
+				"cluster_deployment = cd-3 namespace = cd-3 signature = DNSAlreadyExists 1",
+			},
+		}, {
+			name: "additional regexes configmap",
+			existing: []runtime.Object{
+				regexes,
+				regexesConfigMap("additional-install-log-regexes", `
+- name: CustomError
+  searchRegexStrings:
+  - "custom failure"
+  installFailingReason: CustomError
+`),
+				cdBuilder("cd-1").Build(withProvision("provision")),
+				failedWithLog("cd-1", "level=error msg=custom failure"),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = cd-1 signature = CustomError 1",
+			},
+		}, {
+			name: "installed and deleted clusters",
+			existing: []runtime.Object{
+				regexes,
+				cdBuilder("cd-1").Build(testcd.Installed(), withProvision("provision")),
+				failedWithLog("cd-1", "level=error msg=VpcLimitExceeded"),
+				cdBuilder("cd-2").GenericOptions(deleted...).Build(withProvision("provision")),
+				failedWithLog("cd-2", "level=error msg=VpcLimitExceeded"),
+			},
+		}, {
+			name: "missing provision",
+			existing: []runtime.Object{
+				regexes,
+				cdBuilder("cd-1").Build(withProvision("provision")),
+			},
+		}},
+	}, {
+		name:      "stage",
+		collector: newStageCollector,
+		cases: []collectorCase{{
+			name: "no cluster deployments",
+			expected: []string{
+				"stage = pending 0",
+				"stage = provisioning 0",
+				"stage = provision_failed 0",
+				"stage = installed 0",
+				"stage = deprovisioning 0",
+			},
+		}, {
+			name: "all installed",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(testcd.Installed()),
+				cdBuilder("cd-2").Build(testcd.Installed()),
+			},
+			expected: []string{
+				"stage = pending 0",
+				"stage = provisioning 0",
+				"stage = provision_failed 0",
+				"stage = installed 2",
+				"stage = deprovisioning 0",
+			},
+		}, {
+			name: "mix of stages",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(),
+				cdBuilder("cd-2").Build(provisioning),
+				cdBuilder("cd-3").Build(provisioning),
+				cdBuilder("cd-4").Build(provisioning, provisionFailed),
+				cdBuilder("cd-5").Build(testcd.Installed()),
+				cdBuilder("cd-6").GenericOptions(deleted...).Build(testcd.Installed()),
+				cdBuilder("cd-7").GenericOptions(deleted...).Build(provisioning),
+			},
+			expected: []string{
+				"stage = pending 1",
+				"stage = provisioning 2",
+				"stage = provision_failed 1",
+				"stage = installed 1",
+				"stage = deprovisioning 2",
+			},
+		}, {
+			name: "requirements not met takes precedence over provision failure",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(provisionFailed,
+					condition(hivev1.RequirementsMetCondition, corev1.ConditionFalse, "ClusterImageSetNotFound")),
+			},
+			expected: []string{
+				"stage = pending 1",
+				"stage = provisioning 0",
+				"stage = provision_failed 0",
+				"stage = installed 0",
+				"stage = deprovisioning 0",
+			},
+		}},
+	}, {
+		name:      "never reconciled",
+		collector: newNeverReconciledCollector,
+		cases: []collectorCase{{
+			name: "reconciled and unreconciled clusters",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(),
+				cdBuilder("cd-2").Build(condition(hivev1.ProvisionFailedCondition, corev1.ConditionUnknown, hivev1.InitializedConditionReason)),
+				cdBuilder("cd-3").Build(condition(hivev1.ProvisionFailedCondition, corev1.ConditionUnknown, hivev1.InitializedConditionReason), testcd.Installed()),
+				cdBuilder("cd-4").GenericOptions(deleted...).Build(),
+				cdBuilder("cd-5").Build(),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = cd-1 1",
+				"cluster_deployment = cd-5 namespace = cd-5 1",
+			},
+		}},
+	}, {
+		name:      "ready condition missing",
+		collector: newReadyConditionMissingCollector,
+		cases: []collectorCase{{
+			name: "ready condition present",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(testcd.Installed(), condition(hivev1.ClusterReadyCondition, corev1.ConditionTrue, "")),
+				cdBuilder("cd-2").Build(testcd.Installed(), condition(hivev1.ClusterReadyCondition, corev1.ConditionFalse, "")),
+			},
+		}, {
+			name: "ready condition missing",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(testcd.Installed()),
+				cdBuilder("cd-2").Build(testcd.Installed(), condition(hivev1.ClusterReadyCondition, corev1.ConditionUnknown, "")),
+				cdBuilder("cd-3").Build(testcd.Installed(), condition(hivev1.ClusterReadyCondition, corev1.ConditionTrue, "")),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = cd-1 1",
+				"cluster_deployment = cd-2 namespace = cd-2 1",
+			},
+		}, {
+			name: "uninstalled and deleted clusters are skipped",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(),
+				cdBuilder("cd-2").GenericOptions(deleted...).Build(testcd.Installed()),
+			},
+		}},
+	}, {
+		name:      "credentials request pending",
+		collector: newCredentialsRequestPendingCollector,
+		cases: []collectorCase{{
+			name: "credentials provided",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").Build(sts),
+				signingKey("cd-1"),
+				cdBuilder("cd-2").Build(sts, manifestsConfigMap),
+				signingKey("cd-2"),
+				testcm.FullBuilder("cd-2", "manifests", scheme).Build(),
+				cdBuilder("cd-3").Build(sts, manifestsSecret),
+				signingKey("cd-3"),
+				testsecret.FullBuilder("cd-3", "manifests", scheme).Build(),
+			},
+		}, {
+			name: "credentials pending",
+			existing: []runtime.Object{
+				// No signing key.
+				cdBuilder("cd-1").Build(sts),
+				// Signing key without the key.
+				cdBuilder("cd-2").Build(sts),
+				testsecret.FullBuilder("cd-2", "signing-key", scheme).Build(),
+				// No manifests.
+				cdBuilder("cd-3").Build(sts, manifestsConfigMap),
+				signingKey("cd-3"),
+				cdBuilder("cd-4").Build(sts, manifestsSecret),
+				signingKey("cd-4"),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = cd-1 1",
+				"cluster_deployment = cd-2 namespace = cd-2 1",
+				"cluster_deployment = cd-3 namespace = cd-3 1",
+				"cluster_deployment = cd-4 namespace = cd-4 1",
+			},
+		}, {
+			name: "not pending",
+			existing: []runtime.Object{
+				// Not using manual credentials.
+				cdBuilder("cd-1").Build(manifestsConfigMap),
+				cdBuilder("cd-2").Build(sts, testcd.Installed()),
+				cdBuilder("cd-3").GenericOptions(deleted...).Build(sts),
+			},
+		}},
+	}, {
+		name:      "install resource timeout",
+		collector: newInstallResourceTimeoutCollector,
+		cases: []collectorCase{{
+			name: "failed provision timing out on two resources",
+			existing: []runtime.Object{
+				restartedCD("cd-1", 1, "provision-0").Build(),
+				provision("cd-1", "provision-0", testcp.Failed(), testcp.WithInstallLog(lbTimeout+natTimeout+natTimeout)),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = cd-1 resource = aws_lb 1",
+				"cluster_deployment = cd-1 namespace = cd-1 resource = aws_nat_gateway 1",
+			},
+		}, {
+			name: "restarted provision",
+			existing: []runtime.Object{
+				restartedCD("cd-1", 1, "provision-1").Build(),
+				provision("cd-1", "provision-0", testcp.Failed(), testcp.WithInstallLog(natTimeout)),
+				provision("cd-1", "provision-1", testcp.WithStage(hivev1.ClusterProvisionStageProvisioning), withPrev("provision-0")),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = cd-1 resource = aws_nat_gateway 1",
+			},
+		}, {
+			name: "other failures",
+			existing: []runtime.Object{
+				restartedCD("cd-1", 1, "provision-0").Build(),
+				provision("cd-1", "provision-0", testcp.Failed(), testcp.WithInstallLog("level=error msg=VpcLimitExceeded")),
+				restartedCD("cd-2", 1, "provision-0").Build(),
+				provision("cd-2", "provision-0", testcp.Failed()),
+			},
+		}, {
+			name: "not restarted, installed or missing provision",
+			existing: []runtime.Object{
+				restartedCD("cd-1", 0, "provision-0").Build(),
+				provision("cd-1", "provision-0", testcp.Failed(), testcp.WithInstallLog(lbTimeout)),
+				restartedCD("cd-2", 1, "provision-0").Build(testcd.Installed()),
+				provision("cd-2", "provision-0", testcp.Failed(), testcp.WithInstallLog(lbTimeout)),
+				restartedCD("cd-3", 1, "provision-1").Build(),
+			},
+		}},
+	}, {
+		name:      "excessive provisions",
+		collector: excessiveProvisions(3),
+		cases: []collectorCase{{
+			name:     "below, at and above the maximum",
+			existing: attempts(2, 3, 4, 7),
+			expected: []string{
+				"cluster_deployment = cd-3 count = 4 namespace = cd-3 4",
+				"cluster_deployment = cd-4 count = 7 namespace = cd-4 7",
+			},
+		}, {
+			name:      "no maximum",
+			existing:  attempts(1),
+			collector: excessiveProvisions(0),
+			expected: []string{
+				"cluster_deployment = cd-1 count = 1 namespace = cd-1 1",
+			},
+		}, {
+			name: "no provisions",
+		}},
+	}, {
+		name:      "registry auth failures",
+		collector: newRegistryAuthFailuresCollector,
+		cases: []collectorCase{{
+			name: "auth and other failures",
+			existing: []runtime.Object{
+				// Auth failure found in the failure condition, on two attempts.
+				attempt("cd-1", 0, withFailureMessage("error pulling image quay.io/openshift/release: pull access denied")),
+				attempt("cd-1", 1, withFailureMessage("error pulling image: Invalid username/password")),
+				attempt("cd-1", 2, testcp.WithStage(hivev1.ClusterProvisionStageProvisioning)),
+				// Auth failure found in the install log only.
+				attempt("cd-2", 0, testcp.WithFailureReason("UnknownError"), testcp.WithInstallLog(authLog)),
+				// Other failures.
+				attempt("cd-3", 0, withFailureMessage("Timeout waiting for the Kubernetes API to begin responding")),
+				attempt("cd-3", 1, testcp.WithFailureReason("KubeAPIWaitTimeout"), testcp.WithInstallLog("waiting for Kubernetes API: context deadline exceeded")),
+				// An auth error that didn't fail the provision.
+				attempt("cd-4", 0, testcp.WithStage(hivev1.ClusterProvisionStageProvisioning), testcp.WithInstallLog(authLog)),
+			},
+			expected: []string{
+				"cluster_deployment = cd-1 namespace = cd-1 2",
+				"cluster_deployment = cd-2 namespace = cd-2 1",
+			},
+		}},
+	}})
+}
+
+func TestProvisioningUnderwayOptions(t *testing.T) {
+	scheme := scheme.GetScheme()
+
+	cdBuilder := func(name string, age time.Duration) testcd.Builder {
+		return testcd.FullBuilder(name, name, scheme).
+			GenericOptions(testgeneric.WithCreationTimestamp(testNow.Add(-age)))
+	}
+	condition := func(conditionType hivev1.ClusterDeploymentConditionType, status corev1.ConditionStatus, reason string, since time.Duration) testcd.Option {
+		return testcd.WithCondition(hivev1.ClusterDeploymentCondition{
+			Type:               conditionType,
+			Status:             status,
+			Reason:             reason,
+			LastTransitionTime: metav1.NewTime(testNow.Add(-since)),
+		})
+	}
+	provisionFailed := func(reason, message string) testcd.Option {
+		return testcd.WithCondition(hivev1.ClusterDeploymentCondition{
+			Type:    hivev1.ProvisionFailedCondition,
+			Status:  corev1.ConditionTrue,
+			Reason:  reason,
+			Message: message,
+		})
+	}
+	withMetadata := func(cd *hivev1.ClusterDeployment) {
+		cd.Spec.ClusterMetadata = &hivev1.ClusterMetadata{InfraID: cd.Name + "-infra"}
+	}
+	withProvision := func(cd *hivev1.ClusterDeployment) {
+		cd.Status.ProvisionRef = &corev1.LocalObjectReference{Name: cd.Name + "-0"}
+	}
+	withClusterInstall := func(cd *hivev1.ClusterDeployment) {
+		cd.Spec.ClusterInstallRef = &hivev1.ClusterInstallLocalReference{Kind: "AgentClusterInstall", Name: cd.Name}
+	}
+	owner := func(kind, name string, controller bool) testcd.Option {
+		return func(cd *hivev1.ClusterDeployment) {
+			cd.OwnerReferences = append(cd.OwnerReferences, metav1.OwnerReference{
+				APIVersion: hivev1.SchemeGroupVersion.String(),
+				Kind:       kind,
+				Name:       name,
+				UID:        types.UID(name),
+				Controller: pointer.Bool(controller),
+			})
+		}
+	}
+	const tierLabel = "acme.com/tier"
+	provisioningUnderway := func(opts provisioningUnderwayOptions) func(client.Client) prometheus.Collector {
+		return func(c client.Client) prometheus.Collector {
+			collect := newProvisioningUnderwaySecondsCollector(c, opts).(provisioningUnderwayCollector)
+			collect.clock = clocktesting.NewFakePassiveClock(testNow)
+			return collect
+		}
+	}
+
+	reasons := []runtime.Object{
+		cdBuilder("cd-1", time.Hour).Build(testcd.InstallRestarts(1), provisionFailed(hivev1.ProvisionedReasonProvisionStopped, "")),
+		cdBuilder("cd-2", time.Hour).Build(testcd.InstallRestarts(1), provisionFailed("AWSInsufficientCapacity", "")),
+		cdBuilder("cd-3", time.Hour).Build(testcd.InstallRestarts(1), provisionFailed("SomethingBespoke-1234", "")),
+	}
+	postInstall := []runtime.Object{
+		cdBuilder("healthy", 3*time.Hour).Build(testcd.Installed(),
+			condition(hivev1.ClusterImageSetNotFoundCondition, corev1.ConditionFalse, "ClusterImageSetFound", time.Hour),
+			condition(hivev1.UnreachableCondition, corev1.ConditionFalse, "ClusterReachable", time.Hour)),
+		cdBuilder("imageset-missing", 3*time.Hour).Build(testcd.Installed(),
+			condition(hivev1.ClusterImageSetNotFoundCondition, corev1.ConditionTrue, "ClusterImageSetNotFound", time.Hour)),
+		cdBuilder("unreachable", 3*time.Hour).Build(testcd.Installed(),
+			condition(hivev1.UnreachableCondition, corev1.ConditionTrue, "", 2*time.Hour)),
+		// Conditions that don't indicate post-install problems, such as hibernation, are not reported.
+		cdBuilder("hibernating", 3*time.Hour).Build(testcd.Installed(),
+			condition(hivev1.ClusterReadyCondition, corev1.ConditionFalse, hivev1.ReadyReasonStoppingOrHibernating, time.Hour)),
+		cdBuilder("provisioning", 3*time.Hour).Build(),
+	}
+	clusterTypes := []runtime.Object{
+		cdBuilder("cd-tier", time.Hour).GenericOptions(
+			testgeneric.WithLabel(tierLabel, "gold"),
+			testgeneric.WithLabel(hivev1.HiveClusterTypeLabel, "prod"),
+		).Build(),
+		cdBuilder("cd-hive", time.Hour).GenericOptions(
+			testgeneric.WithLabel(hivev1.HiveClusterTypeLabel, "prod"),
+		).Build(),
+		cdBuilder("cd-none", time.Hour).Build(),
+	}
+
+	runCollectorTests(t, []collectorTest{{
+		name:      "provisioning underway seconds",
+		collector: provisioningUnderway(provisioningUnderwayOptions{}),
+		named:     true,
+		cases: []collectorCase{{
+			name: "minimum",
+			existing: []runtime.Object{
+				cdBuilder("cd-1", time.Hour).Build(),
+				cdBuilder("cd-2", time.Hour-time.Second).Build(),
+				cdBuilder("cd-3", 2*time.Hour).Build(),
+				// Condition overrides compare ages the same way.
+				cdBuilder("cd-4", 30*time.Minute).Build(condition(hivev1.DNSNotReadyCondition, corev1.ConditionTrue, "", 0)),
+				cdBuilder("cd-5", 30*time.Minute-time.Second).Build(condition(hivev1.DNSNotReadyCondition, corev1.ConditionTrue, "", 0)),
+			},
+			collector: provisioningUnderway(provisioningUnderwayOptions{
+				minimum:            time.Hour,
+				minimumByCondition: map[hivev1.ClusterDeploymentConditionType]time.Duration{hivev1.DNSNotReadyCondition: 30 * time.Minute},
+			}),
+			// A cluster provisioning for exactly the minimum duration is reported.
+			expected: []string{
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-1 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-1 platform =  reason = Unknown 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-3 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-3 platform =  reason = Unknown 7200",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-4 cluster_type = unspecified condition = DNSNotReady image_set = none namespace = cd-4 platform =  reason = Unknown 1800",
+			},
+		}, {
+			name:      "additional reasons",
+			existing:  reasons,
+			collector: provisioningUnderway(provisioningUnderwayOptions{additionalReasons: []string{"AWSInsufficientCapacity"}}),
+			expected: []string{
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-1 cluster_type = unspecified condition = ProvisionFailed image_set = none namespace = cd-1 platform =  reason = ProvisionStopped 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-2 cluster_type = unspecified condition = ProvisionFailed image_set = none namespace = cd-2 platform =  reason = AWSInsufficientCapacity 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-3 cluster_type = unspecified condition = ProvisionFailed image_set = none namespace = cd-3 platform =  reason = Other 3600",
+			},
+		}, {
+			name: "provision kind",
+			existing: []runtime.Object{
+				// Fresh installs, including those whose install has produced metadata.
+				cdBuilder("cd-1", time.Hour).Build(),
+				cdBuilder("cd-2", time.Hour).Build(withProvision, withMetadata),
+				cdBuilder("cd-3", time.Hour).Build(withClusterInstall, withMetadata),
+				// Installs restarted after failing.
+				cdBuilder("cd-4", time.Hour).Build(testcd.InstallRestarts(2), withProvision, withMetadata),
+				// Metadata supplied by the creator.
+				cdBuilder("cd-5", time.Hour).Build(withMetadata),
+				cdBuilder("cd-6", time.Hour).Build(withMetadata, testcd.InstallRestarts(1)),
+			},
+			collector: provisioningUnderway(provisioningUnderwayOptions{provisionKind: true}),
+			expected: []string{
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-1 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-1 platform =  provision_kind = initial reason = Unknown 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-2 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-2 platform =  provision_kind = initial reason = Unknown 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-3 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-3 platform =  provision_kind = initial reason = Unknown 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-4 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-4 platform =  provision_kind = reprovision reason = Unknown 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-5 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-5 platform =  provision_kind = adopted reason = Unknown 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-6 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-6 platform =  provision_kind = adopted reason = Unknown 3600",
+			},
+		}, {
+			name: "quota detail",
+			existing: []runtime.Object{
+				cdBuilder("aws-quota-check", time.Hour).Build(provisionFailed("AWSEC2QuotaExceeded",
+					`failed to fetch Cluster: failed to generate asset "Platform Quota Check": error(MissingQuota): ec2/L-1216C47A is not available in us-east-1 because the required number of resources (24) is more than the limit of 16`)),
+				cdBuilder("aws-api", time.Hour).Build(provisionFailed("FailedDueToQuotas",
+					`Error: creating EC2 Instance: VcpuLimitExceeded: You have requested more vCPU capacity than your current vCPU limit of 32 allows for the instance bucket that the specified instance type belongs to.`)),
+				cdBuilder("gcp-region", time.Hour).Build(provisionFailed("GCPComputeQuotaExceeded",
+					`googleapi: Error 403: Quota 'CPUS' exceeded.  Limit: 24.0 in region us-central1., quotaExceeded`)),
+				cdBuilder("gcp-global", time.Hour).Build(provisionFailed("FallbackQuotaExceeded",
+					`googleapi: Error 403: Quota 'SSD_TOTAL_GB' exceeded.  Limit: 500.0 globally., quotaExceeded`)),
+				cdBuilder("gcp-quota-check", time.Hour).Build(provisionFailed("GCPServiceAccountQuotaExceeded",
+					`error(MissingQuota): iam.googleapis.com/quota/service-account-count is not available in global because the required number of resources (5) is more than remaining quota of 0`)),
+				// Quota failures whose message doesn't say which quota.
+				cdBuilder("unparsable", time.Hour).Build(provisionFailed("GCPComputeQuotaExceeded", "GCP CPUs quota exceeded")),
+				cdBuilder("no-message", time.Hour).Build(provisionFailed("FailedDueToQuotas", "")),
+				// Other failures don't get a quota_detail, even if the message mentions a quota.
+				cdBuilder("not-quota", time.Hour).Build(provisionFailed("UnknownError", `Quota 'CPUS' exceeded.  Limit: 24.0 in region us-central1.`)),
+			},
+			collector: provisioningUnderway(provisioningUnderwayOptions{quotaDetail: true}),
+			expected: []string{
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = aws-api cluster_type = unspecified condition = ProvisionFailed image_set = none namespace = aws-api platform =  quota_detail = VcpuLimitExceeded reason = Other 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = aws-quota-check cluster_type = unspecified condition = ProvisionFailed image_set = none namespace = aws-quota-check platform =  quota_detail = ec2/L-1216C47A/us-east-1 reason = Other 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = gcp-global cluster_type = unspecified condition = ProvisionFailed image_set = none namespace = gcp-global platform =  quota_detail = SSD_TOTAL_GB/global reason = Other 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = gcp-quota-check cluster_type = unspecified condition = ProvisionFailed image_set = none namespace = gcp-quota-check platform =  quota_detail = iam.googleapis.com/quota/service-account-count/global reason = Other 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = gcp-region cluster_type = unspecified condition = ProvisionFailed image_set = none namespace = gcp-region platform =  quota_detail = CPUS/us-central1 reason = Other 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = no-message cluster_type = unspecified condition = ProvisionFailed image_set = none namespace = no-message platform =  quota_detail = unknown reason = Other 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = not-quota cluster_type = unspecified condition = ProvisionFailed image_set = none namespace = not-quota platform =  quota_detail =  reason = Other 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = unparsable cluster_type = unspecified condition = ProvisionFailed image_set = none namespace = unparsable platform =  quota_detail = unknown reason = Other 3600",
+			},
+		}, {
+			name: "version",
+			existing: []runtime.Object{
+				cdBuilder("cd-1", time.Hour).Build(func(cd *hivev1.ClusterDeployment) {
+					cd.Status.InstallVersion = pointer.String("4.14.0")
+				}),
+				// The version is empty until the install reports it.
+				cdBuilder("cd-2", time.Hour).Build(),
+			},
+			collector: provisioningUnderway(provisioningUnderwayOptions{version: true}),
+			expected: []string{
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-1 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-1 platform =  reason = Unknown version = 4.14.0 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-2 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-2 platform =  reason = Unknown version = 3600",
+			},
+		}, {
+			name: "owned by",
+			existing: []runtime.Object{
+				cdBuilder("cd-1", time.Hour).Build(owner("ClusterPool", "pool-1", true)),
+				cdBuilder("cd-2", time.Hour).Build(),
+				// The controller owner is preferred over earlier owners.
+				cdBuilder("cd-3", time.Hour).Build(owner("ClusterClaim", "claim-1", false), owner("ClusterPool", "pool-1", true)),
+				// Without a controller owner, the first owner is reported.
+				cdBuilder("cd-4", time.Hour).Build(owner("ClusterClaim", "claim-1", false), owner("ClusterPool", "pool-1", false)),
+			},
+			collector: provisioningUnderway(provisioningUnderwayOptions{ownedBy: true}),
+			expected: []string{
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-1 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-1 owned_by = ClusterPool/pool-1 platform =  reason = Unknown 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-2 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-2 owned_by = none platform =  reason = Unknown 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-3 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-3 owned_by = ClusterPool/pool-1 platform =  reason = Unknown 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-4 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-4 owned_by = ClusterClaim/claim-1 platform =  reason = Unknown 3600",
+			},
+		}, {
+			name:      "post-install degraded",
+			existing:  postInstall,
+			collector: provisioningUnderway(provisioningUnderwayOptions{additionalReasons: []string{"ClusterImageSetNotFound"}, postInstallDegraded: true}),
+			expected: []string{
+				"hive_cluster_deployment_post_install_degraded_seconds cluster_deployment = imageset-missing cluster_type = unspecified condition = ClusterImageSetNotFound namespace = imageset-missing reason = ClusterImageSetNotFound 3600",
+				"hive_cluster_deployment_post_install_degraded_seconds cluster_deployment = unreachable cluster_type = unspecified condition = Unreachable namespace = unreachable reason = Unknown 7200",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = provisioning cluster_type = unspecified condition = Unknown image_set = none namespace = provisioning platform =  reason = Unknown 10800",
+			},
+		}, {
+			// By default, installed clusters are not reported at all.
+			name:     "post-install degraded not reported",
+			existing: postInstall,
+			expected: []string{
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = provisioning cluster_type = unspecified condition = Unknown image_set = none namespace = provisioning platform =  reason = Unknown 10800",
+			},
+		}, {
+			name:     "default cluster type label",
+			existing: clusterTypes,
+			expected: []string{
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-tier cluster_type = prod condition = Unknown image_set = none namespace = cd-tier platform =  reason = Unknown 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-hive cluster_type = prod condition = Unknown image_set = none namespace = cd-hive platform =  reason = Unknown 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-none cluster_type = unspecified condition = Unknown image_set = none namespace = cd-none platform =  reason = Unknown 3600",
+			},
+		}, {
+			name:      "custom cluster type label",
+			existing:  clusterTypes,
+			collector: provisioningUnderway(provisioningUnderwayOptions{clusterTypeLabelKey: tierLabel}),
+			expected: []string{
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-tier cluster_type = gold condition = Unknown image_set = none namespace = cd-tier platform =  reason = Unknown 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-hive cluster_type = unspecified condition = Unknown image_set = none namespace = cd-hive platform =  reason = Unknown 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-none cluster_type = unspecified condition = Unknown image_set = none namespace = cd-none platform =  reason = Unknown 3600",
+			},
+		}, {
+			name:      "custom cluster type label filtered",
+			existing:  clusterTypes,
+			collector: provisioningUnderway(provisioningUnderwayOptions{clusterTypeLabelKey: tierLabel, includeClusterTypes: []string{"gold"}}),
+			expected: []string{
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-tier cluster_type = gold condition = Unknown image_set = none namespace = cd-tier platform =  reason = Unknown 3600",
+			},
+		}, {
+			name:      "custom cluster type label filtered to unspecified",
+			existing:  clusterTypes,
+			collector: provisioningUnderway(provisioningUnderwayOptions{clusterTypeLabelKey: tierLabel, includeClusterTypes: []string{"unspecified"}}),
+			expected: []string{
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-hive cluster_type = unspecified condition = Unknown image_set = none namespace = cd-hive platform =  reason = Unknown 3600",
+				"hive_cluster_deployment_provision_underway_seconds cluster_deployment = cd-none cluster_type = unspecified condition = Unknown image_set = none namespace = cd-none platform =  reason = Unknown 3600",
+			},
+		}},
+	}, {
+		name: "provisioning underway install restarts",
+		collector: func(c client.Client) prometheus.Collector {
+			return newProvisioningUnderwayInstallRestartsCollector(c, 1, nil, nil, "", false, []string{"AWSInsufficientCapacity"})
+		},
+		cases: []collectorCase{{
+			name:     "additional reasons",
+			existing: reasons,
+			expected: []string{
+				"cluster_deployment = cd-1 cluster_type = unspecified condition = ProvisionFailed image_set = none namespace = cd-1 platform =  reason = ProvisionStopped 1",
+				"cluster_deployment = cd-2 cluster_type = unspecified condition = ProvisionFailed image_set = none namespace = cd-2 platform =  reason = AWSInsufficientCapacity 1",
+				"cluster_deployment = cd-3 cluster_type = unspecified condition = ProvisionFailed image_set = none namespace = cd-3 platform =  reason = Other 1",
+			},
+		}},
+	}})
+}
+
+func TestProvisioningUnderwaySetMinDuration(t *testing.T) {
+	scheme := scheme.GetScheme()
+
+	cdBuilder := func(name string, age time.Duration) testcd.Builder {
+		return testcd.FullBuilder(name, name, scheme).
+			GenericOptions(testgeneric.WithCreationTimestamp(testNow.Add(-age)))
+	}
+	c := testfake.NewFakeClientBuilder().WithRuntimeObjects(
+		cdBuilder("cd-1", 30*time.Minute).Build(),
+		cdBuilder("cd-2", 2*time.Hour).Build(),
+	).Build()
+	collect := newProvisioningUnderwaySecondsCollector(c, provisioningUnderwayOptions{minimum: time.Hour}).(provisioningUnderwayCollector)
+	collect.clock = clocktesting.NewFakePassiveClock(testNow)
+
+	assert.Equal(t, time.Hour, collect.MinDuration())
+	assert.Equal(t, []string{
+		"cluster_deployment = cd-2 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-2 platform =  reason = Unknown 7200",
+	}, collectMetrics(t, collect, metricPrettyWithValue))
+
+	// Copies of the collector, such as the one registered, see the change.
+	var registered prometheus.Collector = collect
+	registered.(provisioningUnderwayCollector).SetMinDuration(15 * time.Minute)
+	assert.Equal(t, 15*time.Minute, collect.MinDuration())
+	assert.ElementsMatch(t, []string{
+		"cluster_deployment = cd-1 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-1 platform =  reason = Unknown 1800",
+		"cluster_deployment = cd-2 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-2 platform =  reason = Unknown 7200",
+	}, collectMetrics(t, collect, metricPrettyWithValue))
+}
+
+func TestProvisioningUnderwayInstallRestartsHistogram(t *testing.T) {
+	scheme := scheme.GetScheme()
+
+	cdBuilder := func(name string) testcd.Builder {
+		return testcd.FullBuilder(name, name, scheme)
+	}
+
+	c := testfake.NewFakeClientBuilder().WithRuntimeObjects(
+		cdBuilder("cd-1").Build(),
+		cdBuilder("cd-2").Build(testcd.InstallRestarts(1)),
+		cdBuilder("cd-3").Build(testcd.InstallRestarts(2)),
+		cdBuilder("cd-4").Build(testcd.InstallRestarts(3)),
+		cdBuilder("cd-5").Build(testcd.InstallRestarts(5)),
+		cdBuilder("cd-6").Build(testcd.InstallRestarts(20)),
+		// Installed and deleted clusters are not observed.
+		cdBuilder("cd-7").Build(testcd.Installed(), testcd.InstallRestarts(1)),
+		cdBuilder("cd-8").GenericOptions(testgeneric.Deleted(), testgeneric.WithFinalizer(testFinalizer)).Build(testcd.InstallRestarts(1)),
+	).Build()
+
+	// The per-cluster gauges are unaffected by the histogram, which observes clusters regardless of min.
+	collect := newProvisioningUnderwayInstallRestartsCollector(c, 5, nil, nil, "", true, nil)
+	var histogram *dto.Histogram
+	var gauges []string
+	for _, m := range collectMetricsRaw(t, collect) {
+		if m.Histogram != nil {
+			require.Nil(t, histogram, "expected a single histogram")
+			histogram = m.Histogram
+			continue
+		}
+		gauges = append(gauges, metricPrettyWithValue(m))
+	}
+	assert.Equal(t, []string{
+		"cluster_deployment = cd-5 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-5 platform =  reason = Unknown 5",
+		"cluster_deployment = cd-6 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-6 platform =  reason = Unknown 20",
+	}, gauges)
+	require.NotNil(t, histogram, "expected the install restarts histogram")
+	assert.Equal(t, uint64(6), histogram.GetSampleCount(), "unexpected sample count")
+	assert.Equal(t, float64(31), histogram.GetSampleSum(), "unexpected sample sum")
+	buckets := map[float64]uint64{}
+	for _, b := range histogram.GetBucket() {
+		buckets[b.GetUpperBound()] = b.GetCumulativeCount()
+	}
+	assert.Equal(t, map[float64]uint64{0: 1, 1: 2, 2: 3, 4: 4, 8: 5, 16: 5}, buckets)
+
+	// Without the option, only the per-cluster gauges are reported.
+	collect = newProvisioningUnderwayInstallRestartsCollector(c, 5, nil, nil, "", false, nil)
+	for _, m := range collectMetricsRaw(t, collect) {
+		assert.Nil(t, m.Histogram, "unexpected histogram")
+	}
+}
+
+func TestProvisioningUnderwayInstallRestartsSetMinRestarts(t *testing.T) {
+	scheme := scheme.GetScheme()
+
+	cdBuilder := func(name string) testcd.Builder {
+		return testcd.FullBuilder(name, name, scheme)
+	}
+	c := testfake.NewFakeClientBuilder().WithRuntimeObjects(
+		cdBuilder("cd-1").Build(testcd.InstallRestarts(1)),
+		cdBuilder("cd-2").Build(testcd.InstallRestarts(3)),
+		cdBuilder("cd-3").Build(testcd.InstallRestarts(5)),
+	).Build()
+	clusterDeployments := func(collect prometheus.Collector) []string {
+		var got []string
+		for _, m := range collectMetricsRaw(t, collect) {
+			for _, label := range m.Label {
+				if label.GetName() == "cluster_deployment" {
+					got = append(got, label.GetValue())
+				}
+			}
+		}
+		return got
+	}
+
+	// The collector returned by NewCollectors can be changed while it is registered.
+	collectors := NewCollectors(c, MetricsConfig{InstallRestarts: true, InstallRestartsMin: 5})
+	require.Len(t, collectors, 1)
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(collectors[0]))
+	collect := collectors[0].(timedCollector).Unwrap().(provisioningUnderwayInstallRestartsCollector)
+
+	assert.Equal(t, 5, collect.MinRestarts())
+	assert.ElementsMatch(t, []string{"cd-3"}, clusterDeployments(collectors[0]))
+
+	collect.SetMinRestarts(2)
+	assert.Equal(t, 2, collect.MinRestarts())
+	assert.ElementsMatch(t, []string{"cd-2", "cd-3"}, clusterDeployments(collectors[0]))
+
+	collect.SetMinRestarts(0)
+	assert.ElementsMatch(t, []string{"cd-1", "cd-2", "cd-3"}, clusterDeployments(collectors[0]))
+}
@@ -2,23 +2,33 @@ package metrics
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
 	hiveintv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
+	"github.com/openshift/hive/pkg/constants"
 	testcd "github.com/openshift/hive/pkg/test/clusterdeployment"
 	testcs "github.com/openshift/hive/pkg/test/clustersync"
+	testcm "github.com/openshift/hive/pkg/test/configmap"
 	testfake "github.com/openshift/hive/pkg/test/fake"
 	testgeneric "github.com/openshift/hive/pkg/test/generic"
 	"github.com/openshift/hive/pkg/util/scheme"
@@ -33,14 +43,16 @@ func TestProvisioningUnderwayCollector(t *testing.T) {
 
 	cdBuilder := func(name string) testcd.Builder {
 		return testcd.FullBuilder(name, name, scheme).
-			GenericOptions(testgeneric.WithCreationTimestamp(time.Now().Add(-2 * time.Hour)))
+			GenericOptions(testgeneric.WithCreationTimestamp(testNow.Add(-2 * time.Hour)))
 	}
 
 	cases := []struct {
 		name string
 
-		existing []runtime.Object
-		min      time.Duration
+		existing           []runtime.Object
+		min                time.Duration
+		overrides          map[hivev1.ClusterDeploymentConditionType]time.Duration
+		excludedNamespaces []string
 
 		expected []string
 	}{{
@@ -203,7 +215,7 @@ func TestProvisioningUnderwayCollector(t *testing.T) {
 		name: "provisioning with no conditions and duration less than min duration",
 		existing: []runtime.Object{
 			cdBuilder("cd-1").Build(testcd.Installed()),
-			cdBuilder("cd-2").GenericOptions(testgeneric.WithCreationTimestamp(time.Now().Add(-30 * time.Minute))).Build(),
+			cdBuilder("cd-2").GenericOptions(testgeneric.WithCreationTimestamp(testNow.Add(-30 * time.Minute))).Build(),
 		},
 		min: 1 * time.Hour,
 	}, {
@@ -211,7 +223,7 @@ func TestProvisioningUnderwayCollector(t *testing.T) {
 		existing: []runtime.Object{
 			cdBuilder("cd-1").Build(testcd.Installed()),
 			cdBuilder("cd-2").
-				GenericOptions(testgeneric.WithCreationTimestamp(time.Now().Add(-30 * time.Minute))).
+				GenericOptions(testgeneric.WithCreationTimestamp(testNow.Add(-30 * time.Minute))).
 				Build(testcd.WithCondition(hivev1.ClusterDeploymentCondition{
 					Type:   hivev1.ClusterHibernatingCondition,
 					Status: corev1.ConditionTrue,
@@ -223,7 +235,7 @@ func TestProvisioningUnderwayCollector(t *testing.T) {
 		existing: []runtime.Object{
 			cdBuilder("cd-1").Build(testcd.Installed()),
 			cdBuilder("cd-2").
-				GenericOptions(testgeneric.WithCreationTimestamp(time.Now().Add(-30 * time.Minute))).
+				GenericOptions(testgeneric.WithCreationTimestamp(testNow.Add(-30 * time.Minute))).
 				Build(testcd.WithCondition(hivev1.ClusterDeploymentCondition{
 					Type:   hivev1.ProvisionFailedCondition,
 					Status: corev1.ConditionTrue,
@@ -236,7 +248,7 @@ func TestProvisioningUnderwayCollector(t *testing.T) {
 		existing: []runtime.Object{
 			cdBuilder("cd-1").Build(testcd.Installed()),
 			cdBuilder("cd-2").
-				GenericOptions(testgeneric.WithCreationTimestamp(time.Now().Add(-30 * time.Minute))).
+				GenericOptions(testgeneric.WithCreationTimestamp(testNow.Add(-30 * time.Minute))).
 				Build(testcd.WithCondition(hivev1.ClusterDeploymentCondition{
 					Type:   hivev1.ProvisionFailedCondition,
 					Status: corev1.ConditionTrue,
@@ -252,11 +264,90 @@ func TestProvisioningUnderwayCollector(t *testing.T) {
 		expected: []string{
 			"cluster_deployment = cd-3 cluster_type = unspecified condition = DNSNotReady image_set = none namespace = cd-3 platform =  reason = FailedDueToQuotas",
 		},
+	}, {
+		name: "per-condition overrides mixed with global min duration",
+		existing: []runtime.Object{
+			cdBuilder("cd-1").
+				GenericOptions(testgeneric.WithCreationTimestamp(testNow.Add(-30 * time.Minute))).
+				Build(testcd.WithCondition(hivev1.ClusterDeploymentCondition{
+					Type:   hivev1.ProvisionFailedCondition,
+					Status: corev1.ConditionTrue,
+					Reason: "FailedDueToQuotas",
+				})),
+			cdBuilder("cd-2").Build(testcd.WithCondition(hivev1.ClusterDeploymentCondition{
+				Type:   hivev1.DNSNotReadyCondition,
+				Status: corev1.ConditionTrue,
+				Reason: "FailedDueToQuotas",
+			})),
+			cdBuilder("cd-3").
+				GenericOptions(testgeneric.WithCreationTimestamp(testNow.Add(-30 * time.Minute))).
+				Build(testcd.WithCondition(hivev1.ClusterDeploymentCondition{
+					Type:   hivev1.DNSNotReadyCondition,
+					Status: corev1.ConditionTrue,
+					Reason: "FailedDueToQuotas",
+				})),
+			cdBuilder("cd-4").
+				GenericOptions(testgeneric.WithCreationTimestamp(testNow.Add(-30 * time.Minute))).
+				Build(),
+			cdBuilder("cd-5").
+				GenericOptions(testgeneric.WithCreationTimestamp(testNow.Add(-90 * time.Minute))).
+				Build(testcd.WithCondition(hivev1.ClusterDeploymentCondition{
+					Type:   hivev1.RequirementsMetCondition,
+					Status: corev1.ConditionFalse,
+					Reason: "ClusterImageSetNotFound",
+				})),
+		},
+		min: 1 * time.Hour,
+		overrides: map[hivev1.ClusterDeploymentConditionType]time.Duration{
+			hivev1.ProvisionFailedCondition: 10 * time.Minute,
+			hivev1.DNSNotReadyCondition:     3 * time.Hour,
+			hivev1.RequirementsMetCondition: 2 * time.Hour,
+		},
+		expected: []string{
+			"cluster_deployment = cd-1 cluster_type = unspecified condition = ProvisionFailed image_set = none namespace = cd-1 platform =  reason = FailedDueToQuotas",
+		},
+	}, {
+		name: "per-condition override of zero disables min duration for that condition",
+		existing: []runtime.Object{
+			cdBuilder("cd-1").
+				GenericOptions(testgeneric.WithCreationTimestamp(testNow.Add(-30 * time.Minute))).
+				Build(testcd.WithCondition(hivev1.ClusterDeploymentCondition{
+					Type:   hivev1.DNSNotReadyCondition,
+					Status: corev1.ConditionTrue,
+					Reason: "FailedDueToQuotas",
+				})),
+			cdBuilder("cd-2").
+				GenericOptions(testgeneric.WithCreationTimestamp(testNow.Add(-30 * time.Minute))).
+				Build(),
+			cdBuilder("cd-3").Build(),
+		},
+		min: 1 * time.Hour,
+		overrides: map[hivev1.ClusterDeploymentConditionType]time.Duration{
+			hivev1.DNSNotReadyCondition: 0,
+		},
+		expected: []string{
+			"cluster_deployment = cd-1 cluster_type = unspecified condition = DNSNotReady image_set = none namespace = cd-1 platform =  reason = FailedDueToQuotas",
+			"cluster_deployment = cd-3 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-3 platform =  reason = Unknown",
+		},
+	}, {
+		name: "excluded namespaces",
+		existing: []runtime.Object{
+			cdBuilder("ci-1").Build(),
+			cdBuilder("ci-2").Build(),
+			cdBuilder("prod-1").Build(),
+			cdBuilder("scratch").Build(),
+		},
+		min:                1 * time.Hour,
+		excludedNamespaces: []string{"ci-*", "scratch"},
+		expected: []string{
+			"cluster_deployment = prod-1 cluster_type = unspecified condition = Unknown image_set = none namespace = prod-1 platform =  reason = Unknown",
+		},
 	}}
 	for _, test := range cases {
 		t.Run(test.name, func(t *testing.T) {
 			c := testfake.NewFakeClientBuilder().WithRuntimeObjects(test.existing...).Build()
-			collect := newProvisioningUnderwaySecondsCollector(c, test.min)
+			collect := newProvisioningUnderwaySecondsCollector(c, provisioningUnderwayOptions{minimum: test.min, minimumByCondition: test.overrides, excludedNamespaces: test.excludedNamespaces, additionalReasons: []string{"ClusterImageSetNotFound", "FailedDueToQuotas"}}).(provisioningUnderwayCollector)
+			collect.clock = clocktesting.NewFakePassiveClock(testNow)
 			// TODO: Determine whether collect.Describe() is necessary in test cases
 			descCh := make(chan *prometheus.Desc)
 			go func() {
@@ -292,8 +383,9 @@ func TestProvisioningUnderwayInstallRestartsCollector(t *testing.T) {
 	cases := []struct {
 		name string
 
-		existing []runtime.Object
-		min      int
+		existing           []runtime.Object
+		min                int
+		excludedNamespaces []string
 
 		expected []string
 	}{{
@@ -544,11 +636,34 @@ func TestProvisioningUnderwayInstallRestartsCollector(t *testing.T) {
 		expected: []string{
 			"cluster_deployment = cd-3 cluster_type = unspecified condition = DNSNotReady image_set = none namespace = cd-3 platform =  reason = FailedDueToQuotas 2",
 		},
+	}, {
+		name: "restarts equal to min restarts",
+		existing: []runtime.Object{
+			cdBuilder("cd-1").Build(testcd.InstallRestarts(2)),
+			cdBuilder("cd-2").Build(testcd.InstallRestarts(3)),
+			cdBuilder("cd-3").Build(testcd.InstallRestarts(4)),
+		},
+		min: 3,
+		expected: []string{
+			"cluster_deployment = cd-2 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-2 platform =  reason = Unknown 3",
+			"cluster_deployment = cd-3 cluster_type = unspecified condition = Unknown image_set = none namespace = cd-3 platform =  reason = Unknown 4",
+		},
+	}, {
+		name: "excluded namespaces",
+		existing: []runtime.Object{
+			cdBuilder("ci-1").Build(testcd.InstallRestarts(2)),
+			cdBuilder("ci-2").Build(testcd.InstallRestarts(2)),
+			cdBuilder("prod-1").Build(testcd.InstallRestarts(2)),
+		},
+		excludedNamespaces: []string{"ci-*"},
+		expected: []string{
+			"cluster_deployment = prod-1 cluster_type = unspecified condition = Unknown image_set = none namespace = prod-1 platform =  reason = Unknown 2",
+		},
 	}}
 	for _, test := range cases {
 		t.Run(test.name, func(t *testing.T) {
 			c := testfake.NewFakeClientBuilder().WithRuntimeObjects(test.existing...).Build()
-			collect := newProvisioningUnderwayInstallRestartsCollector(c, test.min)
+			collect := newProvisioningUnderwayInstallRestartsCollector(c, test.min, test.excludedNamespaces, nil, "", false, []string{"FailedDueToQuotas"})
 			// TODO: Determine whether collect.Describe() is necessary in test cases
 			descCh := make(chan *prometheus.Desc)
 			go func() {
@@ -574,21 +689,105 @@ func TestProvisioningUnderwayInstallRestartsCollector(t *testing.T) {
 	}
 }
 
+func TestIncludeClusterTypes(t *testing.T) {
+	scheme := scheme.GetScheme()
+
+	cdBuilder := func(name, clusterType string) testcd.Builder {
+		b := testcd.FullBuilder(name, name, scheme)
+		if clusterType != "" {
+			b = b.GenericOptions(testgeneric.WithLabel(hivev1.HiveClusterTypeLabel, clusterType))
+		}
+		return b
+	}
+	deleted := func(b testcd.Builder) testcd.Builder {
+		return b.GenericOptions(testgeneric.Deleted(), testgeneric.WithFinalizer(testFinalizer))
+	}
+	c := testfake.NewFakeClientBuilder().WithRuntimeObjects(
+		cdBuilder("provisioning-unspecified", "").Build(testcd.InstallRestarts(1)),
+		cdBuilder("provisioning-prod", "prod").Build(testcd.InstallRestarts(1)),
+		cdBuilder("provisioning-sandbox", "sandbox").Build(testcd.InstallRestarts(1)),
+		deleted(cdBuilder("deprovisioning-unspecified", "")).Build(),
+		deleted(cdBuilder("deprovisioning-prod", "prod")).Build(),
+		deleted(cdBuilder("deprovisioning-sandbox", "sandbox")).Build(),
+	).Build()
+
+	clusterDeployments := func(collect prometheus.Collector) []string {
+		var got []string
+		for _, m := range collectMetricsRaw(t, collect) {
+			for _, label := range m.Label {
+				if label.GetName() == "cluster_deployment" {
+					got = append(got, label.GetValue())
+				}
+			}
+		}
+		return got
+	}
+
+	cases := []struct {
+		name string
+
+		includeClusterTypes []string
+
+		expectedProvisioning   []string
+		expectedDeprovisioning []string
+	}{{
+		name:                   "no filter",
+		expectedProvisioning:   []string{"provisioning-unspecified", "provisioning-prod", "provisioning-sandbox"},
+		expectedDeprovisioning: []string{"deprovisioning-unspecified", "deprovisioning-prod", "deprovisioning-sandbox"},
+	}, {
+		name:                   "single type",
+		includeClusterTypes:    []string{"prod"},
+		expectedProvisioning:   []string{"provisioning-prod"},
+		expectedDeprovisioning: []string{"deprovisioning-prod"},
+	}, {
+		name:                   "unspecified type",
+		includeClusterTypes:    []string{"sandbox", "unspecified"},
+		expectedProvisioning:   []string{"provisioning-unspecified", "provisioning-sandbox"},
+		expectedDeprovisioning: []string{"deprovisioning-unspecified", "deprovisioning-sandbox"},
+	}, {
+		name:                "no matching type",
+		includeClusterTypes: []string{"staging"},
+	}}
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			collect := newProvisioningUnderwaySecondsCollector(c, provisioningUnderwayOptions{includeClusterTypes: test.includeClusterTypes})
+			assert.ElementsMatch(t, test.expectedProvisioning, clusterDeployments(collect), "unexpected provision underway seconds")
+			collect = newProvisioningUnderwayInstallRestartsCollector(c, 1, nil, test.includeClusterTypes, "", false, nil)
+			assert.ElementsMatch(t, test.expectedProvisioning, clusterDeployments(collect), "unexpected provision underway install restarts")
+			collect = newDeprovisioningUnderwaySecondsCollector(c, deprovisioningUnderwayOptions{includeClusterTypes: test.includeClusterTypes})
+			assert.ElementsMatch(t, test.expectedDeprovisioning, clusterDeployments(collect), "unexpected deprovision underway seconds")
+		})
+	}
+}
+
+func TestNewClusterTypeLabel(t *testing.T) {
+	assert.Equal(t, clusterTypeLabel(hivev1.HiveClusterTypeLabel), newClusterTypeLabel(""))
+	assert.Equal(t, clusterTypeLabel("tier"), newClusterTypeLabel("tier"))
+	assert.Equal(t, clusterTypeLabel("acme.com/tier"), newClusterTypeLabel("acme.com/tier"))
+	for _, key := range []string{"acme.com/", "acme.com/tier/gold", "-tier", "tier!"} {
+		assert.Panics(t, func() { newClusterTypeLabel(key) }, "expected %q to be rejected", key)
+	}
+}
+
 func TestDeprovisioningUnderwayCollector(t *testing.T) {
 	scheme := scheme.GetScheme()
 
 	cdBuilder := func(name string) testcd.Builder {
 		return testcd.FullBuilder(name, name, scheme).
-			GenericOptions(testgeneric.WithFinalizer(testFinalizer))
+			GenericOptions(testgeneric.WithFinalizer(hivev1.FinalizerDeprovision), testgeneric.WithFinalizer(testFinalizer))
 	}
 
 	cases := []struct {
 		name string
 
-		existing []runtime.Object
+		existing           []runtime.Object
+		excludedNamespaces []string
 
-		expected1 []string
-		expected2 []string
+		// expected1 is reported with all finalizers in place, expectedHiveOnly once only Hive's finalizer is left,
+		// and expected2 once no finalizers are left.
+		expected1        []string
+		expectedHiveOnly []string
+		expected2        []string
 	}{
 		{
 			name: "all cluster deployment deletion timestamps set",
@@ -598,9 +797,27 @@ func TestDeprovisioningUnderwayCollector(t *testing.T) {
 				cdBuilder("cd-3").GenericOptions(testgeneric.Deleted()).Build(),
 			},
 			expected1: []string{
-				"cluster_deployment = cd-1 cluster_type = unspecified namespace = cd-1",
-				"cluster_deployment = cd-2 cluster_type = unspecified namespace = cd-2",
-				"cluster_deployment = cd-3 cluster_type = unspecified namespace = cd-3",
+				"blocked_on = test-finalizer cluster_deployment = cd-1 cluster_type = unspecified namespace = cd-1",
+				"blocked_on = test-finalizer cluster_deployment = cd-2 cluster_type = unspecified namespace = cd-2",
+				"blocked_on = test-finalizer cluster_deployment = cd-3 cluster_type = unspecified namespace = cd-3",
+			},
+			expectedHiveOnly: []string{
+				"blocked_on = none cluster_deployment = cd-1 cluster_type = unspecified namespace = cd-1",
+				"blocked_on = none cluster_deployment = cd-2 cluster_type = unspecified namespace = cd-2",
+				"blocked_on = none cluster_deployment = cd-3 cluster_type = unspecified namespace = cd-3",
+			},
+			expected2: []string(nil),
+		},
+		{
+			name: "several foreign finalizers",
+			existing: []runtime.Object{
+				cdBuilder("cd-1").GenericOptions(testgeneric.Deleted(), testgeneric.WithFinalizer("example.com/backup")).Build(),
+			},
+			expected1: []string{
+				"blocked_on = example.com/backup,test-finalizer cluster_deployment = cd-1 cluster_type = unspecified namespace = cd-1",
+			},
+			expectedHiveOnly: []string{
+				"blocked_on = none cluster_deployment = cd-1 cluster_type = unspecified namespace = cd-1",
 			},
 			expected2: []string(nil),
 		},
@@ -618,8 +835,28 @@ func TestDeprovisioningUnderwayCollector(t *testing.T) {
 				cdBuilder("cd-3").GenericOptions(testgeneric.Deleted()).Build(),
 			},
 			expected1: []string{
-				"cluster_deployment = cd-1 cluster_type = unspecified namespace = cd-1",
-				"cluster_deployment = cd-3 cluster_type = unspecified namespace = cd-3",
+				"blocked_on = test-finalizer cluster_deployment = cd-1 cluster_type = unspecified namespace = cd-1",
+				"blocked_on = test-finalizer cluster_deployment = cd-3 cluster_type = unspecified namespace = cd-3",
+			},
+			expectedHiveOnly: []string{
+				"blocked_on = none cluster_deployment = cd-1 cluster_type = unspecified namespace = cd-1",
+				"blocked_on = none cluster_deployment = cd-3 cluster_type = unspecified namespace = cd-3",
+			},
+			expected2: []string(nil),
+		},
+		{
+			name: "excluded namespaces",
+			existing: []runtime.Object{
+				cdBuilder("ci-1").GenericOptions(testgeneric.Deleted()).Build(),
+				cdBuilder("ci-2").GenericOptions(testgeneric.Deleted()).Build(),
+				cdBuilder("prod-1").GenericOptions(testgeneric.Deleted()).Build(),
+			},
+			excludedNamespaces: []string{"ci-?"},
+			expected1: []string{
+				"blocked_on = test-finalizer cluster_deployment = prod-1 cluster_type = unspecified namespace = prod-1",
+			},
+			expectedHiveOnly: []string{
+				"blocked_on = none cluster_deployment = prod-1 cluster_type = unspecified namespace = prod-1",
 			},
 			expected2: []string(nil),
 		},
@@ -627,7 +864,8 @@ func TestDeprovisioningUnderwayCollector(t *testing.T) {
 	for _, test := range cases {
 		t.Run(test.name, func(t *testing.T) {
 			c := testfake.NewFakeClientBuilder().WithRuntimeObjects(test.existing...).Build()
-			collect := newDeprovisioningUnderwaySecondsCollector(c)
+			collect := newDeprovisioningUnderwaySecondsCollector(c, deprovisioningUnderwayOptions{excludedNamespaces: test.excludedNamespaces}).(deprovisioningUnderwayCollector)
+			collect.clock = clocktesting.NewFakePassiveClock(testNow)
 			// TODO: Determine whether collect.Describe() is necessary in test cases
 			descCh := make(chan *prometheus.Desc)
 			go func() {
@@ -652,6 +890,17 @@ func TestDeprovisioningUnderwayCollector(t *testing.T) {
 			assert.Equal(t, test.expected1, got1)
 
 			cdList := &hivev1.ClusterDeploymentList{}
+			require.NoError(t, c.List(context.TODO(), cdList))
+			for _, cd := range cdList.Items {
+				cd.ObjectMeta.Finalizers = []string{hivev1.FinalizerDeprovision}
+				require.NoError(t, c.Update(context.TODO(), &cd))
+			}
+			var gotHiveOnly []string
+			for _, d := range collectMetricsRaw(t, collect) {
+				gotHiveOnly = append(gotHiveOnly, metricPretty(d))
+			}
+			assert.Equal(t, test.expectedHiveOnly, gotHiveOnly)
+
 			require.NoError(t, c.List(context.TODO(), cdList))
 			for _, cd := range cdList.Items {
 				cd.ObjectMeta.Finalizers = nil
@@ -674,6 +923,66 @@ func TestDeprovisioningUnderwayCollector(t *testing.T) {
 	}
 }
 
+func TestPausedClusters(t *testing.T) {
+	scheme := scheme.GetScheme()
+
+	cdBuilder := func(name string) testcd.Builder {
+		return testcd.FullBuilder(name, name, scheme).GenericOptions(
+			testgeneric.WithCreationTimestamp(testNow.Add(-3*time.Hour)),
+			testgeneric.WithFinalizer(testFinalizer),
+		)
+	}
+	paused := testgeneric.WithAnnotation(constants.ReconcilePauseAnnotation, "true")
+	unreachable := testcd.WithCondition(hivev1.ClusterDeploymentCondition{
+		Type:               hivev1.UnreachableCondition,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(testNow.Add(-time.Hour)),
+	})
+
+	c := testfake.NewFakeClientBuilder().WithRuntimeObjects(
+		cdBuilder("provisioning").Build(),
+		// Paused clusters are otherwise past the provisioning threshold, or degraded, or deprovisioning.
+		cdBuilder("provisioning-paused").GenericOptions(paused).Build(),
+		cdBuilder("degraded-paused").GenericOptions(paused).Build(testcd.Installed(), unreachable),
+		cdBuilder("deprovisioning").GenericOptions(testgeneric.Deleted()).Build(),
+		cdBuilder("deprovisioning-paused").GenericOptions(paused, testgeneric.Deleted()).Build(),
+		// Annotation values that don't parse as true don't pause the cluster.
+		cdBuilder("provisioning-unpaused").GenericOptions(testgeneric.WithAnnotation(constants.ReconcilePauseAnnotation, "no")).Build(),
+	).Build()
+	collect := func(includePaused bool) []string {
+		provisioning := newProvisioningUnderwaySecondsCollector(c, provisioningUnderwayOptions{minimum: time.Hour, postInstallDegraded: true, includePaused: includePaused}).(provisioningUnderwayCollector)
+		provisioning.clock = clocktesting.NewFakePassiveClock(testNow)
+		deprovisioning := newDeprovisioningUnderwaySecondsCollector(c, deprovisioningUnderwayOptions{includePaused: includePaused}).(deprovisioningUnderwayCollector)
+		deprovisioning.clock = clocktesting.NewFakePassiveClock(testNow)
+		clusterDeployment := func(m *dto.Metric) string {
+			var name, pausedLabel string
+			for _, label := range m.Label {
+				switch label.GetName() {
+				case "cluster_deployment":
+					name = label.GetValue()
+				case "paused":
+					pausedLabel = " paused = " + label.GetValue()
+				}
+			}
+			return name + pausedLabel
+		}
+		return append(collectMetrics(t, provisioning, clusterDeployment), collectMetrics(t, deprovisioning, clusterDeployment)...)
+	}
+
+	assert.ElementsMatch(t, []string{
+		"provisioning",
+		"provisioning-unpaused",
+		"deprovisioning",
+	}, collect(false), "expected paused clusters to be skipped by default")
+	assert.ElementsMatch(t, []string{
+		"provisioning paused = false",
+		"provisioning-paused paused = true",
+		"provisioning-unpaused paused = false",
+		"degraded-paused paused = true",
+		"deprovisioning paused = false",
+		"deprovisioning-paused paused = true",
+	}, collect(true), "expected paused clusters to be labelled when included")
+}
 func TestClusterSyncCollector(t *testing.T) {
 	scheme := scheme.GetScheme()
 
@@ -684,17 +993,21 @@ func TestClusterSyncCollector(t *testing.T) {
 	cases := []struct {
 		name string
 
-		existing       []runtime.Object
-		min            time.Duration
-		optionalLabels map[string]string
+		existing           []runtime.Object
+		min                time.Duration
+		optionalLabels     map[string]string
+		excludedNamespaces []string
 
 		expected1 []string
 		expected2 []string
+		// expectedTotal and expectedOldest are the aggregate series reported alongside expected1.
+		expectedTotal  int
+		expectedOldest time.Duration
 	}{
 		{
 			name: "clustersync did not pass threshold",
 			existing: []runtime.Object{
-				testcs.FullBuilder("test-namespace", "test-name", scheme).Options(FailingSince(time.Now())).Build(),
+				testcs.FullBuilder("test-namespace", "test-name", scheme).Options(FailingSince(testNow)).Build(),
 			},
 			min:            1 * time.Hour,
 			optionalLabels: map[string]string{},
@@ -704,7 +1017,7 @@ func TestClusterSyncCollector(t *testing.T) {
 		{
 			name: "clustersync passed threshold",
 			existing: []runtime.Object{
-				testcs.FullBuilder("test-namespace", "test-name", scheme).Options(FailingSince(time.Now())).Build(),
+				testcs.FullBuilder("test-namespace", "test-name", scheme).Options(FailingSince(testNow)).Build(),
 				cdBuilder("test-namespace", "test-name").Build(testcd.WithCondition(hivev1.ClusterDeploymentCondition{
 					Type:   hivev1.UnreachableCondition,
 					Status: corev1.ConditionUnknown,
@@ -714,6 +1027,7 @@ func TestClusterSyncCollector(t *testing.T) {
 			optionalLabels: map[string]string{},
 			expected1:      []string{"namespaced_name = test-namespace/test-name unreachable = Unknown"},
 			expected2:      []string(nil),
+			expectedTotal:  1,
 		},
 		{
 			name:           "no clustersync",
@@ -726,7 +1040,7 @@ func TestClusterSyncCollector(t *testing.T) {
 		{
 			name: "report expected fixed labels",
 			existing: []runtime.Object{
-				testcs.FullBuilder("test-namespace", "test-name", scheme).Options(FailingSince(time.Now())).Build(),
+				testcs.FullBuilder("test-namespace", "test-name", scheme).Options(FailingSince(testNow)).Build(),
 				cdBuilder("test-namespace", "test-name").Build(testcd.WithCondition(hivev1.ClusterDeploymentCondition{
 					Type:   hivev1.UnreachableCondition,
 					Status: corev1.ConditionTrue,
@@ -736,32 +1050,35 @@ func TestClusterSyncCollector(t *testing.T) {
 			optionalLabels: map[string]string{},
 			expected1:      []string{"namespaced_name = test-namespace/test-name unreachable = True"},
 			expected2:      []string(nil),
+			expectedTotal:  1,
 		},
 		{
 			name: "report  fixed labels; no unreachable condition",
 			existing: []runtime.Object{
-				testcs.FullBuilder("test-namespace", "test-name", scheme).Options(FailingSince(time.Now())).Build(),
+				testcs.FullBuilder("test-namespace", "test-name", scheme).Options(FailingSince(testNow)).Build(),
 				cdBuilder("test-namespace", "test-name").Build(),
 			},
 			min:            0 * time.Hour,
 			optionalLabels: map[string]string{},
 			expected1:      []string{"namespaced_name = test-namespace/test-name unreachable = unspecified"},
 			expected2:      []string(nil),
+			expectedTotal:  1,
 		},
 		{
 			name: "report when ClusterDeployment not found",
 			existing: []runtime.Object{
-				testcs.FullBuilder("test-namespace", "test-name", scheme).Options(FailingSince(time.Now())).Build(),
+				testcs.FullBuilder("test-namespace", "test-name", scheme).Options(FailingSince(testNow)).Build(),
 			},
 			min:            0 * time.Hour,
 			optionalLabels: map[string]string{},
 			expected1:      []string{"namespaced_name = test-namespace/test-name unreachable = unspecified"},
 			expected2:      []string(nil),
+			expectedTotal:  1,
 		},
 		{
 			name: "report optional metrics",
 			existing: []runtime.Object{
-				testcs.FullBuilder("test-namespace", "test-name", scheme).Options(FailingSince(time.Now())).Build(),
+				testcs.FullBuilder("test-namespace", "test-name", scheme).Options(FailingSince(testNow)).Build(),
 				cdBuilder("test-namespace", "test-name").Build(
 					testcd.WithLabel("cd-label", "test-value"),
 					testcd.WithCondition(hivev1.ClusterDeploymentCondition{
@@ -773,13 +1090,14 @@ func TestClusterSyncCollector(t *testing.T) {
 			optionalLabels: map[string]string{
 				"test_label": "cd-label",
 			},
-			expected1: []string{"namespaced_name = test-namespace/test-name test_label = test-value unreachable = Unknown"},
-			expected2: []string(nil),
+			expected1:     []string{"namespaced_name = test-namespace/test-name test_label = test-value unreachable = Unknown"},
+			expected2:     []string(nil),
+			expectedTotal: 1,
 		},
 		{
 			name: "report multiple optional metrics",
 			existing: []runtime.Object{
-				testcs.FullBuilder("test-namespace", "test-name", scheme).Options(FailingSince(time.Now())).Build(),
+				testcs.FullBuilder("test-namespace", "test-name", scheme).Options(FailingSince(testNow)).Build(),
 				cdBuilder("test-namespace", "test-name").Build(
 					testcd.WithLabel("cd-label-1", "value-1"),
 					testcd.WithLabel("cd-label-2", "value-2"),
@@ -796,13 +1114,14 @@ func TestClusterSyncCollector(t *testing.T) {
 				"label3": "cd-label-3",
 			},
 			// ensure correct values for all labels are reported
-			expected1: []string{"label1 = value-1 label2 = value-2 label3 = value-3 namespaced_name = test-namespace/test-name unreachable = Unknown"},
-			expected2: []string(nil),
+			expected1:     []string{"label1 = value-1 label2 = value-2 label3 = value-3 namespaced_name = test-namespace/test-name unreachable = Unknown"},
+			expected2:     []string(nil),
+			expectedTotal: 1,
 		},
 		{
 			name: "report multiple optional metrics; no clusterdeployment found",
 			existing: []runtime.Object{
-				testcs.FullBuilder("test-namespace", "test-name", scheme).Options(FailingSince(time.Now())).Build(),
+				testcs.FullBuilder("test-namespace", "test-name", scheme).Options(FailingSince(testNow)).Build(),
 			},
 			min: 0 * time.Hour,
 			optionalLabels: map[string]string{
@@ -811,15 +1130,48 @@ func TestClusterSyncCollector(t *testing.T) {
 				"label3": "cd-label-3",
 			},
 			// ensure correct values for all labels are reported
-			expected1: []string{"label1 = unspecified label2 = unspecified label3 = unspecified namespaced_name = test-namespace/test-name unreachable = unspecified"},
-			expected2: []string(nil),
+			expected1:     []string{"label1 = unspecified label2 = unspecified label3 = unspecified namespaced_name = test-namespace/test-name unreachable = unspecified"},
+			expected2:     []string(nil),
+			expectedTotal: 1,
+		},
+		{
+			name: "excluded namespaces",
+			existing: []runtime.Object{
+				testcs.FullBuilder("ci-1", "test-name", scheme).Options(FailingSince(testNow)).Build(),
+				testcs.FullBuilder("ci-2", "test-name", scheme).Options(FailingSince(testNow)).Build(),
+				testcs.FullBuilder("prod-1", "test-name", scheme).Options(FailingSince(testNow)).Build(),
+			},
+			min:                0 * time.Hour,
+			optionalLabels:     map[string]string{},
+			excludedNamespaces: []string{"ci-[0-9]"},
+			expected1:          []string{"namespaced_name = prod-1/test-name unreachable = unspecified"},
+			expected2:          []string(nil),
+			expectedTotal:      1,
+		},
+		{
+			name: "aggregate failing clustersyncs",
+			existing: []runtime.Object{
+				testcs.FullBuilder("test-namespace", "test-name-1", scheme).Options(FailingSince(testNow.Add(-30 * time.Minute))).Build(),
+				testcs.FullBuilder("test-namespace", "test-name-2", scheme).Options(FailingSince(testNow.Add(-2 * time.Hour))).Build(),
+				testcs.FullBuilder("test-namespace", "test-name-3", scheme).Options(FailingSince(testNow.Add(-3 * time.Hour))).Build(),
+			},
+			min:            1 * time.Hour,
+			optionalLabels: map[string]string{},
+			expected1: []string{
+				"namespaced_name = test-namespace/test-name-2 unreachable = unspecified",
+				"namespaced_name = test-namespace/test-name-3 unreachable = unspecified",
+			},
+			expected2:      []string(nil),
+			expectedTotal:  2,
+			expectedOldest: 3 * time.Hour,
 		},
 	}
 	for _, test := range cases {
 		t.Run("test", func(t *testing.T) {
 			c := testfake.NewFakeClientBuilder().WithRuntimeObjects(test.existing...).Build()
 
-			collect := newClusterSyncFailingCollector(c, test.min, test.optionalLabels)
+			collect := newClusterSyncFailingCollector(c, test.min, test.optionalLabels, test.excludedNamespaces).(clusterSyncFailingCollector)
+			collect.clock = clocktesting.NewFakePassiveClock(testNow)
 			// TODO: Determine whether collect.Describe() is necessary in test cases
 			descCh := make(chan *prometheus.Desc)
 			go func() {
@@ -829,19 +1181,32 @@ func TestClusterSyncCollector(t *testing.T) {
 			collect.Describe(descCh)
 			close(descCh)
 
-			ch1 := make(chan prometheus.Metric)
-			go func() {
-				collect.Collect(ch1)
-				close(ch1)
-			}()
-
-			var got1 []string
-			for sample := range ch1 {
-				var d dto.Metric
-				require.NoError(t, sample.Write(&d))
-				got1 = append(got1, metricPretty(&d))
+			// collectFailing separates the per-ClusterSync series from the aggregate ones.
+			collectFailing := func() (perCS []string, total, oldest float64) {
+				ch := make(chan prometheus.Metric)
+				go func() {
+					collect.Collect(ch)
+					close(ch)
+				}()
+				for sample := range ch {
+					var d dto.Metric
+					require.NoError(t, sample.Write(&d))
+					switch sample.Desc() {
+					case metricClusterSyncFailingTotalDesc.Desc:
+						total = d.GetGauge().GetValue()
+					case metricClusterSyncOldestFailingSecondsDesc.Desc:
+						oldest = d.GetGauge().GetValue()
+					default:
+						perCS = append(perCS, metricPretty(&d))
+					}
+				}
+				return perCS, total, oldest
 			}
+
+			got1, total1, oldest1 := collectFailing()
 			assert.Equal(t, test.expected1, got1)
+			assert.Equal(t, float64(test.expectedTotal), total1, "unexpected failing total")
+			assert.Equal(t, test.expectedOldest.Seconds(), oldest1, "unexpected oldest failing seconds")
 
 			csList := &hiveintv1alpha1.ClusterSyncList{}
 			require.NoError(t, c.List(context.TODO(), csList))
@@ -849,24 +1214,524 @@ func TestClusterSyncCollector(t *testing.T) {
 				require.NoError(t, c.Delete(context.TODO(), &cs))
 			}
 
-			ch2 := make(chan prometheus.Metric)
+			got2, total2, oldest2 := collectFailing()
+			assert.Equal(t, test.expected2, got2)
+			assert.Zero(t, total2, "unexpected failing total")
+			assert.Zero(t, oldest2, "unexpected oldest failing seconds")
+		})
+	}
+}
 
-			var got2 []string
+func TestConstMetricDesc(t *testing.T) {
+	desc := newConstMetricDesc("hive_test_metric", "A test metric.", "version", "namespace", "platform", "cluster_deployment", "image_set", "cluster_type")
+	assert.Equal(t, []string{"cluster_deployment", "cluster_type", "image_set", "namespace", "platform", "version"}, desc.labelNames)
+
+	labels := prometheus.Labels{
+		"version":            "4.14.0",
+		"namespace":          "ns",
+		"platform":           "aws",
+		"cluster_deployment": "cd",
+		"image_set":          "none",
+		"cluster_type":       "managed",
+	}
+	var d dto.Metric
+	require.NoError(t, desc.mustNewConstMetric(prometheus.GaugeValue, 1, labels).Write(&d))
+	got := map[string]string{}
+	for _, label := range d.Label {
+		got[*label.Name] = *label.Value
+	}
+	assert.Equal(t, map[string]string(labels), got, "label values reported against the wrong label names")
+
+	assert.Panics(t, func() {
+		desc.mustNewConstMetric(prometheus.GaugeValue, 1, prometheus.Labels{"namespace": "ns"})
+	}, "expected a panic for missing labels")
+	assert.Panics(t, func() {
+		extra := prometheus.Labels{"unexpected": "value"}
+		for k, v := range labels {
+			extra[k] = v
+		}
+		desc.mustNewConstMetric(prometheus.GaugeValue, 1, extra)
+	}, "expected a panic for unexpected labels")
+}
+
+func TestConstMetricBuffer(t *testing.T) {
+	desc := newConstMetricDesc("hive_test_metric", "A test metric.", "namespace", "cluster_deployment")
+	buffer := desc.newBuffer()
+	labelsOf := func(m prometheus.Metric) map[string]string {
+		var d dto.Metric
+		require.NoError(t, m.Write(&d))
+		labels := map[string]string{}
+		for _, label := range d.Label {
+			labels[*label.Name] = *label.Value
+		}
+		return labels
+	}
+
+	buffer.labels["namespace"] = "ns-1"
+	buffer.labels["cluster_deployment"] = "cd-1"
+	first := buffer.mustNewConstMetric(prometheus.GaugeValue, 1)
+	buffer.labels["namespace"] = "ns-2"
+	buffer.labels["cluster_deployment"] = "cd-2"
+	second := buffer.mustNewConstMetric(prometheus.GaugeValue, 2)
+	assert.Equal(t, map[string]string{"namespace": "ns-1", "cluster_deployment": "cd-1"}, labelsOf(first), "refilling the buffer changed an earlier metric")
+	assert.Equal(t, map[string]string{"namespace": "ns-2", "cluster_deployment": "cd-2"}, labelsOf(second))
+
+	assert.Panics(t, func() {
+		buffer := desc.newBuffer()
+		buffer.labels["namespace"] = "ns"
+		buffer.mustNewConstMetric(prometheus.GaugeValue, 1)
+	}, "expected a panic for missing labels")
+}
+
+func TestNamespaceFilter(t *testing.T) {
+	filter := newNamespaceFilter([]string{"ci-*", "scratch-?", "exact"})
+	for namespace, excluded := range map[string]bool{
+		"ci-1234":   true,
+		"ci-":       true,
+		"scratch-a": true,
+		"scratch-":  false,
+		"exact":     true,
+		"exactly":   false,
+		"prod-ci-1": false,
+	} {
+		assert.Equal(t, excluded, filter.excludes(namespace), "unexpected result for namespace %q", namespace)
+	}
+	assert.False(t, newNamespaceFilter(nil).excludes("any"), "empty filter should not exclude anything")
+	assert.Panics(t, func() { newNamespaceFilter([]string{"ci-["}) }, "expected a panic for a malformed pattern")
+}
+
+// slowReadsClient passes the first fastReads List and Get calls through to the wrapped client and blocks later ones
+// until delay passes or their context is done.
+type slowReadsClient struct {
+	client.Client
+	delay     time.Duration
+	fastReads int
+	reads     int
+}
+
+func (c *slowReadsClient) wait(ctx context.Context) error {
+	c.reads++
+	if c.reads <= c.fastReads {
+		return nil
+	}
+	select {
+	case <-time.After(c.delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *slowReadsClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func (c *slowReadsClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func TestCollectTimeout(t *testing.T) {
+	scheme := scheme.GetScheme()
+
+	defer func(timeout time.Duration) { collectTimeout = timeout }(collectTimeout)
+	collectTimeout = 50 * time.Millisecond
+
+	withManifestsConfigMap := func(cd *hivev1.ClusterDeployment) {
+		cd.Spec.Provisioning = &hivev1.Provisioning{
+			ManifestsConfigMapRef: &corev1.LocalObjectReference{Name: "manifests"},
+		}
+	}
+	existing := []runtime.Object{
+		testcd.FullBuilder("cd-1", "cd-1", scheme).Build(withManifestsConfigMap),
+		testcm.FullBuilder("cd-1", "manifests", scheme).Build(testcm.WithDataKeyValue("manifest.yaml", "kind: ConfigMap")),
+		testcd.FullBuilder("cd-2", "cd-2", scheme).Build(withManifestsConfigMap),
+		testcm.FullBuilder("cd-2", "manifests", scheme).Build(testcm.WithDataKeyValue("manifest.yaml", "kind: ConfigMap")),
+	}
+
+	cases := []struct {
+		name string
+
+		delay     time.Duration
+		fastReads int
+
+		expected         []string
+		expectedTimeouts float64
+	}{{
+		name:  "reads within the timeout",
+		delay: time.Millisecond,
+		expected: []string{
+			"cluster_deployment = cd-1 namespace = cd-1 1",
+			"cluster_deployment = cd-2 namespace = cd-2 1",
+		},
+	}, {
+		name:             "list exceeds the timeout",
+		delay:            time.Hour,
+		expectedTimeouts: 1,
+	}, {
+		name:      "get exceeds the timeout",
+		delay:     time.Hour,
+		fastReads: 2,
+		expected: []string{
+			"cluster_deployment = cd-1 namespace = cd-1 1",
+		},
+		expectedTimeouts: 1,
+	}}
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			c := &slowReadsClient{
+				Client:    testfake.NewFakeClientBuilder().WithRuntimeObjects(existing...).Build(),
+				delay:     test.delay,
+				fastReads: test.fastReads,
+			}
+			timeouts := metricCollectorTimeoutsTotal.WithLabelValues("additionalManifestCountCollector")
+			before := testutil.ToFloat64(timeouts)
+			collect := newAdditionalManifestCountCollector(c, 0)
+
+			// Collect directly rather than through collectMetrics, whose Describe would also read through c.
+			ch := make(chan prometheus.Metric)
 			go func() {
-				collect.Collect(ch2)
-				close(ch2)
+				collect.Collect(ch)
+				close(ch)
 			}()
-			for sample := range ch2 {
+			var got []string
+			for sample := range ch {
 				var d dto.Metric
 				require.NoError(t, sample.Write(&d))
-				got2 = append(got2, metricPretty(&d))
+				got = append(got, metricPrettyWithValue(&d))
 			}
-			assert.Equal(t, test.expected2, got2)
+			assert.Equal(t, test.expected, got)
+			assert.Equal(t, before+test.expectedTimeouts, testutil.ToFloat64(timeouts), "unexpected number of collect timeouts")
+		})
+	}
+}
+
+// failingReadsClient passes the first goodReads List and Get calls through to the wrapped client and fails later ones.
+type failingReadsClient struct {
+	client.Client
+	goodReads int
+	reads     int
+}
 
+func (c *failingReadsClient) read() error {
+	c.reads++
+	if c.reads <= c.goodReads {
+		return nil
+	}
+	return errors.New("read refused")
+}
+
+func (c *failingReadsClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if err := c.read(); err != nil {
+		return err
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func (c *failingReadsClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if err := c.read(); err != nil {
+		return err
+	}
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func TestCollectErrors(t *testing.T) {
+	scheme := scheme.GetScheme()
+
+	withManifestsConfigMap := func(cd *hivev1.ClusterDeployment) {
+		cd.Spec.Provisioning = &hivev1.Provisioning{
+			ManifestsConfigMapRef: &corev1.LocalObjectReference{Name: "manifests"},
+		}
+	}
+	existing := []runtime.Object{
+		testcd.FullBuilder("cd-1", "cd-1", scheme).Build(withManifestsConfigMap),
+		testcm.FullBuilder("cd-1", "manifests", scheme).Build(testcm.WithDataKeyValue("manifest.yaml", "kind: ConfigMap")),
+		testcd.FullBuilder("cd-2", "cd-2", scheme).Build(withManifestsConfigMap),
+		testcm.FullBuilder("cd-2", "manifests", scheme).Build(testcm.WithDataKeyValue("manifest.yaml", "kind: ConfigMap")),
+	}
+
+	cases := []struct {
+		name string
+
+		goodReads int
+
+		expected       []string
+		expectedErrors float64
+	}{{
+		name:      "no errors",
+		goodReads: 3,
+		expected: []string{
+			"cluster_deployment = cd-1 namespace = cd-1 1",
+			"cluster_deployment = cd-2 namespace = cd-2 1",
+		},
+	}, {
+		name:           "list fails",
+		expectedErrors: 1,
+	}, {
+		name:      "get fails",
+		goodReads: 2,
+		expected: []string{
+			"cluster_deployment = cd-1 namespace = cd-1 1",
+		},
+		expectedErrors: 1,
+	}}
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			c := &failingReadsClient{
+				Client:    testfake.NewFakeClientBuilder().WithRuntimeObjects(existing...).Build(),
+				goodReads: test.goodReads,
+			}
+			errs := metricCollectorErrorsTotal.WithLabelValues("additionalManifestCountCollector")
+			timeouts := metricCollectorTimeoutsTotal.WithLabelValues("additionalManifestCountCollector")
+			beforeErrors, beforeTimeouts := testutil.ToFloat64(errs), testutil.ToFloat64(timeouts)
+			collect := newAdditionalManifestCountCollector(c, 0)
+
+			// Collect directly rather than through collectMetrics, whose Describe would also read through c.
+			ch := make(chan prometheus.Metric)
+			go func() {
+				collect.Collect(ch)
+				close(ch)
+			}()
+			var got []string
+			for sample := range ch {
+				var d dto.Metric
+				require.NoError(t, sample.Write(&d))
+				got = append(got, metricPrettyWithValue(&d))
+			}
+			assert.Equal(t, test.expected, got)
+			assert.Equal(t, beforeErrors+test.expectedErrors, testutil.ToFloat64(errs), "unexpected number of collect errors")
+			assert.Equal(t, beforeTimeouts, testutil.ToFloat64(timeouts), "expected errors not to be counted as timeouts")
 		})
 	}
 }
 
+// pagingClient serves List requests a page at a time, as the API server does, and counts them.
+type pagingClient struct {
+	client.Client
+	lists int
+}
+
+func (c *pagingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.lists++
+	if err := c.Client.List(ctx, list, opts...); err != nil {
+		return err
+	}
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if listOpts.Limit == 0 {
+		return nil
+	}
+	items, err := apimeta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	start := 0
+	if listOpts.Continue != "" {
+		if start, err = strconv.Atoi(listOpts.Continue); err != nil {
+			return err
+		}
+	}
+	end := start + int(listOpts.Limit)
+	if end >= len(items) {
+		end = len(items)
+	} else {
+		list.SetContinue(strconv.Itoa(end))
+	}
+	return apimeta.SetList(list, items[start:end])
+}
+
+// pagingTestObjects returns ClusterDeployments in a spread of states, along with related objects, for comparing
+// paged and unpaged collection.
+func pagingTestObjects(count int) []runtime.Object {
+	scheme := scheme.GetScheme()
+	var objs []runtime.Object
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("cd-%d", i)
+		opts := []testcd.Option{
+			testcd.InstallRestarts(i % 4),
+			testcd.WithCondition(hivev1.ClusterDeploymentCondition{
+				Type:   hivev1.ProvisionFailedCondition,
+				Status: corev1.ConditionTrue,
+				Reason: "UnknownError",
+			}),
+		}
+		if i%3 == 0 {
+			opts = append(opts, testcd.Installed())
+		}
+		objs = append(objs,
+			testcd.FullBuilder(name, name, scheme).
+				GenericOptions(testgeneric.WithAnnotation(constants.CreatorAnnotation, fmt.Sprintf("user-%d", i%5))).
+				Build(opts...),
+			testcs.FullBuilder(name, name, scheme).Build(FailingSince(time.Now().Add(-time.Hour))),
+		)
+	}
+	return objs
+}
+
+func TestCollectPaging(t *testing.T) {
+	defer func(pageSize int64) { collectPageSize = pageSize }(collectPageSize)
+
+	// Durations change between scrapes, so only the labels of those metrics are compared.
+	pretty := func(m *dto.Metric) string {
+		if m.Histogram != nil {
+			return fmt.Sprintf("%s %d %v", metricPretty(m), m.Histogram.GetSampleCount(), m.Histogram.GetSampleSum())
+		}
+		return metricPrettyWithValue(m)
+	}
+	collectors := map[string]struct {
+		newCollector func(client.Client) prometheus.Collector
+		pretty       func(*dto.Metric) string
+	}{
+		"provisioning underway": {
+			newCollector: func(c client.Client) prometheus.Collector {
+				return newProvisioningUnderwaySecondsCollector(c, provisioningUnderwayOptions{})
+			},
+			pretty: metricPretty,
+		},
+		"install restarts": {
+			newCollector: func(c client.Client) prometheus.Collector {
+				return newProvisioningUnderwayInstallRestartsCollector(c, 1, nil, nil, "", true, nil)
+			},
+			pretty: pretty,
+		},
+		"cluster sync failing": {
+			newCollector: func(c client.Client) prometheus.Collector {
+				return newClusterSyncFailingCollector(c, 0, nil, nil)
+			},
+			pretty: metricPretty,
+		},
+		"creator": {
+			newCollector: newCreatorCollector,
+			pretty:       pretty,
+		},
+	}
+	existing := pagingTestObjects(50)
+
+	for name, test := range collectors {
+		t.Run(name, func(t *testing.T) {
+			collectPageSize = 0
+			c := &pagingClient{Client: testfake.NewFakeClientBuilder().WithRuntimeObjects(existing...).Build()}
+			unpaged := collectMetrics(t, test.newCollector(c), test.pretty)
+			require.NotEmpty(t, unpaged, "expected metrics")
+
+			collectPageSize = 7
+			c = &pagingClient{Client: testfake.NewFakeClientBuilder().WithRuntimeObjects(existing...).Build()}
+			assert.ElementsMatch(t, unpaged, collectMetrics(t, test.newCollector(c), test.pretty), "paged collection produced different series")
+			assert.Greater(t, c.lists, 50/7, "expected paged lists")
+		})
+	}
+}
+
+// clusterDeploymentServer serves ClusterDeploymentLists a page at a time from memory, copying each page as a client
+// decoding the API server's response would, without the overhead of the fake client.
+type clusterDeploymentServer struct {
+	client.Client
+	items []hivev1.ClusterDeployment
+}
+
+func (c *clusterDeploymentServer) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	cdList, ok := list.(*hivev1.ClusterDeploymentList)
+	if !ok {
+		return c.Client.List(ctx, list, opts...)
+	}
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	start, end := 0, len(c.items)
+	if listOpts.Continue != "" {
+		start, _ = strconv.Atoi(listOpts.Continue)
+	}
+	cdList.Continue = ""
+	if listOpts.Limit > 0 && start+int(listOpts.Limit) < end {
+		end = start + int(listOpts.Limit)
+		cdList.Continue = strconv.Itoa(end)
+	}
+	cdList.Items = make([]hivev1.ClusterDeployment, 0, end-start)
+	for i := start; i < end; i++ {
+		cdList.Items = append(cdList.Items, *c.items[i].DeepCopy())
+	}
+	return nil
+}
+
+func BenchmarkCollectPaging(b *testing.B) {
+	defer func(pageSize int64) { collectPageSize = pageSize }(collectPageSize)
+
+	server := &clusterDeploymentServer{Client: testfake.NewFakeClientBuilder().Build()}
+	for _, obj := range pagingTestObjects(5000) {
+		if cd, ok := obj.(*hivev1.ClusterDeployment); ok {
+			server.items = append(server.items, *cd)
+		}
+	}
+	// Lists are paged from the server as the metrics controller pages them from the API server.
+	c := newAPIListClient(testfake.NewFakeClientBuilder().Build(), server)
+	collector := newProvisioningUnderwaySecondsCollector(c, provisioningUnderwayOptions{})
+	for _, pageSize := range []int64{0, defaultCollectPageSize} {
+		b.Run(fmt.Sprintf("page size %d", pageSize), func(b *testing.B) {
+			collectPageSize = pageSize
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				ch := make(chan prometheus.Metric)
+				go func() {
+					collector.Collect(ch)
+					close(ch)
+				}()
+				for range ch {
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkConstMetricLabels(b *testing.B) {
+	desc := metricClusterDeploymentProvisionUnderwaySecondsDesc
+	names := make([]string, 30000)
+	for i := range names {
+		names[i] = fmt.Sprintf("cd-%d", i)
+	}
+	b.Run("labels per metric", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, name := range names {
+				desc.mustNewConstMetric(prometheus.GaugeValue, 1, prometheus.Labels{
+					"cluster_deployment": name,
+					"cluster_type":       "unspecified",
+					"condition":          "Unknown",
+					"image_set":          "none",
+					"namespace":          name,
+					"platform":           "aws",
+					"reason":             "Unknown",
+					"version":            "4.14.0",
+				})
+			}
+		}
+	})
+	b.Run("buffer", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buffer := desc.newBuffer()
+			for _, name := range names {
+				buffer.labels["cluster_deployment"] = name
+				buffer.labels["cluster_type"] = "unspecified"
+				buffer.labels["condition"] = "Unknown"
+				buffer.labels["image_set"] = "none"
+				buffer.labels["namespace"] = name
+				buffer.labels["platform"] = "aws"
+				buffer.labels["reason"] = "Unknown"
+				buffer.labels["version"] = "4.14.0"
+				buffer.mustNewConstMetric(prometheus.GaugeValue, 1)
+			}
+		}
+	})
+}
+
+// testNow is the current time of the fake clocks given to collectors under test. It is a whole number of seconds, as
+// timestamps lose their fractional seconds when stored by the fake client.
+var testNow = time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+
 func FailingSince(t time.Time) testcs.Option {
 	return testcs.WithCondition(hiveintv1alpha1.ClusterSyncCondition{
 		Type:               hiveintv1alpha1.ClusterSyncFailed,
@@ -877,6 +1742,53 @@ func FailingSince(t time.Time) testcs.Option {
 	})
 }
 
+// collectorTest is a table-driven test of a custom collector. Each of its cases collects from the collector built over
+// a fake client holding the existing objects of the case.
+type collectorTest struct {
+	name string
+
+	collector func(c client.Client) prometheus.Collector
+	// named formats the collected metrics by collectNamedMetrics, for collectors reporting several metrics.
+	named bool
+
+	cases []collectorCase
+}
+
+// collectorCase is a case of a collectorTest.
+type collectorCase struct {
+	name string
+
+	existing []runtime.Object
+	// collector, if set, replaces the collector of the test for the case, as for cases with other options.
+	collector func(c client.Client) prometheus.Collector
+
+	// expected are the collected metrics, formatted by metricPrettyWithValue, in any order.
+	expected []string
+}
+
+// runCollectorTests runs each of the tests, and each of their cases, as a subtest.
+func runCollectorTests(t *testing.T, tests []collectorTest) {
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, tc := range test.cases {
+				t.Run(tc.name, func(t *testing.T) {
+					newCollector := test.collector
+					if tc.collector != nil {
+						newCollector = tc.collector
+					}
+					c := testfake.NewFakeClientBuilder().WithRuntimeObjects(tc.existing...).Build()
+					collect := newCollector(c)
+					if test.named {
+						assert.ElementsMatch(t, tc.expected, collectNamedMetrics(t, collect))
+					} else {
+						assert.ElementsMatch(t, tc.expected, collectMetrics(t, collect, metricPrettyWithValue))
+					}
+				})
+			}
+		})
+	}
+}
+
 func metricPretty(d *dto.Metric) string {
 	labels := make([]string, len(d.Label))
 	for _, label := range d.Label {
@@ -893,3 +1805,83 @@ func metricPrettyWithValue(d *dto.Metric) string {
 	}
 	return fmt.Sprintf("%s %d", labels, value)
 }
+
+// collectMetrics runs Describe and Collect on the collector, and returns the emitted metrics formatted by pretty.
+func collectMetrics(t *testing.T, collect prometheus.Collector, pretty func(*dto.Metric) string) []string {
+	var got []string
+	for _, d := range collectMetricsRaw(t, collect) {
+		got = append(got, pretty(d))
+	}
+	return got
+}
+
+// collectNamedMetrics runs Describe and Collect on the collector, and returns the emitted metrics formatted by
+// metricPrettyWithValue after their metric name, for collectors reporting several metrics.
+func collectNamedMetrics(t *testing.T, collect prometheus.Collector) []string {
+	var got []string
+	collectSamples(t, collect, func(sample prometheus.Metric, d *dto.Metric) {
+		name := metricNameRegex.FindStringSubmatch(sample.Desc().String())
+		require.NotNil(t, name, "no metric name in %s", sample.Desc())
+		got = append(got, name[1]+" "+metricPrettyWithValue(d))
+	})
+	return got
+}
+
+// metricNameRegex matches the metric name in the string form of a prometheus.Desc.
+var metricNameRegex = regexp.MustCompile(`fqName: "([^"]*)"`)
+
+// collectMetricsRaw runs Describe and Collect on the collector, and returns the emitted metrics. Like
+// AssertDescribeCovers, it fails the test for metrics whose descriptor Describe did not advertise.
+func collectMetricsRaw(t *testing.T, collect prometheus.Collector) []*dto.Metric {
+	var got []*dto.Metric
+	collectSamples(t, collect, func(_ prometheus.Metric, d *dto.Metric) {
+		got = append(got, d)
+	})
+	return got
+}
+
+// collectSamples runs Describe and Collect on the collector, and calls sample for each emitted metric. It fails the
+// test for metrics whose descriptor Describe did not advertise.
+func collectSamples(t *testing.T, collect prometheus.Collector, sample func(prometheus.Metric, *dto.Metric)) {
+	t.Helper()
+	described := describedDescs(collect)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		collect.Collect(ch)
+		close(ch)
+	}()
+
+	for m := range ch {
+		assert.True(t, described.Has(m.Desc().String()), "collected metric with undescribed descriptor %s", m.Desc())
+		d := &dto.Metric{}
+		require.NoError(t, m.Write(d))
+		sample(m, d)
+	}
+}
+
+// describedDescs returns the descriptors the collector advertises through Describe.
+func describedDescs(collect prometheus.Collector) sets.Set[string] {
+	described := sets.New[string]()
+	descCh := make(chan *prometheus.Desc)
+	done := make(chan struct{})
+	go func() {
+		for desc := range descCh {
+			described.Insert(desc.String())
+		}
+		close(done)
+	}()
+	collect.Describe(descCh)
+	close(descCh)
+	<-done
+	return described
+}
+
+// AssertDescribeCovers runs Describe and then Collect on the collector. It fails the test if Describe advertised no
+// descriptors, and for every metric collected with a descriptor Describe did not advertise, which a registry refuses
+// to gather.
+func AssertDescribeCovers(t *testing.T, collect prometheus.Collector) {
+	t.Helper()
+	require.NotEmpty(t, describedDescs(collect), "Describe advertised no descriptors")
+	collectMetricsRaw(t, collect)
+}
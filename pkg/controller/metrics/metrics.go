@@ -148,6 +148,33 @@ var (
 		},
 		[]string{"cluster_deployment", "namespace", "cluster_type"},
 	)
+	// metricCollectorTimeoutsTotal counts the scrapes for which a custom collector ran out of time reading from the
+	// API server and reported partial results.
+	metricCollectorTimeoutsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hive_metrics_collector_timeouts_total",
+			Help: "Total number of times a custom metrics collector exceeded its collect timeout.",
+		},
+		[]string{"collector"},
+	)
+	// metricCollectorErrorsTotal counts the reads from the API server that failed for a custom collector, other than
+	// those cut short by the collect timeout.
+	metricCollectorErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hive_metrics_collector_errors_total",
+			Help: "Total number of errors a custom metrics collector encountered reading from the API server.",
+		},
+		[]string{"collector"},
+	)
+	// metricCollectorScrapeDurationSeconds records how long the last scrape of each custom collector took, including
+	// scrapes that failed reading from the API server.
+	metricCollectorScrapeDurationSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hive_metrics_collector_scrape_duration_seconds",
+			Help: "Length of time the last scrape of a custom metrics collector took.",
+		},
+		[]string{"collector"},
+	)
 
 	// mapMetricToDurationHistograms is a map of optional durationMetrics of type Histogram to their specific duration,
 	// if mentioned
@@ -186,19 +213,94 @@ func init() {
 	metrics.Registry.MustRegister(metricSyncSetsUnappliedTotal)
 	metrics.Registry.MustRegister(metricControllerReconcileTime)
 	metrics.Registry.MustRegister(metricClusterDeploymentSyncsetPaused)
+	metrics.Registry.MustRegister(metricCollectorTimeoutsTotal)
+	metrics.Registry.MustRegister(metricCollectorErrorsTotal)
+	metrics.Registry.MustRegister(metricCollectorScrapeDurationSeconds)
 }
 
-// Add creates a new metrics Calculator and adds it to the Manager.
+// provisioningConditionReasons are the reasons, beyond those defined by the hivev1 API, that Hive's controllers set on
+// the conditions reported by the provisioning underway metrics.
+var provisioningConditionReasons = []string{
+	"AuthenticationFailure",
+	"ClusterImageSetNotFound",
+	"DNSNotReady",
+	"DNSNotReadyTimedOut",
+	"DNSUnsupportedPlatform",
+	"DNSZoneResourceConflict",
+	"InstallAttemptsLimitReached",
+	"JobToResolveImagesFailed",
+	"UnknownError",
+}
+
+// Add creates a new metrics Calculator and adds it to the Manager, registering its collectors with the
+// controller-runtime metrics registry.
 func Add(mgr manager.Manager) error {
+	return AddWithRegistry(mgr, metrics.Registry)
+}
+
+// AddWithRegistry creates a new metrics Calculator and adds it to the Manager, registering its custom collectors and
+// optional metrics with registry, for binaries embedding Hive's controllers that serve their own registry. The
+// metrics Hive's controllers update directly are always registered with the controller-runtime metrics registry.
+func AddWithRegistry(mgr manager.Manager, registry prometheus.Registerer) error {
 	mc := &Calculator{
 		Client:   mgr.GetClient(),
 		Interval: 2 * time.Minute,
+		registry: registry,
+	}
+	// The custom collectors count their timeouts, errors and scrape durations in these, so serve them from the same
+	// registry.
+	for _, collector := range []prometheus.Collector{metricCollectorTimeoutsTotal, metricCollectorErrorsTotal, metricCollectorScrapeDurationSeconds} {
+		if err := registry.Register(collector); err != nil && !errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+			return err
+		}
+	}
+	// The collectors below are scraped as soon as they are registered, so the collect timeout, client, page size and
+	// labels have to be known now rather than when the Calculator starts. A config that cannot be read is reported by
+	// Start.
+	opts := DefaultMetricsConfig()
+	// Collectors read from the informer cache unless configured to list from the API server a page at a time. The
+	// informer cache ignores continue tokens, so lists read from it are never paged.
+	mc.collectClient = mgr.GetClient()
+	if mConfig, err := ReadMetricsConfig(); err == nil {
+		if mConfig.CollectTimeout != nil {
+			collectTimeout = mConfig.CollectTimeout.Duration
+		}
+		if mConfig.CollectFromAPIServer {
+			mc.collectClient = newAPIListClient(mgr.GetClient(), mgr.GetAPIReader())
+			collectPageSize = defaultCollectPageSize
+			if mConfig.CollectPageSize != nil {
+				collectPageSize = *mConfig.CollectPageSize
+			}
+		}
+		opts.ProvisioningUnderwayOwnedBy = mConfig.ProvisioningUnderwayOwnedBy
+		opts.ProvisioningUnderwayVersion = mConfig.ProvisioningUnderwayVersion
+		opts.ProvisioningUnderwayProvisionKind = mConfig.ProvisioningUnderwayProvisionKind
+		opts.ProvisioningUnderwayQuotaDetail = mConfig.ProvisioningUnderwayQuotaDetail
+		opts.InstallRestartsHistogram = mConfig.InstallRestartsHistogram
+		opts.ProvisioningUnderwayPostInstallDegraded = mConfig.ProvisioningUnderwayPostInstallDegraded
+		opts.IncludeClusterTypes = mConfig.IncludeClusterTypes
+		opts.ClusterTypeLabel = mConfig.ClusterTypeLabel
+		opts.IncludePaused = mConfig.IncludePausedClusters
+		if mConfig.PerClusterReadMetrics {
+			opts.EnablePerClusterReads()
+		}
+		for _, name := range mConfig.EnabledMetrics {
+			if !opts.SetEnabled(name, true) {
+				log.WithField("metric", name).Warn("ignoring unknown metric in enabledMetrics")
+			}
+		}
+		for _, name := range mConfig.DisabledMetrics {
+			if !opts.SetEnabled(name, false) {
+				log.WithField("metric", name).Warn("ignoring unknown metric in disabledMetrics")
+			}
+		}
+		if mConfig.ExcessiveProvisionsMax != nil {
+			opts.ExcessiveProvisionsMax = int(*mConfig.ExcessiveProvisionsMax)
+		}
+	}
+	if err := RegisterCollectors(mc.registry, mc.collectClient, opts); err != nil {
+		return err
 	}
-	// TODO: Make these optional & configurable via HiveConfig.Spec.MetricsConfig
-	metrics.Registry.MustRegister(newProvisioningUnderwaySecondsCollector(mgr.GetClient(), 1*time.Hour))
-	metrics.Registry.MustRegister(newProvisioningUnderwayInstallRestartsCollector(mgr.GetClient(), 1))
-	// TODO: Add deprovisioning underway metric to set of optional duration-based metrics
-	metrics.Registry.MustRegister(newDeprovisioningUnderwaySecondsCollector(mgr.GetClient()))
 
 	return mgr.Add(mc)
 }
@@ -214,6 +316,12 @@ type Calculator struct {
 
 	// Interval is the length of time we sleep between metrics calculations.
 	Interval time.Duration
+
+	// collectClient is the client the custom collectors read through.
+	collectClient client.Client
+
+	// registry is where the custom collectors and optional metrics are registered.
+	registry prometheus.Registerer
 }
 
 // Start begins the metrics calculation loop.
@@ -227,7 +335,10 @@ func (mc *Calculator) Start(ctx context.Context) error {
 	}
 	// Register optional metrics and update them in their corresponding maps, so controllers logging them can access
 	// the information
-	mc.registerOptionalMetrics(mConfig)
+	if err := mc.registerOptionalMetrics(mConfig); err != nil {
+		log.WithError(err).Error("error registering optional metrics")
+		return err
+	}
 
 	// Run forever, sleep at the end:
 	wait.UntilWithContext(ctx, func(ctx context.Context) {
@@ -386,32 +497,42 @@ func (mc *Calculator) Start(ctx context.Context) error {
 }
 
 // registerOptionalMetrics registers the metrics, and stores their configs in the corresponding maps
-func (mc *Calculator) registerOptionalMetrics(mConfig *metricsconfig.MetricsConfig) {
+func (mc *Calculator) registerOptionalMetrics(mConfig *metricsconfig.MetricsConfig) error {
 	mapMetricToDurationHistograms = make(map[*prometheus.HistogramVec]time.Duration)
 	mapMetricToDurationGauges = make(map[*prometheus.GaugeVec]time.Duration)
+	opts := MetricsConfig{
+		Tenant:           mConfig.Tenant,
+		ProvisioningSLOs: mConfig.ProvisioningSLOs,
+	}
 	for _, metric := range mConfig.MetricsWithDuration {
 		switch metric.Name {
 		// Histograms
 		case metricsconfig.CurrentStopping:
-			metrics.Registry.MustRegister(MetricStoppingClustersSeconds)
+			mc.registry.MustRegister(MetricStoppingClustersSeconds)
 			mapMetricToDurationHistograms[MetricStoppingClustersSeconds] = metric.Duration.Duration
 		case metricsconfig.CurrentResuming:
-			metrics.Registry.MustRegister(MetricResumingClustersSeconds)
+			mc.registry.MustRegister(MetricResumingClustersSeconds)
 			mapMetricToDurationHistograms[MetricResumingClustersSeconds] = metric.Duration.Duration
 		case metricsconfig.CurrentWaitingForCO:
-			metrics.Registry.MustRegister(MetricWaitingForCOClustersSeconds)
+			mc.registry.MustRegister(MetricWaitingForCOClustersSeconds)
 			mapMetricToDurationHistograms[MetricWaitingForCOClustersSeconds] = metric.Duration.Duration
 		case metricsconfig.CumulativeHibernated:
-			metrics.Registry.MustRegister(MetricClusterHibernationTransitionSeconds)
+			mc.registry.MustRegister(MetricClusterHibernationTransitionSeconds)
 			mapMetricToDurationHistograms[MetricClusterHibernationTransitionSeconds] = metric.Duration.Duration
 		case metricsconfig.CumulativeResumed:
-			metrics.Registry.MustRegister(MetricClusterReadyTransitionSeconds)
+			mc.registry.MustRegister(MetricClusterReadyTransitionSeconds)
 			mapMetricToDurationHistograms[MetricClusterReadyTransitionSeconds] = metric.Duration.Duration
 		// Gauges
 		case metricsconfig.CurrentClusterSyncFailing:
-			metrics.Registry.MustRegister(newClusterSyncFailingCollector(mc.Client, metric.Duration.Duration, GetOptionalClusterTypeLabels(mConfig)))
+			opts.ClusterSyncFailing = true
+			opts.ClusterSyncFailingMin = metric.Duration.Duration
+			opts.ClusterSyncFailingLabels = GetOptionalClusterTypeLabels(mConfig)
+		case metricsconfig.CurrentSyncSetFailing:
+			opts.FailingSyncSets = true
+			opts.FailingSyncSetsMin = metric.Duration.Duration
 		}
 	}
+	return RegisterCollectors(mc.registry, mc.collectClient, opts)
 }
 
 // ShouldLogHistogramDurationMetric decides whether the corresponding duration metric of type histogram should be logged.
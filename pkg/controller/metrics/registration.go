@@ -0,0 +1,691 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/openshift/hive/apis/hive/v1/metricsconfig"
+)
+
+// MetricsConfig selects the custom collectors registered by RegisterCollectors, along with the thresholds they
+// report against. Collectors whose flag is false are not registered.
+type MetricsConfig struct {
+	// ExcludedNamespaces are namespace patterns whose objects are not reported by the collectors that support it.
+	ExcludedNamespaces []string
+	// IncludeClusterTypes, when not empty, limits the provisioning and deprovisioning collectors to ClusterDeployments
+	// whose cluster_type is one of these.
+	IncludeClusterTypes []string
+	// ClusterTypeLabel is the ClusterDeployment label whose value the collectors report as cluster_type. Defaults to
+	// hive.openshift.io/cluster-type when empty.
+	ClusterTypeLabel string
+	// IncludePaused reports ClusterDeployments whose reconciles are paused in the provisioning and deprovisioning
+	// underway collectors, with a paused label, rather than leaving them out.
+	IncludePaused bool
+
+	// ProvisioningUnderway enables hive_cluster_deployment_provision_underway_seconds.
+	ProvisioningUnderway bool
+	// ProvisioningUnderwayMin is how long a cluster must have been provisioning before it is reported. Clusters
+	// provisioning for exactly ProvisioningUnderwayMin are reported.
+	ProvisioningUnderwayMin time.Duration
+	// ProvisioningUnderwayMinByCondition overrides ProvisioningUnderwayMin for clusters held up by a condition.
+	ProvisioningUnderwayMinByCondition map[hivev1.ClusterDeploymentConditionType]time.Duration
+	// AdditionalConditionReasons are reported in the reason label of the provisioning metrics, alongside the
+	// reasons defined by the hivev1 API.
+	AdditionalConditionReasons []string
+	// ProvisioningUnderwayOwnedBy adds the owned_by label to hive_cluster_deployment_provision_underway_seconds.
+	ProvisioningUnderwayOwnedBy bool
+	// ProvisioningUnderwayVersion adds the version label to hive_cluster_deployment_provision_underway_seconds.
+	ProvisioningUnderwayVersion bool
+	// ProvisioningUnderwayProvisionKind adds the provision_kind label to
+	// hive_cluster_deployment_provision_underway_seconds.
+	ProvisioningUnderwayProvisionKind bool
+	// ProvisioningUnderwayQuotaDetail adds the quota_detail label to hive_cluster_deployment_provision_underway_seconds.
+	ProvisioningUnderwayQuotaDetail bool
+	// ProvisioningUnderwayPostInstallDegraded enables hive_cluster_deployment_post_install_degraded_seconds, reported
+	// alongside hive_cluster_deployment_provision_underway_seconds.
+	ProvisioningUnderwayPostInstallDegraded bool
+
+	// InstallRestarts enables hive_cluster_deployment_provision_underway_install_restarts.
+	InstallRestarts bool
+	// InstallRestartsMin is how many times a cluster's install must have restarted before it is reported. Clusters
+	// with exactly InstallRestartsMin restarts are reported.
+	InstallRestartsMin int
+	// InstallRestartsHistogram enables hive_cluster_deployment_install_restarts.
+	InstallRestartsHistogram bool
+
+	// DeprovisioningUnderway enables hive_cluster_deployment_deprovision_underway_seconds.
+	DeprovisioningUnderway bool
+
+	// ClusterSyncFailing enables hive_clustersync_failing_seconds.
+	ClusterSyncFailing bool
+	// ClusterSyncFailingMin is how long a ClusterSync must have been failing before it is reported.
+	ClusterSyncFailingMin time.Duration
+	// ClusterSyncFailingLabels are the optional ClusterDeployment labels added to hive_clustersync_failing_seconds.
+	ClusterSyncFailingLabels map[string]string
+
+	// FailingSyncSets enables hive_clustersync_syncset_failing_seconds.
+	FailingSyncSets bool
+	// FailingSyncSetsMin is how long a SyncSet must have been failing to apply before it is reported.
+	FailingSyncSetsMin time.Duration
+
+	// Tenant enables hive_cluster_deployments_per_tenant, identifying tenants as configured.
+	Tenant *metricsconfig.TenantConfig
+	// ProvisioningSLOs enables hive_cluster_deployment_slo_breached for the given SLOs.
+	ProvisioningSLOs []metricsconfig.ProvisioningSLO
+
+	// AdditionalManifestCount enables hive_cluster_deployment_additional_manifest_count.
+	AdditionalManifestCount bool
+	// AdditionalManifestCountMin is how many additional manifests a cluster must have before it is reported.
+	AdditionalManifestCountMin int
+
+	// HibernationTransitionUnderway enables hive_cluster_deployment_hibernation_transition_underway_seconds.
+	HibernationTransitionUnderway bool
+	// HibernationTransitionUnderwayMin is how long a cluster must have been transitioning before it is reported.
+	HibernationTransitionUnderwayMin time.Duration
+
+	// ClusterPoolCapacity enables the hive_clusterpool_size family of metrics.
+	ClusterPoolCapacity bool
+	// ClusterPoolMaxUnclaimedAge is the age after which an unclaimed pool cluster is reported as stale.
+	ClusterPoolMaxUnclaimedAge time.Duration
+
+	// PullSecretExpiring enables hive_cluster_deployment_pull_secret_expiring.
+	PullSecretExpiring bool
+	// PullSecretExpiringThreshold is how close to expiry a pull secret token must be before it is reported.
+	PullSecretExpiringThreshold time.Duration
+
+	// MachinePoolUnderway enables hive_machinepool_replicas_mismatch.
+	MachinePoolUnderway bool
+	// MachinePoolUnderwayMin is how long a MachinePool's replicas must have differed from its desired replicas
+	// before it is reported.
+	MachinePoolUnderwayMin time.Duration
+
+	// DNSZoneNotReady enables hive_dnszone_not_ready_seconds.
+	DNSZoneNotReady bool
+	// DNSZoneNotReadyMin is how long a DNSZone must have not been available before it is reported.
+	DNSZoneNotReadyMin time.Duration
+
+	// PendingClusterClaim enables hive_clusterclaim_pending_seconds.
+	PendingClusterClaim bool
+	// PendingClusterClaimMin is how long a ClusterClaim must have been waiting for a cluster before it is reported.
+	PendingClusterClaimMin time.Duration
+
+	// ExcessiveProvisions enables hive_cluster_deployment_excessive_provisions.
+	ExcessiveProvisions bool
+	// ExcessiveProvisionsMax is how many ClusterProvisions a ClusterDeployment may have before it is reported.
+	ExcessiveProvisionsMax int
+
+	// FailingClusterDeprovision enables hive_cluster_deprovision_underway_seconds.
+	FailingClusterDeprovision bool
+	// FailingClusterDeprovisionMin is how long a ClusterDeprovision must have existed without completing before it is
+	// reported.
+	FailingClusterDeprovisionMin time.Duration
+
+	// SelectorSyncSetNamespaceSpan enables hive_selectorsyncset_namespace_span.
+	SelectorSyncSetNamespaceSpan bool
+	// SelectorSyncSetNamespaceSpanMax is how many namespaces the clusters matched by a SelectorSyncSet may span before
+	// it is reported.
+	SelectorSyncSetNamespaceSpanMax int
+
+	// InstalledNeverSynced enables hive_cluster_deployment_installed_never_synced_seconds.
+	InstalledNeverSynced bool
+	// InstalledNeverSyncedMin is how long a cluster must have been installed without its ClusterSync succeeding
+	// before it is reported.
+	InstalledNeverSyncedMin time.Duration
+
+	// CustomCA enables hive_cluster_deployment_custom_ca.
+	CustomCA bool
+	// ClusterPoolLastCreationFailed enables hive_clusterpool_last_creation_failed.
+	ClusterPoolLastCreationFailed bool
+	// InstallerVersionMismatch enables hive_cluster_deployment_installer_version_mismatch.
+	InstallerVersionMismatch bool
+	// InstallType enables hive_cluster_deployments_by_install_type.
+	InstallType bool
+	// DNSZoneRecordConflict enables hive_dnszone_record_conflict.
+	DNSZoneRecordConflict bool
+	// DNSLimitFailures enables hive_cluster_deployment_dns_limit_failures.
+	DNSLimitFailures bool
+	// KnownInstallErrors enables hive_cluster_deployment_known_install_error.
+	KnownInstallErrors bool
+	// MachinePoolSpotInstances enables hive_machine_pool_spot_instances.
+	MachinePoolSpotInstances bool
+	// DNSCleanupFailed enables hive_cluster_deployment_dns_cleanup_failed.
+	DNSCleanupFailed bool
+	// AvailabilityZoneCount enables hive_cluster_deployment_az_count.
+	AvailabilityZoneCount bool
+	// InstallConfigMutated enables hive_cluster_deployment_installconfig_mutated.
+	InstallConfigMutated bool
+	// Stage enables hive_cluster_deployments_by_stage.
+	Stage bool
+	// SyncSetCreateOnly enables hive_syncset_create_only_total.
+	SyncSetCreateOnly bool
+	// Creator enables hive_cluster_deployments_by_creator.
+	Creator bool
+	// CertificateExpiry enables hive_cluster_deployment_certificate_valid_seconds.
+	CertificateExpiry bool
+	// MirrorInstall enables hive_cluster_deployment_mirror_install.
+	MirrorInstall bool
+	// FIPS enables hive_cluster_deployments_by_fips.
+	FIPS bool
+	// NodesBelowMin enables hive_cluster_deployment_nodes_below_min.
+	NodesBelowMin bool
+	// ClusterPoolEmptyUnderDemand enables hive_clusterpool_empty_under_demand_seconds.
+	ClusterPoolEmptyUnderDemand bool
+	// NeverReconciled enables hive_cluster_deployment_never_reconciled.
+	NeverReconciled bool
+	// DNSMode enables hive_cluster_deployments_by_dns_mode.
+	DNSMode bool
+	// ClusterSyncPendingDeletes enables hive_clustersync_failing_with_pending_deletes.
+	ClusterSyncPendingDeletes bool
+	// InstanceFamily enables hive_cluster_deployment_instance_family.
+	InstanceFamily bool
+	// CredentialsRequestPending enables hive_cluster_deployment_credentials_request_pending.
+	CredentialsRequestPending bool
+	// CloudOrg enables hive_cluster_deployments_by_cloud_org.
+	CloudOrg bool
+	// InstallResourceTimeout enables hive_cluster_deployment_install_resource_timeout.
+	InstallResourceTimeout bool
+	// APIMarker enables hive_cluster_deployments_by_api_marker.
+	APIMarker bool
+	// ReadyConditionMissing enables hive_cluster_deployment_ready_condition_missing.
+	ReadyConditionMissing bool
+	// SyncIdentityProviderFailing enables hive_syncidentityprovider_failing_seconds.
+	SyncIdentityProviderFailing bool
+	// RegistryAuthFailures enables hive_cluster_deployment_registry_auth_failures.
+	RegistryAuthFailures bool
+	// NetworkType enables hive_cluster_deployments_by_network_type.
+	NetworkType bool
+	// ClusterSyncCRDOrdering enables hive_clustersync_crd_ordering_failures.
+	ClusterSyncCRDOrdering bool
+	// Proxy enables hive_cluster_deployments_with_proxy.
+	Proxy bool
+	// PlatformRegion enables hive_cluster_deployments_total.
+	PlatformRegion bool
+	// AuthDegraded enables hive_cluster_deployment_auth_degraded.
+	AuthDegraded bool
+	// ImageSetReferences enables hive_clusterimageset_reference_count.
+	ImageSetReferences bool
+	// ClusterImageSetUsage enables hive_clusterimageset_in_use and hive_clusterimageset_referencing_clusterdeployments.
+	ClusterImageSetUsage bool
+	// DeprovisionOldest enables hive_cluster_deployment_deprovision_oldest_seconds.
+	DeprovisionOldest bool
+	// ClusterSyncFailingExpected enables hive_clustersync_failing_expected.
+	ClusterSyncFailingExpected bool
+	// ClusterSyncResources enables hive_clustersync_resources_success and hive_clustersync_resources_failure.
+	ClusterSyncResources bool
+	// ClusterPoolCondition enables hive_clusterpool_condition.
+	ClusterPoolCondition bool
+}
+
+// DefaultMetricsConfig returns the collectors the metrics controller registers when it is added, before any optional
+// metrics are configured in HiveConfig. Only the provisioning, install restart and deprovisioning collectors are on;
+// the thresholds of the others apply once they are enabled, such as by EnablePerClusterReads or SetEnabled.
+func DefaultMetricsConfig() MetricsConfig {
+	return MetricsConfig{
+		ProvisioningUnderway:             true,
+		ProvisioningUnderwayMin:          1 * time.Hour,
+		AdditionalConditionReasons:       provisioningConditionReasons,
+		InstallRestarts:                  true,
+		InstallRestartsMin:               1,
+		DeprovisioningUnderway:           true,
+		AdditionalManifestCountMin:       50,
+		HibernationTransitionUnderwayMin: 1 * time.Hour,
+		ClusterPoolMaxUnclaimedAge:       7 * 24 * time.Hour,
+		PullSecretExpiringThreshold:      14 * 24 * time.Hour,
+		MachinePoolUnderwayMin:           30 * time.Minute,
+		DNSZoneNotReadyMin:               30 * time.Minute,
+		PendingClusterClaimMin:           30 * time.Minute,
+		ExcessiveProvisionsMax:           10,
+		FailingClusterDeprovisionMin:     1 * time.Hour,
+		SelectorSyncSetNamespaceSpanMax:  1,
+		InstalledNeverSyncedMin:          1 * time.Hour,
+	}
+}
+
+// EnablePerClusterReads enables the collectors that read a Secret, ConfigMap or ClusterProvision for each
+// ClusterDeployment on every scrape. On large fleets those reads, and the install log matching of some of them, make
+// scrapes far more expensive than the lists the other collectors read.
+func (opts *MetricsConfig) EnablePerClusterReads() {
+	opts.AdditionalManifestCount = true
+	opts.PullSecretExpiring = true
+	opts.KnownInstallErrors = true
+	opts.InstallConfigMutated = true
+	opts.CertificateExpiry = true
+	opts.MirrorInstall = true
+	opts.FIPS = true
+	opts.InstanceFamily = true
+	opts.CredentialsRequestPending = true
+	opts.CloudOrg = true
+	opts.InstallResourceTimeout = true
+	opts.NetworkType = true
+	opts.Proxy = true
+}
+
+// collectorFlags returns the flag of opts enabling each collector, by the name of every metric the collector reports.
+// The collectors configured through metricsWithDuration, and those enabled by a setting rather than a flag, are left
+// out.
+func (opts *MetricsConfig) collectorFlags() map[string]*bool {
+	return map[string]*bool{
+		"hive_cluster_deployment_provision_underway_seconds":              &opts.ProvisioningUnderway,
+		"hive_cluster_deployment_provision_underway_install_restarts":     &opts.InstallRestarts,
+		"hive_cluster_deployment_deprovision_underway_seconds":            &opts.DeprovisioningUnderway,
+		"hive_cluster_deployment_additional_manifest_count":               &opts.AdditionalManifestCount,
+		"hive_cluster_deployment_hibernation_transition_underway_seconds": &opts.HibernationTransitionUnderway,
+		"hive_clusterpool_size":                                           &opts.ClusterPoolCapacity,
+		"hive_clusterpool_ready":                                          &opts.ClusterPoolCapacity,
+		"hive_clusterpool_standby":                                        &opts.ClusterPoolCapacity,
+		"hive_clusterpool_stale_unclaimed":                                &opts.ClusterPoolCapacity,
+		"hive_cluster_deployment_pull_secret_expiring":                    &opts.PullSecretExpiring,
+		"hive_machinepool_replicas_mismatch":                              &opts.MachinePoolUnderway,
+		"hive_dnszone_not_ready_seconds":                                  &opts.DNSZoneNotReady,
+		"hive_clusterclaim_pending_seconds":                               &opts.PendingClusterClaim,
+		"hive_cluster_deployment_excessive_provisions":                    &opts.ExcessiveProvisions,
+		"hive_cluster_deprovision_underway_seconds":                       &opts.FailingClusterDeprovision,
+		"hive_selectorsyncset_namespace_span":                             &opts.SelectorSyncSetNamespaceSpan,
+		"hive_cluster_deployment_installed_never_synced_seconds":          &opts.InstalledNeverSynced,
+		"hive_cluster_deployment_custom_ca":                               &opts.CustomCA,
+		"hive_clusterpool_last_creation_failed":                           &opts.ClusterPoolLastCreationFailed,
+		"hive_cluster_deployment_installer_version_mismatch":              &opts.InstallerVersionMismatch,
+		"hive_cluster_deployments_by_install_type":                        &opts.InstallType,
+		"hive_dnszone_record_conflict":                                    &opts.DNSZoneRecordConflict,
+		"hive_cluster_deployment_dns_limit_failures":                      &opts.DNSLimitFailures,
+		"hive_cluster_deployment_known_install_error":                     &opts.KnownInstallErrors,
+		"hive_machine_pool_spot_instances":                                &opts.MachinePoolSpotInstances,
+		"hive_cluster_deployment_dns_cleanup_failed":                      &opts.DNSCleanupFailed,
+		"hive_cluster_deployment_az_count":                                &opts.AvailabilityZoneCount,
+		"hive_cluster_deployment_installconfig_mutated":                   &opts.InstallConfigMutated,
+		"hive_cluster_deployments_by_stage":                               &opts.Stage,
+		"hive_syncset_create_only_total":                                  &opts.SyncSetCreateOnly,
+		"hive_cluster_deployments_by_creator":                             &opts.Creator,
+		"hive_cluster_deployment_certificate_valid_seconds":               &opts.CertificateExpiry,
+		"hive_cluster_deployment_mirror_install":                          &opts.MirrorInstall,
+		"hive_cluster_deployments_by_fips":                                &opts.FIPS,
+		"hive_cluster_deployment_nodes_below_min":                         &opts.NodesBelowMin,
+		"hive_clusterpool_empty_under_demand_seconds":                     &opts.ClusterPoolEmptyUnderDemand,
+		"hive_cluster_deployment_never_reconciled":                        &opts.NeverReconciled,
+		"hive_cluster_deployments_by_dns_mode":                            &opts.DNSMode,
+		"hive_clustersync_failing_with_pending_deletes":                   &opts.ClusterSyncPendingDeletes,
+		"hive_cluster_deployment_instance_family":                         &opts.InstanceFamily,
+		"hive_cluster_deployment_credentials_request_pending":             &opts.CredentialsRequestPending,
+		"hive_cluster_deployments_by_cloud_org":                           &opts.CloudOrg,
+		"hive_cluster_deployment_install_resource_timeout":                &opts.InstallResourceTimeout,
+		"hive_cluster_deployments_by_api_marker":                          &opts.APIMarker,
+		"hive_cluster_deployment_ready_condition_missing":                 &opts.ReadyConditionMissing,
+		"hive_syncidentityprovider_failing_seconds":                       &opts.SyncIdentityProviderFailing,
+		"hive_cluster_deployment_registry_auth_failures":                  &opts.RegistryAuthFailures,
+		"hive_cluster_deployments_by_network_type":                        &opts.NetworkType,
+		"hive_clustersync_crd_ordering_failures":                          &opts.ClusterSyncCRDOrdering,
+		"hive_cluster_deployments_with_proxy":                             &opts.Proxy,
+		"hive_cluster_deployments_total":                                  &opts.PlatformRegion,
+		"hive_cluster_deployment_auth_degraded":                           &opts.AuthDegraded,
+		"hive_clusterimageset_reference_count":                            &opts.ImageSetReferences,
+		"hive_clusterimageset_in_use":                                     &opts.ClusterImageSetUsage,
+		"hive_clusterimageset_referencing_clusterdeployments":             &opts.ClusterImageSetUsage,
+		"hive_cluster_deployment_deprovision_oldest_seconds":              &opts.DeprovisionOldest,
+		"hive_clustersync_failing_expected":                               &opts.ClusterSyncFailingExpected,
+		"hive_clustersync_resources_success":                              &opts.ClusterSyncResources,
+		"hive_clustersync_resources_failure":                              &opts.ClusterSyncResources,
+		"hive_clusterpool_condition":                                      &opts.ClusterPoolCondition,
+	}
+}
+
+// SetEnabled enables or disables the collector reporting the named metric, returning false if no collector
+// constructed by NewCollectors reports it. Collectors reporting several metrics are toggled by any of their names.
+func (opts *MetricsConfig) SetEnabled(metricName string, enabled bool) bool {
+	flag, ok := opts.collectorFlags()[metricName]
+	if ok {
+		*flag = enabled
+	}
+	return ok
+}
+
+// NewCollectors constructs the custom collectors enabled by opts, reading through c, for the caller to register with
+// the registry of its choice. Each collector records how long its scrapes take in
+// hive_metrics_collector_scrape_duration_seconds.
+func NewCollectors(c client.Client, opts MetricsConfig) []prometheus.Collector {
+	collectors := []struct {
+		enabled      bool
+		newCollector func() prometheus.Collector
+	}{{
+		enabled: opts.ProvisioningUnderway,
+		newCollector: func() prometheus.Collector {
+			return newProvisioningUnderwaySecondsCollector(c, provisioningUnderwayOptions{
+				minimum:             opts.ProvisioningUnderwayMin,
+				minimumByCondition:  opts.ProvisioningUnderwayMinByCondition,
+				excludedNamespaces:  opts.ExcludedNamespaces,
+				includeClusterTypes: opts.IncludeClusterTypes,
+				clusterTypeLabelKey: opts.ClusterTypeLabel,
+				additionalReasons:   opts.AdditionalConditionReasons,
+				ownedBy:             opts.ProvisioningUnderwayOwnedBy,
+				version:             opts.ProvisioningUnderwayVersion,
+				provisionKind:       opts.ProvisioningUnderwayProvisionKind,
+				quotaDetail:         opts.ProvisioningUnderwayQuotaDetail,
+				postInstallDegraded: opts.ProvisioningUnderwayPostInstallDegraded,
+				includePaused:       opts.IncludePaused,
+			})
+		},
+	}, {
+		enabled: opts.InstallRestarts,
+		newCollector: func() prometheus.Collector {
+			return newProvisioningUnderwayInstallRestartsCollector(c, opts.InstallRestartsMin, opts.ExcludedNamespaces, opts.IncludeClusterTypes, opts.ClusterTypeLabel, opts.InstallRestartsHistogram, opts.AdditionalConditionReasons)
+		},
+	}, {
+		enabled: opts.DeprovisioningUnderway,
+		newCollector: func() prometheus.Collector {
+			return newDeprovisioningUnderwaySecondsCollector(c, deprovisioningUnderwayOptions{
+				excludedNamespaces:  opts.ExcludedNamespaces,
+				includeClusterTypes: opts.IncludeClusterTypes,
+				clusterTypeLabelKey: opts.ClusterTypeLabel,
+				includePaused:       opts.IncludePaused,
+			})
+		},
+	}, {
+		enabled: opts.ClusterSyncFailing,
+		newCollector: func() prometheus.Collector {
+			return newClusterSyncFailingCollector(c, opts.ClusterSyncFailingMin, opts.ClusterSyncFailingLabels, opts.ExcludedNamespaces)
+		},
+	}, {
+		enabled: opts.FailingSyncSets,
+		newCollector: func() prometheus.Collector {
+			return newFailingSyncSetResourcesCollector(c, opts.FailingSyncSetsMin)
+		},
+	}, {
+		enabled: opts.Tenant != nil,
+		newCollector: func() prometheus.Collector {
+			return newTenantCollector(c, *opts.Tenant)
+		},
+	}, {
+		enabled: len(opts.ProvisioningSLOs) > 0,
+		newCollector: func() prometheus.Collector {
+			return newProvisioningSLOBreachedCollector(c, opts.ProvisioningSLOs, opts.ClusterTypeLabel)
+		},
+	}, {
+		enabled: opts.AdditionalManifestCount,
+		newCollector: func() prometheus.Collector {
+			return newAdditionalManifestCountCollector(c, opts.AdditionalManifestCountMin)
+		},
+	}, {
+		enabled: opts.HibernationTransitionUnderway,
+		newCollector: func() prometheus.Collector {
+			return newHibernationTransitionUnderwayCollector(c, opts.HibernationTransitionUnderwayMin, opts.ClusterTypeLabel)
+		},
+	}, {
+		enabled: opts.ClusterPoolCapacity,
+		newCollector: func() prometheus.Collector {
+			return newClusterPoolCapacityCollector(c, opts.ClusterPoolMaxUnclaimedAge)
+		},
+	}, {
+		enabled: opts.PullSecretExpiring,
+		newCollector: func() prometheus.Collector {
+			return newPullSecretExpiringCollector(c, opts.PullSecretExpiringThreshold)
+		},
+	}, {
+		enabled: opts.MachinePoolUnderway,
+		newCollector: func() prometheus.Collector {
+			return newMachinePoolUnderwayCollector(c, opts.MachinePoolUnderwayMin)
+		},
+	}, {
+		enabled: opts.DNSZoneNotReady,
+		newCollector: func() prometheus.Collector {
+			return newDNSZoneNotReadyCollector(c, opts.DNSZoneNotReadyMin)
+		},
+	}, {
+		enabled: opts.PendingClusterClaim,
+		newCollector: func() prometheus.Collector {
+			return newPendingClusterClaimCollector(c, opts.PendingClusterClaimMin)
+		},
+	}, {
+		enabled: opts.ExcessiveProvisions,
+		newCollector: func() prometheus.Collector {
+			return newExcessiveProvisionsCollector(c, opts.ExcessiveProvisionsMax)
+		},
+	}, {
+		enabled: opts.FailingClusterDeprovision,
+		newCollector: func() prometheus.Collector {
+			return newFailingClusterDeprovisionCollector(c, opts.FailingClusterDeprovisionMin)
+		},
+	}, {
+		enabled: opts.SelectorSyncSetNamespaceSpan,
+		newCollector: func() prometheus.Collector {
+			return newSelectorSyncSetNamespaceSpanCollector(c, opts.SelectorSyncSetNamespaceSpanMax)
+		},
+	}, {
+		enabled: opts.InstalledNeverSynced,
+		newCollector: func() prometheus.Collector {
+			return newInstalledNeverSyncedCollector(c, opts.InstalledNeverSyncedMin)
+		},
+	}, {
+		enabled: opts.CustomCA,
+		newCollector: func() prometheus.Collector {
+			return newCustomCACollector(c)
+		},
+	}, {
+		enabled: opts.ClusterPoolLastCreationFailed,
+		newCollector: func() prometheus.Collector {
+			return newClusterPoolLastCreationFailedCollector(c)
+		},
+	}, {
+		enabled: opts.InstallerVersionMismatch,
+		newCollector: func() prometheus.Collector {
+			return newInstallerVersionMismatchCollector(c)
+		},
+	}, {
+		enabled: opts.InstallType,
+		newCollector: func() prometheus.Collector {
+			return newInstallTypeCollector(c)
+		},
+	}, {
+		enabled: opts.DNSZoneRecordConflict,
+		newCollector: func() prometheus.Collector {
+			return newDNSZoneRecordConflictCollector(c)
+		},
+	}, {
+		enabled: opts.DNSLimitFailures,
+		newCollector: func() prometheus.Collector {
+			return newDNSLimitFailuresCollector(c)
+		},
+	}, {
+		enabled: opts.KnownInstallErrors,
+		newCollector: func() prometheus.Collector {
+			return newKnownInstallErrorCollector(c)
+		},
+	}, {
+		enabled: opts.MachinePoolSpotInstances,
+		newCollector: func() prometheus.Collector {
+			return newMachinePoolSpotInstancesCollector(c)
+		},
+	}, {
+		enabled: opts.DNSCleanupFailed,
+		newCollector: func() prometheus.Collector {
+			return newDNSCleanupFailedCollector(c)
+		},
+	}, {
+		enabled: opts.AvailabilityZoneCount,
+		newCollector: func() prometheus.Collector {
+			return newAvailabilityZoneCountCollector(c)
+		},
+	}, {
+		enabled: opts.InstallConfigMutated,
+		newCollector: func() prometheus.Collector {
+			return newInstallConfigMutatedCollector(c)
+		},
+	}, {
+		enabled: opts.Stage,
+		newCollector: func() prometheus.Collector {
+			return newStageCollector(c)
+		},
+	}, {
+		enabled: opts.SyncSetCreateOnly,
+		newCollector: func() prometheus.Collector {
+			return newSyncSetCreateOnlyCollector(c)
+		},
+	}, {
+		enabled: opts.Creator,
+		newCollector: func() prometheus.Collector {
+			return newCreatorCollector(c)
+		},
+	}, {
+		enabled: opts.CertificateExpiry,
+		newCollector: func() prometheus.Collector {
+			return newClusterCertificateExpiryCollector(c, opts.ClusterTypeLabel)
+		},
+	}, {
+		enabled: opts.MirrorInstall,
+		newCollector: func() prometheus.Collector {
+			return newMirrorInstallCollector(c)
+		},
+	}, {
+		enabled: opts.FIPS,
+		newCollector: func() prometheus.Collector {
+			return newFIPSCollector(c)
+		},
+	}, {
+		enabled: opts.NodesBelowMin,
+		newCollector: func() prometheus.Collector {
+			return newNodesBelowMinCollector(c)
+		},
+	}, {
+		enabled: opts.ClusterPoolEmptyUnderDemand,
+		newCollector: func() prometheus.Collector {
+			return newClusterPoolEmptyUnderDemandCollector(c)
+		},
+	}, {
+		enabled: opts.NeverReconciled,
+		newCollector: func() prometheus.Collector {
+			return newNeverReconciledCollector(c)
+		},
+	}, {
+		enabled: opts.DNSMode,
+		newCollector: func() prometheus.Collector {
+			return newDNSModeCollector(c)
+		},
+	}, {
+		enabled: opts.ClusterSyncPendingDeletes,
+		newCollector: func() prometheus.Collector {
+			return newClusterSyncPendingDeletesCollector(c)
+		},
+	}, {
+		enabled: opts.InstanceFamily,
+		newCollector: func() prometheus.Collector {
+			return newInstanceFamilyCollector(c)
+		},
+	}, {
+		enabled: opts.CredentialsRequestPending,
+		newCollector: func() prometheus.Collector {
+			return newCredentialsRequestPendingCollector(c)
+		},
+	}, {
+		enabled: opts.CloudOrg,
+		newCollector: func() prometheus.Collector {
+			return newCloudOrgCollector(c)
+		},
+	}, {
+		enabled: opts.InstallResourceTimeout,
+		newCollector: func() prometheus.Collector {
+			return newInstallResourceTimeoutCollector(c)
+		},
+	}, {
+		enabled: opts.APIMarker,
+		newCollector: func() prometheus.Collector {
+			return newAPIMarkerCollector(c)
+		},
+	}, {
+		enabled: opts.ReadyConditionMissing,
+		newCollector: func() prometheus.Collector {
+			return newReadyConditionMissingCollector(c)
+		},
+	}, {
+		enabled: opts.SyncIdentityProviderFailing,
+		newCollector: func() prometheus.Collector {
+			return newSyncIdentityProviderFailingCollector(c)
+		},
+	}, {
+		enabled: opts.RegistryAuthFailures,
+		newCollector: func() prometheus.Collector {
+			return newRegistryAuthFailuresCollector(c)
+		},
+	}, {
+		enabled: opts.NetworkType,
+		newCollector: func() prometheus.Collector {
+			return newNetworkTypeCollector(c)
+		},
+	}, {
+		enabled: opts.ClusterSyncCRDOrdering,
+		newCollector: func() prometheus.Collector {
+			return newClusterSyncCRDOrderingCollector(c)
+		},
+	}, {
+		enabled: opts.Proxy,
+		newCollector: func() prometheus.Collector {
+			return newProxyCollector(c)
+		},
+	}, {
+		enabled: opts.PlatformRegion,
+		newCollector: func() prometheus.Collector {
+			return newPlatformRegionCollector(c)
+		},
+	}, {
+		enabled: opts.AuthDegraded,
+		newCollector: func() prometheus.Collector {
+			return newAuthDegradedCollector(c)
+		},
+	}, {
+		enabled: opts.ImageSetReferences,
+		newCollector: func() prometheus.Collector {
+			return newImageSetReferenceCollector(c)
+		},
+	}, {
+		enabled: opts.ClusterImageSetUsage,
+		newCollector: func() prometheus.Collector {
+			return newClusterImageSetUsageCollector(c)
+		},
+	}, {
+		enabled: opts.DeprovisionOldest,
+		newCollector: func() prometheus.Collector {
+			return newDeprovisionOldestCollector(c)
+		},
+	}, {
+		enabled: opts.ClusterSyncFailingExpected,
+		newCollector: func() prometheus.Collector {
+			return newClusterSyncFailingExpectedCollector(c)
+		},
+	}, {
+		enabled: opts.ClusterSyncResources,
+		newCollector: func() prometheus.Collector {
+			return newClusterSyncResourcesCollector(c)
+		},
+	}, {
+		enabled: opts.ClusterPoolCondition,
+		newCollector: func() prometheus.Collector {
+			return newClusterPoolConditionCollector(c)
+		},
+	}}
+
+	var enabled []prometheus.Collector
+	for _, collector := range collectors {
+		if collector.enabled {
+			enabled = append(enabled, newTimedCollector(collector.newCollector()))
+		}
+	}
+	return enabled
+}
+
+// RegisterCollectors constructs the custom collectors enabled by opts, reading through c, and registers them with
+// registry. Collectors that are already registered are left in place. Every enabled collector is attempted, and the
+// errors of any that could not be registered are returned together.
+func RegisterCollectors(registry prometheus.Registerer, c client.Client, opts MetricsConfig) error {
+	var errs []error
+	for _, collector := range NewCollectors(c, opts) {
+		if err := registry.Register(collector); err != nil {
+			if errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+				continue
+			}
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,347 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hivev1azure "github.com/openshift/hive/apis/hive/v1/azure"
+	"github.com/openshift/hive/apis/hive/v1/metricsconfig"
+	"github.com/openshift/hive/pkg/constants"
+	testcd "github.com/openshift/hive/pkg/test/clusterdeployment"
+	testfake "github.com/openshift/hive/pkg/test/fake"
+	"github.com/openshift/hive/pkg/util/scheme"
+)
+
+func TestRegisterCollectors(t *testing.T) {
+	scheme := scheme.GetScheme()
+
+	existing := []runtime.Object{
+		testcd.FullBuilder("cd-1", "cd-1", scheme).Build(testcd.InstallRestarts(2)),
+	}
+
+	cases := []struct {
+		name string
+
+		opts MetricsConfig
+
+		expected []string
+	}{{
+		name: "nothing enabled",
+	}, {
+		name: "selected collectors",
+		opts: MetricsConfig{
+			Creator: true,
+			FIPS:    true,
+		},
+		expected: []string{
+			"hive_cluster_deployments_by_creator",
+			"hive_cluster_deployments_by_fips",
+		},
+	}, {
+		name: "threshold not reached",
+		opts: MetricsConfig{
+			InstallRestarts:    true,
+			InstallRestartsMin: 3,
+		},
+	}, {
+		name: "threshold reached",
+		opts: MetricsConfig{
+			InstallRestarts:          true,
+			InstallRestartsMin:       2,
+			InstallRestartsHistogram: true,
+		},
+		expected: []string{
+			"hive_cluster_deployment_install_restarts",
+			"hive_cluster_deployment_provision_underway_install_restarts",
+		},
+	}, {
+		name: "configured collectors",
+		opts: MetricsConfig{
+			Tenant: &metricsconfig.TenantConfig{LabelKey: "tenant"},
+		},
+		expected: []string{
+			"hive_cluster_deployments_per_tenant",
+		},
+	}, {
+		name: "default collectors",
+		opts: DefaultMetricsConfig(),
+		expected: []string{
+			"hive_cluster_deployment_provision_underway_install_restarts",
+			"hive_cluster_deployment_provision_underway_seconds",
+		},
+	}}
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			c := testfake.NewFakeClientBuilder().WithRuntimeObjects(existing...).Build()
+			registry := prometheus.NewRegistry()
+			require.NoError(t, RegisterCollectors(registry, c, test.opts))
+
+			families, err := registry.Gather()
+			require.NoError(t, err)
+			var got []string
+			for _, family := range families {
+				got = append(got, family.GetName())
+			}
+			sort.Strings(got)
+			assert.Equal(t, test.expected, got)
+		})
+	}
+}
+
+func TestNewCollectors(t *testing.T) {
+	scheme := scheme.GetScheme()
+	c := testfake.NewFakeClientBuilder().WithRuntimeObjects(
+		testcd.FullBuilder("cd-1", "cd-1", scheme).Build(),
+	).Build()
+
+	collectors := NewCollectors(c, MetricsConfig{
+		Creator: true,
+		FIPS:    true,
+	})
+	require.Len(t, collectors, 2)
+	registry := prometheus.NewRegistry()
+	for _, collector := range collectors {
+		require.NoError(t, registry.Register(collector))
+	}
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	var got []string
+	for _, family := range families {
+		got = append(got, family.GetName())
+	}
+	assert.ElementsMatch(t, []string{
+		"hive_cluster_deployments_by_creator",
+		"hive_cluster_deployments_by_fips",
+	}, got)
+
+	global, err := metrics.Registry.Gather()
+	require.NoError(t, err)
+	for _, family := range global {
+		assert.NotContains(t, got, family.GetName(), "expected the collectors not to be registered globally")
+	}
+}
+
+// perClusterReadsClient counts the Secrets, ConfigMaps and ClusterProvisions read through it.
+type perClusterReadsClient struct {
+	client.Client
+	reads int
+}
+
+func (c *perClusterReadsClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	switch obj.(type) {
+	case *corev1.Secret, *corev1.ConfigMap, *hivev1.ClusterProvision:
+		c.reads++
+	}
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func TestEnablePerClusterReads(t *testing.T) {
+	scheme := scheme.GetScheme()
+	// The ClusterDeployments refer to every object the collectors read per cluster. None of those objects exist,
+	// which makes no difference to whether they are read.
+	withReferences := func(cd *hivev1.ClusterDeployment) {
+		cd.Spec.Platform.Azure = &hivev1azure.Platform{CredentialsSecretRef: corev1.LocalObjectReference{Name: "credentials"}}
+		cd.Spec.Provisioning = &hivev1.Provisioning{
+			InstallConfigSecretRef: &corev1.LocalObjectReference{Name: "install-config"},
+			ManifestsConfigMapRef:  &corev1.LocalObjectReference{Name: "manifests"},
+		}
+		cd.Spec.PullSecretRef = &corev1.LocalObjectReference{Name: "pull-secret"}
+		cd.Spec.BoundServiceAccountSignkingKeySecretRef = &corev1.LocalObjectReference{Name: "signing-key"}
+		cd.Spec.ClusterMetadata = &hivev1.ClusterMetadata{AdminKubeconfigSecretRef: corev1.LocalObjectReference{Name: "admin-kubeconfig"}}
+		cd.Status.ProvisionRef = &corev1.LocalObjectReference{Name: "provision"}
+	}
+	existing := []runtime.Object{
+		testcd.FullBuilder("cd-1", "cd-1", scheme).Build(withReferences, testcd.InstallRestarts(1)),
+		testcd.FullBuilder("cd-2", "cd-2", scheme).Build(withReferences, testcd.Installed()),
+	}
+
+	perClusterReads := MetricsConfig{}
+	perClusterReads.EnablePerClusterReads()
+	for name, enabled := range perClusterReads.collectorFlags() {
+		t.Run(name, func(t *testing.T) {
+			c := &perClusterReadsClient{Client: testfake.NewFakeClientBuilder().WithRuntimeObjects(existing...).Build()}
+			opts := DefaultMetricsConfig()
+			opts.ProvisioningUnderway, opts.InstallRestarts, opts.DeprovisioningUnderway = false, false, false
+			require.True(t, opts.SetEnabled(name, true))
+			collectors := NewCollectors(c, opts)
+			require.Len(t, collectors, 1)
+			collectMetricsRaw(t, collectors[0])
+			if *enabled {
+				assert.Positive(t, c.reads, "expected the collector to read objects per cluster")
+			} else {
+				assert.Zero(t, c.reads, "expected a collector reading objects per cluster to be enabled by EnablePerClusterReads")
+			}
+		})
+	}
+}
+
+func TestDefaultMetricsConfig(t *testing.T) {
+	c := testfake.NewFakeClientBuilder().Build()
+	var names []string
+	for _, collector := range NewCollectors(c, DefaultMetricsConfig()) {
+		names = append(names, collector.(timedCollector).name)
+	}
+	assert.ElementsMatch(t, []string{
+		"deprovisioningUnderwayCollector",
+		"provisioningUnderwayInstallRestartsCollector",
+		"provisioningUnderwayCollector",
+	}, names, "expected only the baseline collectors to be on by default")
+}
+
+func TestSetEnabled(t *testing.T) {
+	c := testfake.NewFakeClientBuilder().Build()
+	opts := MetricsConfig{}
+	for name := range opts.collectorFlags() {
+		opts := MetricsConfig{}
+		require.True(t, opts.SetEnabled(name, true), name)
+		assert.Len(t, NewCollectors(c, opts), 1, "expected %s to enable one collector", name)
+		require.True(t, opts.SetEnabled(name, false), name)
+		assert.Empty(t, NewCollectors(c, opts), "expected %s to disable its collector", name)
+	}
+
+	assert.False(t, opts.SetEnabled("hive_not_a_metric", true), "expected unknown metrics to be refused")
+	assert.Equal(t, MetricsConfig{}, opts, "expected unknown metrics to leave the config unchanged")
+
+	// Any of the metrics of a collector toggles it.
+	require.True(t, opts.SetEnabled("hive_clusterpool_ready", true))
+	require.True(t, opts.SetEnabled("hive_clusterpool_size", false))
+	assert.False(t, opts.ClusterPoolCapacity)
+}
+
+func TestDescribeCovers(t *testing.T) {
+	opts := DefaultMetricsConfig()
+	for name := range opts.collectorFlags() {
+		opts.SetEnabled(name, true)
+	}
+	opts.ProvisioningUnderwayMin = 0
+	opts.ProvisioningUnderwayOwnedBy = true
+	opts.ProvisioningUnderwayPostInstallDegraded = true
+	opts.ClusterSyncFailing = true
+	opts.FailingSyncSets = true
+	opts.Tenant = &metricsconfig.TenantConfig{AnnotationKey: constants.CreatorAnnotation}
+	opts.ProvisioningSLOs = []metricsconfig.ProvisioningSLO{{Name: "install", Duration: metav1.Duration{Duration: time.Minute}}}
+
+	c := &readCountingClient{Client: testfake.NewFakeClientBuilder().WithRuntimeObjects(pagingTestObjects(20)...).Build()}
+	collectors := NewCollectors(c, opts)
+	for _, collector := range collectors {
+		name := fmt.Sprintf("%T", collector)
+		if timed, ok := collector.(timedCollector); ok {
+			name = timed.name
+		}
+		t.Run(name, func(t *testing.T) {
+			// Collectors are described when they are registered, before there is anything to read, so Describe must
+			// not depend on what Collect would report.
+			c.reads = 0
+			describedDescs(collector)
+			assert.Zero(t, c.reads, "expected Describe not to read any objects")
+			AssertDescribeCovers(t, collector)
+		})
+	}
+}
+
+// readCountingClient counts the objects listed and read through it.
+type readCountingClient struct {
+	client.Client
+	reads int
+}
+
+func (c *readCountingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	c.reads++
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func (c *readCountingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.reads++
+	return c.Client.List(ctx, list, opts...)
+}
+
+// delayingClient delays every List request, failing it afterwards if err is set.
+type delayingClient struct {
+	client.Client
+	delay time.Duration
+	err   error
+}
+
+func (c *delayingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	time.Sleep(c.delay)
+	if c.err != nil {
+		return c.err
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func TestCollectorScrapeDuration(t *testing.T) {
+	scheme := scheme.GetScheme()
+	const delay = 50 * time.Millisecond
+
+	cases := []struct {
+		name string
+		err  error
+	}{{
+		name: "list succeeds",
+	}, {
+		name: "list fails",
+		err:  errors.New("list refused"),
+	}}
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			c := &delayingClient{
+				Client: testfake.NewFakeClientBuilder().WithRuntimeObjects(
+					testcd.FullBuilder("cd-1", "cd-1", scheme).Build(),
+				).Build(),
+				delay: delay,
+				err:   test.err,
+			}
+			collectors := NewCollectors(c, MetricsConfig{Creator: true})
+			require.Len(t, collectors, 1)
+			metricCollectorScrapeDurationSeconds.WithLabelValues("creatorCollector").Set(0)
+
+			collectMetricsRaw(t, collectors[0])
+			assert.GreaterOrEqual(t, testutil.ToFloat64(metricCollectorScrapeDurationSeconds.WithLabelValues("creatorCollector")), delay.Seconds(),
+				"expected the scrape duration to include the delayed List")
+		})
+	}
+}
+
+// failingRegisterer refuses every collector registered with it.
+type failingRegisterer struct {
+	prometheus.Registerer
+	attempts int
+}
+
+func (r *failingRegisterer) Register(prometheus.Collector) error {
+	r.attempts++
+	return errors.New("registration refused")
+}
+
+func TestRegisterCollectorsErrors(t *testing.T) {
+	c := testfake.NewFakeClientBuilder().Build()
+	registry := &failingRegisterer{}
+	err := RegisterCollectors(registry, c, MetricsConfig{
+		Creator:                     true,
+		FIPS:                        true,
+		PullSecretExpiring:          true,
+		PullSecretExpiringThreshold: time.Hour,
+	})
+	require.Error(t, err)
+	assert.Equal(t, 3, registry.attempts, "expected every enabled collector to be attempted")
+	var aggregate interface{ Errors() []error }
+	require.True(t, errors.As(err, &aggregate), "expected an aggregate error")
+	assert.Len(t, aggregate.Errors(), 3)
+}
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/openshift/hive/pkg/constants"
+)
+
+// GetClusterDeprovisionPlatform returns the platform the ClusterDeprovision removes a cluster from, or "unknown" if it
+// names none.
+func GetClusterDeprovisionPlatform(deprovision *hivev1.ClusterDeprovision) string {
+	switch p := deprovision.Spec.Platform; {
+	case p.AlibabaCloud != nil:
+		return constants.PlatformAlibabaCloud
+	case p.AWS != nil:
+		return constants.PlatformAWS
+	case p.Azure != nil:
+		return constants.PlatformAzure
+	case p.GCP != nil:
+		return constants.PlatformGCP
+	case p.OpenStack != nil:
+		return constants.PlatformOpenStack
+	case p.VSphere != nil:
+		return constants.PlatformVSphere
+	case p.IBMCloud != nil:
+		return constants.PlatformIBMCloud
+	case p.Ovirt != nil:
+		return constants.PlatformOvirt
+	default:
+		return constants.PlatformUnknown
+	}
+}
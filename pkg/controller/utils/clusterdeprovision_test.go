@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/openshift/hive/pkg/constants"
+)
+
+func TestGetClusterDeprovisionPlatform(t *testing.T) {
+	cases := []struct {
+		name     string
+		platform hivev1.ClusterDeprovisionPlatform
+		expected string
+	}{
+		{
+			name:     "aws",
+			platform: hivev1.ClusterDeprovisionPlatform{AWS: &hivev1.AWSClusterDeprovision{Region: "us-east-1"}},
+			expected: constants.PlatformAWS,
+		},
+		{
+			name:     "gcp",
+			platform: hivev1.ClusterDeprovisionPlatform{GCP: &hivev1.GCPClusterDeprovision{Region: "us-east1"}},
+			expected: constants.PlatformGCP,
+		},
+		{
+			name:     "none",
+			expected: constants.PlatformUnknown,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			deprovision := &hivev1.ClusterDeprovision{Spec: hivev1.ClusterDeprovisionSpec{Platform: tc.platform}}
+			assert.Equal(t, tc.expected, GetClusterDeprovisionPlatform(deprovision))
+		})
+	}
+}
@@ -729,6 +729,22 @@ func IsConditionInDesiredState(condition hivev1.ClusterDeploymentCondition) bool
 		(!IsConditionWithPositivePolarity(condition.Type) && condition.Status == corev1.ConditionFalse)
 }
 
+// IsClusterPoolConditionWithPositivePolarity checks if cluster pool condition has positive polarity
+func IsClusterPoolConditionWithPositivePolarity(conditionType hivev1.ClusterPoolConditionType) bool {
+	for _, condition := range hivev1.PositivePolarityClusterPoolConditions {
+		if condition == conditionType {
+			return true
+		}
+	}
+	return false
+}
+
+// IsClusterPoolConditionInDesiredState checks if the cluster pool condition status is in its desired/expected state
+func IsClusterPoolConditionInDesiredState(condition hivev1.ClusterPoolCondition) bool {
+	return (IsClusterPoolConditionWithPositivePolarity(condition.Type) && condition.Status == corev1.ConditionTrue) ||
+		(!IsClusterPoolConditionWithPositivePolarity(condition.Type) && condition.Status == corev1.ConditionFalse)
+}
+
 // AreAllConditionsInDesiredState checks if all cluster deployment conditions are in their desired state
 func AreAllConditionsInDesiredState(conditions []hivev1.ClusterDeploymentCondition) bool {
 	// cluster deployment conditions are sorted to have error conditions at the top
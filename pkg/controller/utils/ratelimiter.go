@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/client-go/util/workqueue"
+
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+)
+
+var (
+	metricControllerRateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hive_controller_rate_limited_total",
+		Help: "Counter incremented each time a reconcile is delayed by the controller's global workqueue rate limiter.",
+	},
+		[]string{"controller"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(metricControllerRateLimited)
+}
+
+// newMetricsRateLimiter wraps the specified workqueue rate limiter such that each delay it imposes is counted
+// against the controller in hive_controller_rate_limited_total.
+func newMetricsRateLimiter(rateLimiter workqueue.RateLimiter, controllerName hivev1.ControllerName) workqueue.RateLimiter {
+	return &metricsRateLimiter{
+		RateLimiter:    rateLimiter,
+		controllerName: controllerName,
+	}
+}
+
+// metricsRateLimiter counts the delays imposed by the wrapped rate limiter.
+type metricsRateLimiter struct {
+	workqueue.RateLimiter
+
+	controllerName hivev1.ControllerName
+}
+
+var _ workqueue.RateLimiter = &metricsRateLimiter{}
+
+// When implements workqueue.RateLimiter
+func (r *metricsRateLimiter) When(item interface{}) time.Duration {
+	delay := r.RateLimiter.When(item)
+	if delay > 0 {
+		metricControllerRateLimited.WithLabelValues(r.controllerName.String()).Inc()
+	}
+	return delay
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+
+	"k8s.io/client-go/util/workqueue"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+)
+
+func TestMetricsRateLimiter(t *testing.T) {
+	const controllerName hivev1.ControllerName = "test-controller"
+	counter := metricControllerRateLimited.WithLabelValues(controllerName.String())
+	before := testutil.ToFloat64(counter)
+
+	// A bucket with a burst of 2 and a negligible refill rate lets two reconciles through before delaying the rest.
+	rl := newMetricsRateLimiter(&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(0.001), 2)}, controllerName)
+	delays := 0
+	for i := 0; i < 5; i++ {
+		if rl.When(i) > 0 {
+			delays++
+		}
+	}
+
+	assert.Equal(t, 3, delays, "unexpected number of rate limited reconciles")
+	assert.Equal(t, before+3, testutil.ToFloat64(counter), "unexpected rate limited count")
+	assert.Equal(t, float64(0), testutil.ToFloat64(metricControllerRateLimited.WithLabelValues("other-controller")), "unexpected rate limited count for other controller")
+}
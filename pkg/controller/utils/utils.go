@@ -145,7 +145,8 @@ func getQueueRateLimiter(controllerName hivev1.ControllerName) (workqueue.RateLi
 
 	return workqueue.NewMaxOfRateLimiter(
 		workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 1000*time.Second),
-		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(qps), burst)},
+		// Only the bucket is shared across all items, so only its delays count as the controller being rate limited.
+		newMetricsRateLimiter(&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(qps), burst)}, controllerName),
 	), nil
 }
 
@@ -213,6 +214,12 @@ func GetChecksumOfObjects(objects ...interface{}) (string, error) {
 	return GetChecksumOfObject(objects)
 }
 
+// GetInstallConfigChecksum returns a checksum of the data in an install-config secret. It is recorded on each
+// ClusterProvision and compared with the current secret to detect changes made after the provision started.
+func GetInstallConfigChecksum(installConfigSecret *corev1.Secret) (string, error) {
+	return GetChecksumOfObject(installConfigSecret.Data)
+}
+
 // DNSZoneName returns the predictable name for a DNSZone for the given ClusterDeployment.
 func DNSZoneName(cdName string) string {
 	return apihelpers.GetResourceName(cdName, "zone")
@@ -267,7 +267,7 @@ func TestGetQueueRateLimiter(t *testing.T) {
 			environmentVariables: map[string]string{},
 			expectedRateLimiter: workqueue.NewMaxOfRateLimiter(
 				workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 1000*time.Second),
-				&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(defaultQueueQPS), defaultQueueBurst)},
+				newMetricsRateLimiter(&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(defaultQueueQPS), defaultQueueBurst)}, testControllerName),
 			),
 		},
 		{
@@ -278,7 +278,7 @@ func TestGetQueueRateLimiter(t *testing.T) {
 			},
 			expectedRateLimiter: workqueue.NewMaxOfRateLimiter(
 				workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 1000*time.Second),
-				&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(500), 1000)},
+				newMetricsRateLimiter(&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(500), 1000)}, testControllerName),
 			),
 		},
 		{
@@ -289,7 +289,7 @@ func TestGetQueueRateLimiter(t *testing.T) {
 			},
 			expectedRateLimiter: workqueue.NewMaxOfRateLimiter(
 				workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 1000*time.Second),
-				&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(500), 1000)},
+				newMetricsRateLimiter(&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(500), 1000)}, testControllerName),
 			),
 		},
 		{
@@ -302,7 +302,7 @@ func TestGetQueueRateLimiter(t *testing.T) {
 			},
 			expectedRateLimiter: workqueue.NewMaxOfRateLimiter(
 				workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 1000*time.Second),
-				&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(501), 1001)},
+				newMetricsRateLimiter(&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(501), 1001)}, testControllerName),
 			),
 		},
 		{
@@ -13,6 +13,8 @@ import (
 	hivev1azure "github.com/openshift/hive/apis/hive/v1/azure"
 	hivev1gcp "github.com/openshift/hive/apis/hive/v1/gcp"
 	hivev1ibmcloud "github.com/openshift/hive/apis/hive/v1/ibmcloud"
+	hivev1openstack "github.com/openshift/hive/apis/hive/v1/openstack"
+	hivev1vsphere "github.com/openshift/hive/apis/hive/v1/vsphere"
 	"github.com/openshift/hive/pkg/constants"
 	"github.com/openshift/hive/pkg/test/generic"
 )
@@ -239,6 +241,20 @@ func WithAlibabaCloudPlatform(platform *hivev1alibabacloud.Platform) Option {
 	}
 }
 
+// WithOpenStackPlatform sets the specified OpenStack platform on the cd.
+func WithOpenStackPlatform(platform *hivev1openstack.Platform) Option {
+	return func(clusterDeployment *hivev1.ClusterDeployment) {
+		clusterDeployment.Spec.Platform.OpenStack = platform
+	}
+}
+
+// WithVSpherePlatform sets the specified vSphere platform on the cd.
+func WithVSpherePlatform(platform *hivev1vsphere.Platform) Option {
+	return func(clusterDeployment *hivev1.ClusterDeployment) {
+		clusterDeployment.Spec.Platform.VSphere = platform
+	}
+}
+
 // WithClusterMetadata sets the specified cluster metadata on the cd.
 func WithClusterMetadata(clusterMetadata *hivev1.ClusterMetadata) Option {
 	return func(clusterDeployment *hivev1.ClusterDeployment) {
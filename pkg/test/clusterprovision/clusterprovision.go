@@ -171,6 +171,12 @@ func WithJob(jobName string) Option {
 	}
 }
 
+func WithInstallLog(installLog string) Option {
+	return func(clusterProvision *hivev1.ClusterProvision) {
+		clusterProvision.Spec.InstallLog = &installLog
+	}
+}
+
 func WithMetadata(md string) Option {
 	return func(clusterProvision *hivev1.ClusterProvision) {
 		clusterProvision.Spec.MetadataJSON = ([]byte)(md)
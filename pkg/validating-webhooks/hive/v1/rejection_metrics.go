@@ -0,0 +1,39 @@
+package v1
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+
+	"github.com/openshift/generic-admission-server/pkg/apiserver"
+)
+
+// MetricAdmissionRejectionsTotal is a prometheus metric counting the requests rejected by Hive's validating admission
+// webhooks, by the resource of the object and the operation requested. The admission server serves it from its own
+// metrics endpoint.
+var MetricAdmissionRejectionsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "hive_admission_rejections_total",
+		Help: "Total number of requests rejected by Hive's validating admission webhooks.",
+	},
+	[]string{"resource", "operation"})
+
+// rejectionCountingHook is a validating admission hook counting the requests it rejects.
+type rejectionCountingHook struct {
+	apiserver.ValidatingAdmissionHookV1Beta1
+}
+
+// WithRejectionMetrics returns hook, counting the requests it rejects in hive_admission_rejections_total by the
+// resource of the object and the operation requested.
+func WithRejectionMetrics(hook apiserver.ValidatingAdmissionHookV1Beta1) apiserver.ValidatingAdmissionHookV1Beta1 {
+	return rejectionCountingHook{ValidatingAdmissionHookV1Beta1: hook}
+}
+
+// Validate validates the request with the wrapped hook, counting it if it is rejected.
+func (h rejectionCountingHook) Validate(admissionSpec *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	response := h.ValidatingAdmissionHookV1Beta1.Validate(admissionSpec)
+	if response != nil && !response.Allowed {
+		MetricAdmissionRejectionsTotal.WithLabelValues(admissionSpec.Resource.Resource, string(admissionSpec.Operation)).Inc()
+	}
+	return response
+}
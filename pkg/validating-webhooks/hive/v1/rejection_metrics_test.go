@@ -0,0 +1,69 @@
+package v1
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+)
+
+func TestRejectionMetrics(t *testing.T) {
+	imageSetRequest := func(spec hivev1.ClusterImageSetSpec) *admissionv1beta1.AdmissionRequest {
+		raw, err := json.Marshal(&hivev1.ClusterImageSet{Spec: spec})
+		require.NoError(t, err)
+		return &admissionv1beta1.AdmissionRequest{
+			Operation: admissionv1beta1.Create,
+			Kind:      metav1.GroupVersionKind{Group: "hive.openshift.io", Version: "v1", Kind: "ClusterImageSet"},
+			Resource:  metav1.GroupVersionResource{Group: "hive.openshift.io", Version: "v1", Resource: "clusterimagesets"},
+			Object:    runtime.RawExtension{Raw: raw},
+		}
+	}
+	poolRequest := func(pool *hivev1.ClusterPool) *admissionv1beta1.AdmissionRequest {
+		raw, err := json.Marshal(pool)
+		require.NoError(t, err)
+		return &admissionv1beta1.AdmissionRequest{
+			Operation: admissionv1beta1.Create,
+			Kind:      metav1.GroupVersionKind{Group: "hive.openshift.io", Version: "v1", Kind: "ClusterPool"},
+			Resource:  metav1.GroupVersionResource{Group: "hive.openshift.io", Version: "v1", Resource: "clusterpools"},
+			Object:    runtime.RawExtension{Raw: raw},
+		}
+	}
+	rejections := func(resource string, operation admissionv1beta1.Operation) float64 {
+		return testutil.ToFloat64(MetricAdmissionRejectionsTotal.WithLabelValues(resource, string(operation)))
+	}
+
+	imageSets := WithRejectionMetrics(NewClusterImageSetValidatingAdmissionHook(createDecoder(t)))
+	pools := WithRejectionMetrics(NewClusterPoolValidatingAdmissionHook(createDecoder(t)))
+	imageSetCreates := rejections("clusterimagesets", admissionv1beta1.Create)
+	imageSetUpdates := rejections("clusterimagesets", admissionv1beta1.Update)
+	poolCreates := rejections("clusterpools", admissionv1beta1.Create)
+
+	// Allowed requests are not counted.
+	assert.True(t, imageSets.Validate(imageSetRequest(hivev1.ClusterImageSetSpec{ReleaseImage: "image@sha256:abc"})).Allowed)
+	assert.True(t, pools.Validate(poolRequest(validAWSClusterPool())).Allowed)
+	assert.Equal(t, imageSetCreates, rejections("clusterimagesets", admissionv1beta1.Create))
+	assert.Equal(t, poolCreates, rejections("clusterpools", admissionv1beta1.Create))
+
+	// Invalid specs.
+	assert.False(t, imageSets.Validate(imageSetRequest(hivev1.ClusterImageSetSpec{})).Allowed)
+	assert.False(t, imageSets.Validate(imageSetRequest(hivev1.ClusterImageSetSpec{ReleaseImage: "image@SHA256:abc"})).Allowed)
+	assert.Equal(t, imageSetCreates+2, rejections("clusterimagesets", admissionv1beta1.Create))
+	assert.False(t, pools.Validate(poolRequest(invalidOpenStackClusterPool())).Allowed)
+	assert.Equal(t, poolCreates+1, rejections("clusterpools", admissionv1beta1.Create))
+
+	// An object that can't be decoded, on update.
+	undecodable := imageSetRequest(hivev1.ClusterImageSetSpec{})
+	undecodable.Operation = admissionv1beta1.Update
+	undecodable.Object.Raw = []byte{0}
+	assert.False(t, imageSets.Validate(undecodable).Allowed)
+	assert.Equal(t, imageSetUpdates+1, rejections("clusterimagesets", admissionv1beta1.Update))
+	assert.Equal(t, imageSetCreates+2, rejections("clusterimagesets", admissionv1beta1.Create))
+}